@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/config"
+)
+
+// apiClient talks to the running daemon for commands that act on its
+// in-memory state (tunnels, processes, ...)
+var apiClient = &http.Client{Timeout: 60 * time.Second}
+
+// apiURL resolves an API path against the configured daemon address
+func apiURL(path string) (string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+	return cfg.APIURL() + path, nil
+}
+
+// apiGet fetches path and decodes the JSON response into out
+func apiGet(path string, out interface{}) error {
+	url, err := apiURL(path)
+	if err != nil {
+		return err
+	}
+	resp, err := apiClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("daemon not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	return decodeAPIResponse(resp, out)
+}
+
+// apiPost sends body as JSON to path and decodes the response into out
+func apiPost(path string, body, out interface{}) error {
+	url, err := apiURL(path)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := apiClient.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("daemon not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	return decodeAPIResponse(resp, out)
+}
+
+func decodeAPIResponse(resp *http.Response, out interface{}) error {
+	if resp.StatusCode >= 400 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&e); err == nil && e.Error != "" {
+			return fmt.Errorf("%s", e.Error)
+		}
+		return fmt.Errorf("daemon returned %s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
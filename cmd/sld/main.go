@@ -3,18 +3,24 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/spf13/cobra"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/config"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/api"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/project"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/services"
 )
 
 var rootCmd = &cobra.Command{
@@ -132,7 +138,7 @@ func elevate() error {
 		return err
 	}
 
-	cmd := exec.Command("sudo", append([]string{exe}, os.Args[1:]...)...)
+	cmd := sudoCommand(append([]string{exe}, os.Args[1:]...)...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -140,9 +146,18 @@ func elevate() error {
 	return cmd.Run()
 }
 
+// sudoCommand builds a sudo invocation that keeps SLD_HOME, so elevated
+// runs operate on the same instance
+func sudoCommand(args ...string) *exec.Cmd {
+	if os.Getenv("SLD_HOME") != "" {
+		args = append([]string{"--preserve-env=SLD_HOME"}, args...)
+	}
+	return exec.Command("sudo", args...)
+}
+
 // isInstalled checks if SLD has been configured on the system
 func isInstalled() bool {
-	_, err := os.Stat("/var/lib/sld/state.json")
+	_, err := os.Stat(config.NewPaths(config.ResolveHome()).State)
 	return err == nil
 }
 
@@ -158,7 +173,7 @@ func autoInstall() bool {
 		exe = "sld" // Fallback
 	}
 
-	cmd := exec.Command("sudo", exe, "install")
+	cmd := sudoCommand(exe, "install")
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -180,9 +195,11 @@ func main() {
 	// Auto-detect missing installation for commands that need it
 	if len(os.Args) > 1 {
 		cmd := os.Args[1]
-		// Skip check for install, help, version, and completion commands
+		// Skip check for install, help, version, and completion commands,
+		// and for validate so CI can lint .sld.yaml without an install
 		skipCheck := cmd == "install" || cmd == "--help" || cmd == "-h" ||
-			cmd == "--version" || cmd == "-v" || cmd == "help" || cmd == "completion"
+			cmd == "--version" || cmd == "-v" || cmd == "help" || cmd == "completion" ||
+			cmd == "validate"
 
 		if !skipCheck && !isInstalled() {
 			if !autoInstall() {
@@ -214,6 +231,7 @@ After=network.target nginx.service
 [Service]
 Type=simple
 Environment=SUDO_USER=%s
+Environment=SLD_HOME=%s
 ExecStart=%s daemon
 Restart=on-failure
 RestartSec=5
@@ -222,7 +240,7 @@ StandardError=journal
 
 [Install]
 WantedBy=multi-user.target
-`, os.Getenv("SUDO_USER"), exePath)
+`, os.Getenv("SUDO_USER"), config.ResolveHome(), exePath)
 
 	servicePath := "/etc/systemd/system/sld-daemon.service"
 	if err := os.WriteFile(servicePath, []byte(serviceContent), 0644); err != nil {
@@ -284,6 +302,71 @@ func init() {
 	dbCmd.AddCommand(dbCloneCmd)
 	dbCmd.AddCommand(dbSnapshotCmd)
 
+	// Environment export/import
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringSliceP("snapshot", "s", nil, "Database snapshot to include (repeatable)")
+	exportCmd.Flags().Bool("all-snapshots", false, "Include every database snapshot")
+	rootCmd.AddCommand(importCmd)
+
+	rootCmd.AddCommand(configCmd)
+
+	// Operation journal
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().IntP("limit", "n", 20, "Number of entries to show")
+	rootCmd.AddCommand(undoCmd)
+
+	// Workspaces
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceListCmd)
+	workspaceCmd.AddCommand(workspaceCreateCmd)
+	workspaceCreateCmd.Flags().Bool("copy", false, "Start with the active workspace's paths, links and site configs")
+	workspaceCmd.AddCommand(workspaceUseCmd)
+	workspaceCmd.AddCommand(workspaceDeleteCmd)
+
+	// .sld.yaml linting
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().Bool("json", false, "Print the result as JSON")
+	validateCmd.Flags().Bool("schema", false, "Print the .sld.yaml JSON Schema and exit")
+
+	// Single site details
+	rootCmd.AddCommand(siteCmd)
+	siteCmd.AddCommand(siteInfoCmd)
+
+	// Worker processes
+	rootCmd.AddCommand(procCmd)
+	procCmd.AddCommand(procStartCmd)
+	procCmd.AddCommand(procStopCmd)
+	procCmd.AddCommand(procRestartCmd)
+	procCmd.AddCommand(procStatusCmd)
+	procCmd.AddCommand(procLogsCmd)
+	procLogsCmd.Flags().IntP("lines", "n", 100, "Number of lines to show")
+
+	// Built-in DNS
+	rootCmd.AddCommand(dnsCmd)
+	dnsCmd.AddCommand(dnsStatusCmd)
+	dnsStatusCmd.Flags().Bool("json", false, "Print the status as JSON")
+
+	// Certificate inventory
+	rootCmd.AddCommand(certsCmd)
+	certsCmd.Flags().Bool("json", false, "Print the inventory as JSON")
+
+	// PHP extensions and php.ini
+	phpCmd.PersistentFlags().String("php", "", "PHP version to manage (default: the global version)")
+	phpCmd.AddCommand(phpExtCmd)
+	phpExtCmd.AddCommand(phpExtListCmd)
+	phpExtListCmd.Flags().Bool("json", false, "Print the extensions as JSON")
+	phpExtCmd.AddCommand(phpExtEnableCmd)
+	phpExtCmd.AddCommand(phpExtDisableCmd)
+	phpCmd.AddCommand(phpIniCmd)
+	phpIniCmd.AddCommand(phpIniGetCmd)
+	phpIniCmd.AddCommand(phpIniSetCmd)
+	phpIniCmd.AddCommand(phpIniUnsetCmd)
+
+	// Reverse-proxy sites
+	rootCmd.AddCommand(proxyCmd)
+	proxyCmd.Flags().Bool("secure", false, "Serve the site over HTTPS")
+	rootCmd.AddCommand(unproxyCmd)
+
 	// Sites with filtering
 	rootCmd.AddCommand(sitesCmd)
 	sitesCmd.Flags().StringP("tag", "t", "", "Filter sites by tag")
@@ -310,20 +393,24 @@ var unparkCmd = &cobra.Command{
 }
 
 var unsecureCmd = &cobra.Command{
-	Use:   "unsecure",
-	Short: "Disable HTTPS and revert to HTTP",
+	Use:   "unsecure [site]",
+	Short: "Disable HTTPS and revert to HTTP, for all sites or one site",
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		d, err := daemon.GetClient()
 		if err != nil {
 			return err
 		}
+		if len(args) == 1 {
+			return d.UnsecureSite(args[0])
+		}
 		return d.Unsecure()
 	},
 }
 
 var restartCmd = &cobra.Command{
 	Use:   "restart",
-	Short: "Restart Nginx, PHP, and Dnsmasq services",
+	Short: "Restart Nginx and PHP, and re-apply the DNS resolver setup",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		d, err := daemon.GetClient()
 		if err != nil {
@@ -360,7 +447,7 @@ var doctorCmd = &cobra.Command{
 var logsCmd = &cobra.Command{
 	Use:   "logs [service]",
 	Short: "View logs for a service (nginx, php)",
-	Long:  `Available services: nginx-error, nginx-access, php-fpm`,
+	Long:  `Available services: nginx-error, nginx-access, xray, php-fpm`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		d, err := daemon.GetClient()
 		if err != nil {
@@ -375,7 +462,7 @@ var logsCmd = &cobra.Command{
 		paths := d.GetLogPaths()
 		logPath, ok := paths[key]
 		if !ok {
-			return fmt.Errorf("unknown log service: %s. Available: nginx-error, nginx-access, php-fpm", key)
+			return fmt.Errorf("unknown log service: %s. Available: nginx-error, nginx-access, xray, php-fpm", key)
 		}
 
 		fmt.Printf("Tailing log: %s\n", logPath)
@@ -428,8 +515,27 @@ var daemonCmd = &cobra.Command{
 			return err
 		}
 
+		// Journal entries made from here come from the API/dashboard
+		d.State.Source = "daemon"
+
 		// Start Server
-		srv := api.NewServer(2025)
+		srv := api.NewServer(d.Config.BindAddress, d.Config.APIPort)
+
+		// Follow state changes made by the CLI or other tools
+		if err := d.WatchState(); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+
+		// Keep supervised worker processes running
+		d.StartSupervisor()
+
+		// Resolve *.<tld> ourselves
+		if err := d.StartDNS(); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+
+		// Renew certificates before they expire
+		d.StartCertRenewal()
 
 		// Sync state on startup
 		go func() {
@@ -450,6 +556,9 @@ var daemonCmd = &cobra.Command{
 			if d.XRayService != nil {
 				d.XRayService.Stop()
 			}
+			d.StopWatchingState()
+			d.Supervisor.StopAll()
+			d.StopDNS()
 			os.Exit(0)
 		}()
 
@@ -612,15 +721,179 @@ var phpCmd = &cobra.Command{
 	},
 }
 
+var phpExtCmd = &cobra.Command{
+	Use:   "ext",
+	Short: "List, enable and disable PHP extensions",
+}
+
+var phpExtListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the extensions of a PHP version and where they come from",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+		version, _ := cmd.Flags().GetString("php")
+		extensions, err := d.PHPExtensions(version)
+		if err != nil {
+			return err
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			out, _ := json.MarshalIndent(extensions, "", "  ")
+			fmt.Println(string(out))
+			return nil
+		}
+		for _, ext := range extensions {
+			icon, detail := "⚪", "installed, not enabled"
+			switch {
+			case ext.Builtin:
+				icon, detail = "🟢", "built in"
+			case ext.Loaded:
+				icon, detail = "🟢", ext.IniFile
+			}
+			if ext.Package != "" {
+				detail += " [" + ext.Package + "]"
+			}
+			fmt.Printf(" %s %-14s %s\n", icon, ext.Name, detail)
+		}
+		return nil
+	},
+}
+
+var phpExtEnableCmd = &cobra.Command{
+	Use:   "enable <extension>...",
+	Short: "Enable PHP extensions, installing their packages when needed",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+		version, _ := cmd.Flags().GetString("php")
+		for _, name := range args {
+			if err := d.EnablePHPExtension(version, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var phpExtDisableCmd = &cobra.Command{
+	Use:   "disable <extension>...",
+	Short: "Disable PHP extensions (their packages stay installed)",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+		version, _ := cmd.Flags().GetString("php")
+		for _, name := range args {
+			if err := d.DisablePHPExtension(version, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var phpIniCmd = &cobra.Command{
+	Use:   "ini",
+	Short: "Read and override php.ini settings",
+}
+
+var phpIniGetCmd = &cobra.Command{
+	Use:   "get [directive]...",
+	Short: "Show php.ini settings as PHP-FPM sees them (default: the ones SLD overrides)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+		version, _ := cmd.Flags().GetString("php")
+		ini, err := d.PHPIniSettings(version)
+		if err != nil {
+			return err
+		}
+
+		names := args
+		if len(names) == 0 {
+			for name := range ini.Overrides {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if len(names) == 0 {
+				fmt.Printf("No php.ini overrides for PHP %s. Set one with `sld php ini set <directive> <value>`.\n", ini.Version)
+				return nil
+			}
+		}
+		for _, name := range names {
+			value, ok := ini.Values[name]
+			if !ok {
+				return fmt.Errorf("PHP %s has no %s setting", ini.Version, name)
+			}
+			note := ""
+			if _, overridden := ini.Overrides[name]; overridden {
+				note = " (set by SLD)"
+			}
+			fmt.Printf("%s = %s%s\n", name, value, note)
+		}
+		return nil
+	},
+}
+
+var phpIniSetCmd = &cobra.Command{
+	Use:   "set <directive> <value>",
+	Short: "Override a php.ini setting and restart PHP-FPM",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+		version, _ := cmd.Flags().GetString("php")
+		if err := d.SetPHPIni(version, args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s = %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+var phpIniUnsetCmd = &cobra.Command{
+	Use:   "unset <directive>",
+	Short: "Drop an override, going back to the php.ini value",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+		version, _ := cmd.Flags().GetString("php")
+		if err := d.UnsetPHPIni(version, args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s is back to its php.ini value\n", args[0])
+		return nil
+	},
+}
+
 var secureCmd = &cobra.Command{
-	Use:   "secure",
-	Short: "Enable HTTPS (installs mkcert and updates config)",
+	Use:   "secure [site]",
+	Short: "Enable HTTPS for all sites, or for one site with its own certificate",
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		d, err := daemon.GetClient()
 		if err != nil {
 			return err
 		}
 
+		if len(args) == 1 {
+			return d.SecureSite(args[0])
+		}
 		return d.Secure()
 	},
 }
@@ -639,11 +912,13 @@ var parkCmd = &cobra.Command{
 			return err
 		}
 
-		if err := d.Park(path); err != nil {
+		streamHookOutput(d)
+		runs, err := d.Park(path)
+		if err != nil {
 			return err
 		}
 		fmt.Printf("Parked directory: %s\n", path)
-		return nil
+		return reportHooks(runs)
 	},
 }
 
@@ -704,11 +979,13 @@ var linkCmd = &cobra.Command{
 			return err
 		}
 
-		if err := d.Link(name, path); err != nil {
+		streamHookOutput(d)
+		runs, err := d.Link(name, path)
+		if err != nil {
 			return err
 		}
 		fmt.Printf("Linked http://%s.test to %s\n", name, path)
-		return nil
+		return reportHooks(runs)
 	},
 }
 
@@ -729,14 +1006,197 @@ var unlinkCmd = &cobra.Command{
 			return err
 		}
 
-		if err := d.Unlink(name); err != nil {
+		streamHookOutput(d)
+		runs, err := d.Unlink(name)
+		if err != nil {
 			return err
 		}
 		fmt.Printf("Unlinked %s\n", name)
+		return reportHooks(runs)
+	},
+}
+
+var dnsCmd = &cobra.Command{
+	Use:   "dns",
+	Short: "Inspect the built-in DNS server that resolves site domains",
+}
+
+var dnsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check that site domains resolve through the SLD DNS server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+		status := d.DNSStatus()
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			out, _ := json.MarshalIndent(status, "", "  ")
+			fmt.Println(string(out))
+			return nil
+		}
+
+		if !status.Enabled {
+			fmt.Println("DNS server: disabled (set dns_port in config.yaml to enable it)")
+			return nil
+		}
+		mark := func(ok bool) string {
+			if ok {
+				return "🟢"
+			}
+			return "🔴"
+		}
+		upstreams := "none (only site domains resolve)"
+		if len(status.Upstreams) > 0 {
+			upstreams = strings.Join(status.Upstreams, ", ")
+		}
+		fmt.Printf("Server:     %s %s\n", mark(status.Listening), status.Addr)
+		fmt.Printf("Answers:    *.%s -> 127.0.0.1, ::1\n", status.TLD)
+		fmt.Printf("Upstreams:  %s\n", upstreams)
+		routed := "reach SLD"
+		if !status.Resolving {
+			routed = "don't reach SLD"
+		}
+		fmt.Printf("System:     %s .%s lookups %s\n", mark(status.Resolving), status.TLD, routed)
+		if status.Error != "" {
+			return fmt.Errorf("%s", status.Error)
+		}
 		return nil
 	},
 }
 
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "List the certificates SLD manages, their expiry and the names they cover",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+		certs, err := d.Certificates()
+		if err != nil {
+			return err
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			out, _ := json.MarshalIndent(certs, "", "  ")
+			fmt.Println(string(out))
+			return nil
+		}
+
+		if len(certs) == 0 {
+			fmt.Println("No certificates yet. Run `sld secure` to enable HTTPS.")
+			return nil
+		}
+		for _, c := range certs {
+			icon := "🟢"
+			switch c.Status {
+			case daemon.CertExpiring:
+				icon = "🟡"
+			case daemon.CertExpired, daemon.CertMismatch, daemon.CertForeign, daemon.CertInvalid:
+				icon = "🔴"
+			}
+			name := c.Domain
+			if name == "" {
+				name = c.Kind
+			}
+			unused := ""
+			if !c.InUse {
+				unused = " (not served)"
+			}
+			fmt.Printf("%s %s%s\n", icon, name, unused)
+			fmt.Printf("   Path:    %s\n", c.Path)
+			if c.Error != "" {
+				fmt.Printf("   Error:   %s\n", c.Error)
+				continue
+			}
+			fmt.Printf("   Issuer:  %s\n", c.Issuer)
+			fmt.Printf("   Names:   %s\n", strings.Join(c.SANs, ", "))
+			fmt.Printf("   Expires: %s (%d days, %s)\n", c.NotAfter.Format("2006-01-02"), c.DaysLeft, c.Status)
+			if len(c.Missing) > 0 {
+				fmt.Printf("   Missing: %s\n", strings.Join(c.Missing, ", "))
+			}
+		}
+
+		trust, err := d.CATrust()
+		if err != nil {
+			return err
+		}
+		fmt.Println("\nCA trusted by:")
+		for _, t := range trust {
+			if t.Trusted {
+				fmt.Printf("   ✓ %-8s %s\n", t.Store, t.Path)
+			} else if t.Error != "" {
+				fmt.Printf("   ✗ %-8s %s: %s\n", t.Store, t.Path, t.Error)
+			} else {
+				fmt.Printf("   ✗ %-8s %s\n", t.Store, t.Path)
+			}
+		}
+		return nil
+	},
+}
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy <name> <url>",
+	Short: "Serve <name>.test by reverse-proxying to a local server (e.g. http://127.0.0.1:3000)",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+		secure, _ := cmd.Flags().GetBool("secure")
+		domain, err := d.Proxy(args[0], args[1], secure)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Proxying %s -> %s\n", domain, d.State.Data.SiteConfigs[domain].Proxy)
+		return nil
+	},
+}
+
+var unproxyCmd = &cobra.Command{
+	Use:   "unproxy <name>",
+	Short: "Stop serving a proxy site",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := d.Unproxy(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Removed proxy %s\n", args[0])
+		return nil
+	},
+}
+
+// streamHookOutput prints lifecycle hook output as it happens
+func streamHookOutput(d *daemon.Daemon) {
+	d.Events.Subscribe(events.HookOutput, func(e events.Event) {
+		if out, ok := e.Payload.(services.HookOutput); ok {
+			fmt.Printf("  [%s] %s\n", out.Event, out.Line)
+		}
+	})
+}
+
+// reportHooks summarises hook runs and fails the command if any hook failed
+func reportHooks(runs []services.HookRun) error {
+	failed := services.FailedHooks(runs)
+	if len(runs) > 0 && len(failed) == 0 {
+		fmt.Printf("🪝 Ran %d hook(s)\n", len(runs))
+	}
+	for _, r := range failed {
+		fmt.Printf("❌ %s hook failed: %s (%s)\n", r.Event, r.Command, r.Error)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d hook(s) failed", len(failed))
+	}
+	return nil
+}
+
 var linksCmd = &cobra.Command{
 	Use:   "links",
 	Short: "List all linked sites",
@@ -768,30 +1228,12 @@ var shareCmd = &cobra.Command{
 
 		fmt.Printf("Starting tunnel for %s... 🚀\n", name)
 
-		// Call API
-		// We need a helper to call API from CLI properly
-		// For now simple http post
-		url := "http://localhost:2025/api/share/start"
-		body := fmt.Sprintf(`{"site":"%s"}`, name)
-
-		resp, err := http.Post(url, "application/json", strings.NewReader(body))
-		if err != nil {
-			return fmt.Errorf("daemon not reachable: %w", err)
-		}
-		defer resp.Body.Close()
-
 		var res struct {
 			Success bool   `json:"success"`
 			Message string `json:"message"`
-			Error   string `json:"error"`
-		}
-
-		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
-			return err
 		}
-
-		if !res.Success {
-			return fmt.Errorf("failed to start tunnel: %s", res.Error)
+		if err := apiPost("/api/share/start", map[string]string{"site": name}, &res); err != nil {
+			return fmt.Errorf("failed to start tunnel: %w", err)
 		}
 
 		fmt.Printf("✅ Tunnel active at: %s\n", res.Message)
@@ -858,6 +1300,472 @@ var dbSnapshotCmd = &cobra.Command{
 	},
 }
 
+// --- Export / Import ---
+
+var exportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Export parks, links, site configs, certs, snapshots and env backups to an archive",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+
+		snapshots, _ := cmd.Flags().GetStringSlice("snapshot")
+		all, _ := cmd.Flags().GetBool("all-snapshots")
+
+		f, err := os.Create(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to create archive: %w", err)
+		}
+		defer f.Close()
+
+		manifest, err := d.Export(f, daemon.ExportOptions{Snapshots: snapshots, AllSnapshots: all})
+		if err != nil {
+			os.Remove(args[0])
+			return fmt.Errorf("export failed: %w", err)
+		}
+
+		fmt.Printf("📦 Exported environment to %s\n", args[0])
+		fmt.Printf("   Sites: %d linked, %d parked paths\n", len(d.State.Data.Links), len(d.State.Data.Paths))
+		fmt.Printf("   Certificates: %d, Snapshots: %d, Env backup sets: %d\n",
+			len(manifest.Certs), len(manifest.Snapshots), len(manifest.EnvBackups))
+		return nil
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Restore an environment archive created by 'sld export'",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer f.Close()
+
+		result, err := d.Import(f)
+		if err != nil {
+			return fmt.Errorf("import failed: %w", err)
+		}
+
+		if result.PathsChanged {
+			fmt.Printf("Rewrote project paths: %s -> %s\n", result.FromHome, result.ToHome)
+		}
+		for _, w := range result.Warnings {
+			fmt.Printf("⚠️  %s\n", w)
+		}
+		fmt.Printf("✅ Imported %d sites, %d certificates, %d snapshots, %d env backups\n",
+			result.Sites, result.Certs, result.Snapshots, result.EnvBackups)
+		return nil
+	},
+}
+
+// --- Config ---
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show the effective daemon configuration",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		paths := cfg.Paths()
+
+		fmt.Printf("Config files:  %s, %s\n", config.SystemConfigFile, filepath.Join(cfg.Home, "config.yaml"))
+		fmt.Printf("Home:          %s\n", cfg.Home)
+		fmt.Printf("API:           %s (bind %s)\n", cfg.APIURL(), cfg.ListenAddr())
+		fmt.Printf("HTTPS port:    %d\n", cfg.HTTPSPort)
+		fmt.Printf("Log dir:       %s\n", paths.Logs)
+		projects := cfg.ProjectsDir
+		if projects == "" {
+			projects = "(auto-detected)"
+		}
+		fmt.Printf("Projects dir:  %s\n", projects)
+		return nil
+	},
+}
+
+// --- History / Undo ---
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recent changes to parks, links, site configs and settings",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+
+		limit, _ := cmd.Flags().GetInt("limit")
+		entries, err := d.History(limit)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("No recorded changes yet.")
+			return nil
+		}
+
+		for i, e := range entries {
+			marker := ""
+			if e.Reverted {
+				marker = " (undone)"
+			}
+			fmt.Printf("%3d  %s  %-12s %s%s\n", i+1, e.Time.Format("2006-01-02 15:04:05"),
+				fmt.Sprintf("%s/%s", e.User, e.Source), e.Summary, marker)
+		}
+		return nil
+	},
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [n]",
+	Short: "Revert the last n changes (default 1) and regenerate nginx, hosts and certificates",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n := 1
+		if len(args) > 0 {
+			v, err := strconv.Atoi(args[0])
+			if err != nil || v < 1 {
+				return fmt.Errorf("n must be a positive number")
+			}
+			n = v
+		}
+
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+
+		undone, err := d.Undo(n)
+		for _, e := range undone {
+			fmt.Printf("↩️  Reverted: %s\n", e.Summary)
+		}
+		return err
+	},
+}
+
+// --- Workspace Commands ---
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Manage named sets of parked paths, links and site configs",
+}
+
+var workspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List workspaces",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+
+		for _, ws := range d.ListWorkspaces() {
+			marker := "  "
+			if ws.Active {
+				marker = "* "
+			}
+			fmt.Printf("%s%-20s %d paths, %d links\n", marker, ws.Name, ws.Paths, ws.Links)
+		}
+		return nil
+	},
+}
+
+var workspaceCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a new, empty workspace",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+
+		copyActive, _ := cmd.Flags().GetBool("copy")
+		if err := d.CreateWorkspace(args[0], copyActive); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Created workspace %s. Switch to it with: sld workspace use %s\n", args[0], args[0])
+		return nil
+	},
+}
+
+var workspaceUseCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "Switch the live sites to another workspace",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+
+		if err := d.UseWorkspace(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("🔀 Now using workspace %s\n", args[0])
+		return nil
+	},
+}
+
+var workspaceDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete an inactive workspace",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+
+		if err := d.DeleteWorkspace(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("🗑️  Deleted workspace %s\n", args[0])
+		return nil
+	},
+}
+
+// --- Validate Command ---
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Check a project's .sld.yaml (exits non-zero on errors, for CI)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if printSchema, _ := cmd.Flags().GetBool("schema"); printSchema {
+			fmt.Print(string(project.Schema))
+			return nil
+		}
+
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		// Without a local install (e.g. in CI) only the file itself is checked
+		var result *project.ValidationResult
+		var err error
+		if isInstalled() {
+			d, clientErr := daemon.GetClient()
+			if clientErr != nil {
+				return clientErr
+			}
+			result, err = d.ValidateProject(path)
+		} else {
+			result, err = project.Validate(path, project.ValidateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			out, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Println(string(out))
+		} else {
+			for _, issue := range result.Issues {
+				fmt.Printf("%s:%s\n", result.File, issue)
+			}
+			if len(result.Issues) == 0 {
+				fmt.Printf("✅ %s is valid\n", result.File)
+			}
+		}
+
+		if !result.Valid {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%s has errors", result.File)
+		}
+		return nil
+	},
+}
+
+// --- Site Command ---
+
+var siteCmd = &cobra.Command{
+	Use:   "site",
+	Short: "Inspect a single site",
+}
+
+var siteInfoCmd = &cobra.Command{
+	Use:   "info [name]",
+	Short: "Show how a site is served and which PHP version it uses (and why)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := daemon.GetClient()
+		if err != nil {
+			return err
+		}
+
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		} else {
+			cwd, _ := os.Getwd()
+			name = filepath.Base(cwd)
+		}
+
+		info, err := d.SiteInfo(name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("🌐 %s (%s)\n", info.Domain, info.Type)
+		fmt.Printf("   Path:     %s\n", info.Path)
+		fmt.Printf("   Web root: %s\n", info.WebRoot)
+		if info.Driver != "" {
+			fmt.Printf("   Driver:   %s\n", info.Driver)
+		}
+
+		printResolution("🐘 PHP", info.PHP)
+		printResolution("🟢 Node.js", info.Node)
+		return nil
+	},
+}
+
+func printResolution(title string, res daemon.RuntimeResolution) {
+	fmt.Printf("\n%s %s\n", title, res.Chosen)
+	if res.Constraint != "" {
+		fmt.Printf("   Constraint: %s (from %s)\n", res.Constraint, res.Source)
+	}
+	if len(res.Installed) > 0 {
+		fmt.Printf("   Installed:  %s\n", strings.Join(res.Installed, ", "))
+		fmt.Printf("   Satisfying: %s\n", strings.Join(res.Satisfying, ", "))
+	}
+	fmt.Printf("   Why:        %s\n", res.Reason)
+}
+
+// --- Process Supervisor Commands ---
+
+var procCmd = &cobra.Command{
+	Use:   "proc",
+	Short: "Manage a site's worker processes (.sld.yaml processes: or Procfile)",
+}
+
+// procSite returns the site argument, defaulting to the current directory's name
+func procSite(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	cwd, _ := os.Getwd()
+	return filepath.Base(cwd)
+}
+
+// printProcesses lists supervised processes with their state
+func printProcesses(site string, statuses []services.ProcessStatus) {
+	if len(statuses) == 0 {
+		fmt.Printf("No processes running for %s\n", site)
+		return
+	}
+	fmt.Printf("Processes for %s:\n", site)
+	for _, p := range statuses {
+		icon := "🟢"
+		switch p.State {
+		case services.ProcessBackoff:
+			icon = "🟡"
+		case services.ProcessStopped:
+			icon = "⚪"
+		}
+		line := fmt.Sprintf(" %s %-12s %-8s", icon, p.Name, p.State)
+		if p.PID != 0 {
+			line += fmt.Sprintf(" pid %d", p.PID)
+		}
+		if p.Restarts > 0 {
+			line += fmt.Sprintf(" (%d restarts, last %s)", p.Restarts, p.LastExit)
+		}
+		fmt.Printf("%s\n     %s\n", line, p.Command)
+	}
+}
+
+var procStartCmd = &cobra.Command{
+	Use:   "start [site]",
+	Short: "Start a site's processes and keep them running with the daemon",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		site := procSite(args)
+		var statuses []services.ProcessStatus
+		if err := apiPost("/api/proc/start", map[string]string{"site": site}, &statuses); err != nil {
+			return fmt.Errorf("failed to start processes: %w", err)
+		}
+		fmt.Println("✅ Processes started")
+		printProcesses(site, statuses)
+		return nil
+	},
+}
+
+var procStopCmd = &cobra.Command{
+	Use:   "stop [site]",
+	Short: "Stop a site's processes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		site := procSite(args)
+		if err := apiPost("/api/proc/stop", map[string]string{"site": site}, nil); err != nil {
+			return fmt.Errorf("failed to stop processes: %w", err)
+		}
+		fmt.Printf("🛑 Stopped processes for %s\n", site)
+		return nil
+	},
+}
+
+var procRestartCmd = &cobra.Command{
+	Use:   "restart [site]",
+	Short: "Restart a site's processes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		site := procSite(args)
+		var statuses []services.ProcessStatus
+		if err := apiPost("/api/proc/restart", map[string]string{"site": site}, &statuses); err != nil {
+			return fmt.Errorf("failed to restart processes: %w", err)
+		}
+		fmt.Println("🔄 Processes restarted")
+		printProcesses(site, statuses)
+		return nil
+	},
+}
+
+var procStatusCmd = &cobra.Command{
+	Use:   "status [site]",
+	Short: "Show the state of a site's processes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		site := procSite(args)
+		var statuses []services.ProcessStatus
+		if err := apiGet("/api/proc?site="+url.QueryEscape(site), &statuses); err != nil {
+			return err
+		}
+		printProcesses(site, statuses)
+		return nil
+	},
+}
+
+var procLogsCmd = &cobra.Command{
+	Use:   "logs [site]",
+	Short: "Show recent output of a site's processes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		site := procSite(args)
+		lines, _ := cmd.Flags().GetInt("lines")
+		var entries []services.LogEntryData
+		path := fmt.Sprintf("/api/proc/logs?site=%s&lines=%d", url.QueryEscape(site), lines)
+		if err := apiGet(path, &entries); err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Printf("No output captured for %s yet\n", site)
+			return nil
+		}
+		prefix := string(services.LogSourceProcess) + ":" + site + ":"
+		for _, e := range entries {
+			fmt.Printf("[%s] %s\n", strings.TrimPrefix(string(e.Source), prefix), e.Raw)
+		}
+		return nil
+	},
+}
+
 // --- Sites Command ---
 
 var sitesCmd = &cobra.Command{
@@ -903,7 +1811,11 @@ var sitesCmd = &cobra.Command{
 			if s.Category != "" {
 				extra += fmt.Sprintf(" (%s)", s.Category)
 			}
-			fmt.Printf(" - %s -> %s%s\n", s.Domain, s.Path, extra)
+			target := s.Path
+			if s.Proxy != "" {
+				target = s.Proxy
+			}
+			fmt.Printf(" - %s -> %s%s\n", s.Domain, target, extra)
 		}
 		return nil
 	},
@@ -942,6 +1854,7 @@ After=network.target nginx.service
 [Service]
 Type=simple
 Environment=SUDO_USER=%s
+Environment=SLD_HOME=%s
 ExecStart=%s daemon
 Restart=on-failure
 RestartSec=5
@@ -950,7 +1863,7 @@ StandardError=journal
 
 [Install]
 WantedBy=multi-user.target
-`, os.Getenv("SUDO_USER"), exePath)
+`, os.Getenv("SUDO_USER"), config.ResolveHome(), exePath)
 
 		servicePath := "/etc/systemd/system/sld-daemon.service"
 		if err := os.WriteFile(servicePath, []byte(serviceContent), 0644); err != nil {
@@ -0,0 +1,56 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SyncIniFile makes the ini file name in each of dirs hold content, or
+// removes it when content is empty. changed reports whether anything was
+// written or removed; rollback puts the previous files back. write and
+// remove may go through sudo.
+func SyncIniFile(dirs []string, name, content string, write func(path string, data []byte) error, remove func(path string) error) (changed bool, rollback func(), err error) {
+	previous := map[string][]byte{}
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if data, err := os.ReadFile(path); err == nil {
+			previous[path] = data
+		}
+	}
+
+	var touched []string
+	rollback = func() {
+		for _, path := range touched {
+			if data, ok := previous[path]; ok {
+				write(path, data)
+			} else {
+				remove(path)
+			}
+		}
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		old, exists := previous[path]
+		if content == "" {
+			if !exists {
+				continue
+			}
+			if err := remove(path); err != nil {
+				rollback()
+				return false, nil, err
+			}
+		} else {
+			if exists && string(old) == content {
+				continue
+			}
+			if err := write(path, []byte(content)); err != nil {
+				rollback()
+				return false, nil, err
+			}
+		}
+		touched = append(touched, path)
+		changed = true
+	}
+	return changed, rollback, nil
+}
@@ -1,5 +1,7 @@
 package adapters
 
+import "github.com/supreme-majesty/supreme-local-dev/pkg/php"
+
 // SystemAdapter defines the interface for OS-specific interactions.
 type SystemAdapter interface {
 	// Service Management
@@ -13,11 +15,9 @@ type SystemAdapter interface {
 	InstallPHP(version string) error
 	InstallNode(version string) error
 	GetNodePath(version string) (string, error)
-	InstallCertificates() error
-	InstallMkcert() error
-	GenerateCert(homeDir string, domains []string) error
+	ListNodeVersions() ([]string, error)
 	InstallBinary() error
-	Uninstall() error
+	Uninstall(sldHome string) error // Removes SLD from the system, and sldHome unless it is empty
 
 	// Configuration
 	WriteNginxConfig(config string) error
@@ -29,14 +29,25 @@ type SystemAdapter interface {
 	CheckPHPSocket(version string) (string, error)
 	ReloadNginx() error
 
+	// Per-site PHP-FPM pools
+	PHPPoolSocket(version, pool string) string                  // Where the socket of an SLD pool lives
+	SyncPHPPools(version string, pools map[string]string) error // Installs exactly these SLD pools (name -> config), reloading FPM when they change
+
+	// PHP configuration
+	PHPRuntime(version string) php.Runtime                    // How to run a PHP version with the configuration its FPM uses
+	SyncPHPIni(version, name, content string) error           // Writes an SLD-owned conf.d ini for FPM and the CLI (removes it when content is empty), restarting FPM when it changes
+	PHPExtensionPackage(version, name string) string          // Package providing an extension, empty when it can't be installed separately
+	InstallPHPExtension(version, name string) error           // Installs that package and restarts FPM
+	SetPHPExtension(version, name string, enabled bool) error // Enables or disables an installed shared extension, restarting FPM when it changes
+
 	// Permissions & User Management
 	AddWebUserToGroup(group string) error
 	RestartPHP() error
-	UpdateHosts(domains []string) error
+	ConfigureResolver(tld, addr string) error // Routes lookups under tld to the SLD DNS server at addr
 	// Health & Connectivity
 	CheckWifi() (bool, string)
 	Doctor() error
-	GetLogPaths() map[string]string
+	GetLogPaths() map[string]string // OS-specific logs such as php_fpm; nginx logs to config.Paths.Logs
 
 	// Structured Status
 	GetServices() ([]ServiceStatus, error)
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/supreme-majesty/supreme-local-dev/pkg/adapters"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/nginx"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/php"
 )
 
 type LinuxAdapter struct {
@@ -52,10 +54,11 @@ func (l *LinuxAdapter) InstallDependencies() error {
 	if err == nil && path != "" {
 		// Base packages
 		packages := []string{
-			"nginx", "php-fpm", "dnsmasq", "zip", "unzip",
+			"nginx", "php-fpm", "zip", "unzip",
 			"composer",
 			"php-mysql", "php-mbstring", "php-xml", "php-curl",
 			"php-zip", "php-sqlite3", "php-bcmath", "php-intl",
+			"libnss3-tools", // certutil, to trust the SLD CA in Firefox and Chromium
 		}
 
 		// Check specific packages to avoid conflicts or redundancies
@@ -94,44 +97,8 @@ func (l *LinuxAdapter) InstallDependencies() error {
 			return err
 		}
 
-		// Configure dnsmasq for .test domain
-		// strict-order: query strict order (not needed if only one upstream)
-		// bind-interfaces: listen only on specified address (crucial for systemd-resolved coexistence)
-		// listen-address: 127.0.0.1 (avoid binding specific interface or 0.0.0.0)
-		// resolv-file: usage of real upstream to avoid loop with systemd-resolved stub
-		// Use static upstream DNS servers instead of /run/systemd/resolve/resolv.conf
-		// This allows .test domains to resolve even when offline
-		dnsConf := `address=/.test/127.0.0.1
-address=/.test/::1
-bind-interfaces
-listen-address=127.0.0.1
-listen-address=::1
-no-resolv
-local=/test/
-`
-		tmpFile := "/tmp/sld-dnsmasq.conf"
-		os.WriteFile(tmpFile, []byte(dnsConf), 0644)
-		exec.Command("sudo", "mv", tmpFile, "/etc/dnsmasq.d/sld.conf").Run()
-		exec.Command("sudo", "systemctl", "restart", "dnsmasq").Run()
-
-		// Configure systemd-resolved to route .test to 127.0.0.1
-		// We use a drop-in file
-		resolvedConf := `[Resolve]
-DNS=127.0.0.1
-Domains=~test
-`
-		tmpResolved := "/tmp/sld-resolved.conf"
-		os.WriteFile(tmpResolved, []byte(resolvedConf), 0644)
-
-		exec.Command("sudo", "mkdir", "-p", "/etc/systemd/resolved.conf.d").Run()
-		exec.Command("sudo", "mv", tmpResolved, "/etc/systemd/resolved.conf.d/sld.conf").Run()
-		exec.Command("sudo", "systemctl", "restart", "systemd-resolved").Run()
-
-		// Add sld.test to /etc/hosts for reliable offline access
-		// /etc/hosts is consulted first, bypassing DNS entirely
-		if err := l.ensureHostsEntry("sld.test"); err != nil {
-			fmt.Printf("Warning: Failed to add sld.test to /etc/hosts: %v\n", err)
-		}
+		// Site domains are resolved by the daemon's own DNS server, which
+		// installs its systemd-resolved drop-in on start (ConfigureResolver)
 
 		return nil
 	}
@@ -210,191 +177,81 @@ func (l *LinuxAdapter) GetNodePath(version string) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
-// ensureHostsEntry adds a hostname to /etc/hosts if not already present
-func (l *LinuxAdapter) ensureHostsEntry(hostname string) error {
-	hostsPath := "/etc/hosts"
-	entry := fmt.Sprintf("127.0.0.1 %s", hostname)
-
-	// Read current hosts file
-	data, err := os.ReadFile(hostsPath)
+// ListNodeVersions returns the Node.js versions installed through fnm
+func (l *LinuxAdapter) ListNodeVersions() ([]string, error) {
+	out, err := exec.Command("fnm", "list").Output()
 	if err != nil {
-		return fmt.Errorf("failed to read hosts file: %w", err)
-	}
-
-	// Check if entry already exists
-	if strings.Contains(string(data), hostname) {
-		return nil // Already present
-	}
-
-	// Append entry using sudo
-	tmpFile := "/tmp/sld-hosts-entry"
-	newContent := string(data)
-	if !strings.HasSuffix(newContent, "\n") {
-		newContent += "\n"
+		return nil, fmt.Errorf("failed to list node versions (is fnm installed?): %w", err)
 	}
-	newContent += entry + "\n"
-
-	if err := os.WriteFile(tmpFile, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write temp hosts file: %w", err)
-	}
-
-	if err := exec.Command("sudo", "mv", tmpFile, hostsPath).Run(); err != nil {
-		return fmt.Errorf("failed to update hosts file: %w", err)
-	}
-
-	fmt.Printf("Added %s to /etc/hosts for offline access\n", hostname)
-	return nil
+	return adapters.ParseFnmList(out), nil
 }
 
-func (l *LinuxAdapter) UpdateHosts(domains []string) error {
-	hostsPath := "/etc/hosts"
-	startMarker := "# SLD-START"
-	endMarker := "# SLD-END"
+// resolvedDropIn routes the TLD to the SLD DNS server
+const resolvedDropIn = "/etc/systemd/resolved.conf.d/sld.conf"
 
-	// Read current hosts file
-	data, err := os.ReadFile(hostsPath)
-	if err != nil {
-		return fmt.Errorf("failed to read hosts file: %w", err)
+// ConfigureResolver points systemd-resolved at the SLD DNS server for
+// lookups under tld. The drop-in is only rewritten (and resolved restarted)
+// when it changes.
+func (l *LinuxAdapter) ConfigureResolver(tld, addr string) error {
+	if _, err := exec.LookPath("resolvectl"); err != nil {
+		return fmt.Errorf("systemd-resolved not found; point your resolver at %s for .%s domains", addr, tld)
 	}
-	content := string(data)
-
-	// Prepare new block
-	var sb strings.Builder
-	sb.WriteString(startMarker + "\n")
-	// Always include sld.test
-	sb.WriteString("127.0.0.1 sld.test\n")
-	sb.WriteString("::1 sld.test\n")
 
-	for _, d := range domains {
-		if d == "sld.test" {
-			continue
-		}
-		sb.WriteString(fmt.Sprintf("127.0.0.1 %s\n", d))
-		sb.WriteString(fmt.Sprintf("::1 %s\n", d))
-	}
-	sb.WriteString(endMarker)
-	newBlock := sb.String()
-
-	// Replace or Append
-	var newContent string
-	startIndex := strings.Index(content, startMarker)
-	endIndex := strings.Index(content, endMarker)
-
-	if startIndex != -1 && endIndex != -1 && endIndex > startIndex {
-		// Replace existing block
-		// We need to look for newline after endMarker to keep it clean
-		suffix := content[endIndex+len(endMarker):]
-		newContent = content[:startIndex] + newBlock + suffix
-	} else {
-		// Append if not found (ensure newline before)
-		if !strings.HasSuffix(content, "\n") {
-			content += "\n"
-		}
-		newContent = content + newBlock + "\n"
+	conf := fmt.Sprintf("# Generated by SLD: .%s lookups go to the SLD daemon\n[Resolve]\nDNS=%s\nDomains=~%s\n", tld, addr, tld)
+	if current, err := os.ReadFile(resolvedDropIn); err == nil && string(current) == conf {
+		return nil
 	}
 
-	fmt.Printf("Updating hosts file with %d domains...\n", len(domains))
-
-	// Write to temp and mv
-	tmpFile := "/tmp/sld-hosts-update"
-	if err := os.WriteFile(tmpFile, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write temp hosts file: %w", err)
+	if err := exec.Command("sudo", "mkdir", "-p", filepath.Dir(resolvedDropIn)).Run(); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(resolvedDropIn), err)
 	}
-
-	// Move file - check if we are root
-	var cmd *exec.Cmd
-	if os.Getuid() == 0 {
-		cmd = exec.Command("mv", tmpFile, hostsPath)
-	} else {
-		cmd = exec.Command("sudo", "mv", tmpFile, hostsPath)
+	if err := sudoWriteFile(resolvedDropIn, []byte(conf)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", resolvedDropIn, err)
 	}
-
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to update hosts file: %w (output: %s)", err, string(out))
+	if out, err := exec.Command("sudo", "systemctl", "restart", "systemd-resolved").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restart systemd-resolved: %w (output: %s)", err, strings.TrimSpace(string(out)))
 	}
-
-	fmt.Println("Hosts file updated successfully.")
 	return nil
 }
 
-func (l *LinuxAdapter) InstallCertificates() error {
-	// 1. Get mkcert Root CA path
-	var cmd *exec.Cmd
-	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
-		cmd = exec.Command("sudo", "-u", sudoUser, "mkcert", "-CAROOT")
-	} else {
-		cmd = exec.Command("mkcert", "-CAROOT")
-	}
-
-	out, err := cmd.Output()
-	if err != nil {
-		return fmt.Errorf("failed to get mkcert CA path: %w", err)
-	}
-	caRoot := strings.TrimSpace(string(out))
-	caFile := filepath.Join(caRoot, "rootCA.pem")
+// Configuration
 
-	// 2. Define search paths
-	home := l.getRealUserHome()
+func (l *LinuxAdapter) WriteNginxConfig(config string) error {
+	path := l.GetNginxConfigPath()
 
-	searchPaths := []string{
-		filepath.Join(home, ".pki"),
-		filepath.Join(home, ".mozilla"),
-		filepath.Join(home, "snap"),
+	// Enable the site first so `nginx -t` sees the candidate
+	linkPath := "/etc/nginx/sites-enabled/sld.conf"
+	if _, err := os.Lstat(linkPath); os.IsNotExist(err) {
+		exec.Command("sudo", "ln", "-s", path, linkPath).Run()
 	}
 
-	fmt.Println("Scanning for browser databases to trust...")
-
-	// 3. Walk and find cert9.db
-	for _, root := range searchPaths {
-		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil // skip errors
-			}
-			if info.Name() == "cert9.db" {
-				dbDir := filepath.Dir(path)
-				fmt.Printf("Trusting CA in %s\n", dbDir)
-
-				// 3a. Try to delete existing cert (to avoid SEC_ERROR_ADDING_CERT)
-				exec.Command("certutil", "-d", "sql:"+dbDir, "-D", "-n", "supremelocaldev").Run()
-
-				// 3b. Add new cert
-				// certutil -d sql:DIR -A -t "C,," -n "supremelocaldev" -i CAFILE
-				// We must use "sql:" prefix
-				cmd := exec.Command("certutil", "-d", "sql:"+dbDir, "-A", "-t", "C,,", "-n", "supremelocaldev", "-i", caFile)
-				// Certutil might fail if DB is locked or read-only, we try best effort
-				if out, err := cmd.CombinedOutput(); err != nil {
-					fmt.Printf("Warning: Failed to trust in %s: %v (%s)\n", dbDir, err, string(out))
-				}
-			}
-			return nil
-		})
+	installer := nginx.Installer{
+		Path:  path,
+		Write: sudoWriteFile,
+		Test: func() ([]byte, error) {
+			return exec.Command("sudo", "nginx", "-t").CombinedOutput()
+		},
+		Reload: l.ReloadNginx,
 	}
-	return nil
+	return installer.Apply(config)
 }
 
-// Configuration
-
-func (l *LinuxAdapter) WriteNginxConfig(config string) error {
-	path := l.GetNginxConfigPath()
-	// Write to a temporary file first then move with sudo
-	tmpFile := "/tmp/sld-nginx.conf"
-	if err := os.WriteFile(tmpFile, []byte(config), 0644); err != nil {
+// sudoWriteFile writes to a temporary file first then moves it into place with sudo
+func sudoWriteFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp("", "sld-*.conf")
+	if err != nil {
 		return err
 	}
-
-	// Move to /etc/nginx/sites-available/sld.conf
-	cmd := exec.Command("sudo", "mv", tmpFile, path)
-	if err := cmd.Run(); err != nil {
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
 		return err
 	}
-
-	// Create symlink if not exists
-	linkPath := "/etc/nginx/sites-enabled/sld.conf"
-	if _, err := os.Stat(linkPath); os.IsNotExist(err) {
-		exec.Command("sudo", "ln", "-s", path, linkPath).Run()
+	if err := tmp.Close(); err != nil {
+		return err
 	}
-
-	return l.ReloadNginx()
+	os.Chmod(tmp.Name(), 0644)
+	return exec.Command("sudo", "mv", tmp.Name(), path).Run()
 }
 
 func (l *LinuxAdapter) GetNginxConfigPath() string {
@@ -542,114 +399,6 @@ func (l *LinuxAdapter) FreePort80() error {
 	return nil
 }
 
-// HTTPS Support
-
-func (l *LinuxAdapter) InstallMkcert() error {
-	path, err := exec.LookPath("mkcert")
-	if err == nil && path != "" {
-		return nil // already installed
-	}
-
-	// Try installing via apt (if available) or suggest user install it
-	// On Ubuntu/Debian 'mkcert' is in recent repos or via brew.
-	// For simplicity, let's assume apt install works or fail with message.
-	// Actually, `libnss3-tools` is needed for mkcert.
-
-	cmd := exec.Command("sudo", "apt-get", "install", "-y", "mkcert", "libnss3-tools")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
-func (l *LinuxAdapter) GenerateCert(homeDir string, domains []string) error {
-	sudoUser := os.Getenv("SUDO_USER")
-
-	// 1. Install CA if needed (mkcert -install checks itself)
-	// Run as user if possible to hit user's browsers
-	var installCmd *exec.Cmd
-	if sudoUser != "" {
-		installCmd = exec.Command("sudo", "-u", sudoUser, "mkcert", "-install")
-	} else {
-		installCmd = exec.Command("mkcert", "-install")
-		if os.Getuid() != 0 {
-			installCmd.Stdin = os.Stdin
-		}
-	}
-
-	installCmd.Stdout = os.Stdout
-	installCmd.Stderr = os.Stderr
-
-	if err := installCmd.Run(); err != nil {
-		fmt.Printf("Warning: mkcert -install failed: %v\n", err)
-	}
-
-	// 2. Generate certs to temporary location
-	tempDir, err := os.MkdirTemp("", "sld-certs")
-	if err != nil {
-		return err
-	}
-	defer os.RemoveAll(tempDir) // cleanup
-
-	// Fix ownership of tempDir so user can write to it
-	if sudoUser != "" {
-		exec.Command("chown", "-R", sudoUser, tempDir).Run()
-	}
-
-	certName := "dev.pem"
-	keyName := "dev-key.pem"
-	certPath := filepath.Join(tempDir, certName)
-	keyPath := filepath.Join(tempDir, keyName)
-
-	// Base domains
-	// Note: *.test wildcard doesn't match "sld.test" itself, only subdomains like "app.test"
-	// We must explicitly include sld.test for the dashboard to work over HTTPS
-	args := []string{"mkcert", "-cert-file", certPath, "-key-file", keyPath, "*.test", "sld.test", "test.test", "localhost", "127.0.0.1", "::1"}
-	// Append custom domains
-	args = append(args, domains...)
-
-	var genCmd *exec.Cmd
-	if sudoUser != "" {
-		// Prepend sudo -u user
-		genArgs := append([]string{"-u", sudoUser}, args...)
-		genCmd = exec.Command("sudo", genArgs...)
-	} else {
-		// args included "mkcert", remove it for direct call?
-		// exec.Command takes (name, args...). "mkcert" is in args[0].
-		// wait, args defined above has "mkcert" as first element? Yes.
-		genCmd = exec.Command(args[0], args[1:]...)
-	}
-
-	genCmd.Stdout = os.Stdout
-	genCmd.Stderr = os.Stderr
-	genCmd.Stdin = os.Stdin
-	if err := genCmd.Run(); err != nil {
-		return fmt.Errorf("mkcert generation failed: %w", err)
-	}
-
-	// 3. Move to system location
-	finalDir := "/var/lib/sld/certs"
-	fmt.Printf("Installing certificates to %s...\n", finalDir)
-
-	// mkdir -p
-	if err := exec.Command("sudo", "mkdir", "-p", finalDir).Run(); err != nil {
-		return fmt.Errorf("failed to create cert directory: %w", err)
-	}
-
-	// copy files
-	if err := exec.Command("sudo", "cp", certPath, filepath.Join(finalDir, certName)).Run(); err != nil {
-		return fmt.Errorf("failed to install cert: %w", err)
-	}
-	if err := exec.Command("sudo", "cp", keyPath, filepath.Join(finalDir, keyName)).Run(); err != nil {
-		return fmt.Errorf("failed to install key: %w", err)
-	}
-
-	// chmod valid for nginx reading
-	exec.Command("sudo", "chmod", "644", filepath.Join(finalDir, certName)).Run()
-	exec.Command("sudo", "chmod", "644", filepath.Join(finalDir, keyName)).Run()
-
-	return nil
-}
-
 func (l *LinuxAdapter) InstallBinary() error {
 	// Get current binary path
 	exe, err := os.Executable()
@@ -680,17 +429,22 @@ func (l *LinuxAdapter) InstallBinary() error {
 	return exec.Command("sudo", "chmod", "+x", dest).Run()
 }
 
-func (l *LinuxAdapter) Uninstall() error {
+func (l *LinuxAdapter) Uninstall(sldHome string) error {
 	fmt.Println("Removing configuration files...")
 
 	files := []string{
 		"/usr/local/bin/sld",
-		"/etc/dnsmasq.d/sld.conf",
-		"/etc/systemd/resolved.conf.d/sld.conf",
+		"/etc/dnsmasq.d/sld.conf", // Left by installs from before the built-in DNS server
+		resolvedDropIn,
 		"/etc/nginx/sites-enabled/sld.conf",
 		"/etc/nginx/sites-enabled/sld-ssl.conf",
 	}
 
+	pools, _ := filepath.Glob(filepath.Join(phpPoolDir("*"), adapters.PoolFilePrefix+"*.conf"))
+	files = append(files, pools...)
+	inis, _ := filepath.Glob("/etc/php/*/*/conf.d/*-sld-*.ini") // Plugin settings and overrides
+	files = append(files, inis...)
+
 	for _, f := range files {
 		if _, err := os.Stat(f); err == nil {
 			fmt.Printf("Removing %s...\n", f)
@@ -698,8 +452,11 @@ func (l *LinuxAdapter) Uninstall() error {
 		}
 	}
 
-	fmt.Println("Removing data directories...")
-	exec.Command("sudo", "rm", "-rf", "/var/lib/sld").Run()
+	// Empty when the home isn't safe to delete, see Daemon.Uninstall
+	if sldHome != "" {
+		fmt.Println("Removing data directories...")
+		exec.Command("sudo", "rm", "-rf", sldHome).Run()
+	}
 
 	// Remove user config
 	home := l.getRealUserHome()
@@ -731,6 +488,180 @@ func (l *LinuxAdapter) CheckPHPSocket(version string) (string, error) {
 	return socketPath, nil
 }
 
+// phpPoolDir is where php-fpm of a version includes pools from (Debian layout)
+func phpPoolDir(version string) string {
+	return fmt.Sprintf("/etc/php/%s/fpm/pool.d", version)
+}
+
+func (l *LinuxAdapter) PHPPoolSocket(version, pool string) string {
+	return fmt.Sprintf("/run/php/%s.sock", pool)
+}
+
+func (l *LinuxAdapter) SyncPHPPools(version string, pools map[string]string) error {
+	dir := phpPoolDir(version)
+	if _, err := os.Stat(dir); err != nil {
+		if len(pools) == 0 {
+			return nil
+		}
+		return fmt.Errorf("PHP-FPM %s pool directory %s not found", version, dir)
+	}
+
+	remove := func(path string) error { return exec.Command("sudo", "rm", "-f", path).Run() }
+	changed, rollback, err := adapters.SyncPoolFiles(dir, pools, sudoWriteFile, remove)
+	if err != nil {
+		return fmt.Errorf("failed to write PHP-FPM %s pools: %w", version, err)
+	}
+	if !changed {
+		return nil
+	}
+
+	// One broken pool stops every site on this version, so test before reloading
+	if out, err := exec.Command("sudo", "php-fpm"+version, "-t").CombinedOutput(); err != nil {
+		rollback()
+		return fmt.Errorf("php-fpm%s rejected the SLD pools: %s", version, strings.TrimSpace(string(out)))
+	}
+	service := fmt.Sprintf("php%s-fpm", version)
+	if out, err := exec.Command("sudo", "systemctl", "reload-or-restart", service).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to reload %s: %w (output: %s)", service, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// phpConfDirs are the conf.d directories of a version that exist: FPM and
+// the CLI read separate ones on Debian
+func phpConfDirs(version string) []string {
+	var dirs []string
+	for _, sapi := range []string{"fpm", "cli"} {
+		dir := fmt.Sprintf("/etc/php/%s/%s/conf.d", version, sapi)
+		if _, err := os.Stat(dir); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+func (l *LinuxAdapter) PHPRuntime(version string) php.Runtime {
+	runtime := php.Runtime{
+		Version: version,
+		Binary:  "/usr/bin/php" + version,
+		ScanDir: fmt.Sprintf("/etc/php/%s/cli/conf.d", version),
+	}
+	// Inspect what FPM loads, not the CLI
+	fpm := fmt.Sprintf("/etc/php/%s/fpm", version)
+	if _, err := os.Stat(filepath.Join(fpm, "php.ini")); err == nil {
+		runtime.Config = filepath.Join(fpm, "php.ini")
+		runtime.ScanDir = filepath.Join(fpm, "conf.d")
+	}
+	return runtime
+}
+
+func (l *LinuxAdapter) SyncPHPIni(version, name, content string) error {
+	dirs := phpConfDirs(version)
+	if len(dirs) == 0 {
+		if content == "" {
+			return nil
+		}
+		return fmt.Errorf("PHP %s conf.d directory not found", version)
+	}
+
+	remove := func(path string) error { return exec.Command("sudo", "rm", "-f", path).Run() }
+	changed, rollback, err := adapters.SyncIniFile(dirs, name, content, sudoWriteFile, remove)
+	if err != nil {
+		return fmt.Errorf("failed to write PHP %s %s: %w", version, name, err)
+	}
+	if !changed {
+		return nil
+	}
+	return restartPHPFPM(version, name, rollback)
+}
+
+func (l *LinuxAdapter) PHPExtensionPackage(version, name string) string {
+	return php.DebianPackage(version, name)
+}
+
+func (l *LinuxAdapter) InstallPHPExtension(version, name string) error {
+	pkg := php.DebianPackage(version, name)
+	fmt.Printf("Installing %s...\n", pkg)
+	cmd := exec.Command("sudo", "env", "DEBIAN_FRONTEND=noninteractive", "apt-get", "install", "-y", pkg)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to install %s: %w", pkg, err)
+	}
+	// The package enables itself through phpenmod
+	return restartPHPFPM(version, pkg, nil)
+}
+
+// SetPHPExtension links or unlinks the extension's mods-available ini in
+// each conf.d, as phpenmod and phpdismod do
+func (l *LinuxAdapter) SetPHPExtension(version, name string, enabled bool) error {
+	available := fmt.Sprintf("/etc/php/%s/mods-available/%s.ini", version, name)
+	data, err := os.ReadFile(available)
+	if err != nil {
+		return fmt.Errorf("PHP %s has no %s extension installed (%s): install %s", version, name, available, php.DebianPackage(version, name))
+	}
+	link := php.Priority(string(data)) + "-" + name + ".ini"
+
+	var made, removed []string
+	rollback := func() {
+		for _, path := range made {
+			exec.Command("sudo", "rm", "-f", path).Run()
+		}
+		for _, path := range removed {
+			exec.Command("sudo", "ln", "-sf", available, path).Run()
+		}
+	}
+	for _, dir := range phpConfDirs(version) {
+		existing, _ := filepath.Glob(filepath.Join(dir, "*-"+name+".ini"))
+		if enabled {
+			if len(existing) > 0 {
+				continue
+			}
+			path := filepath.Join(dir, link)
+			if out, err := exec.Command("sudo", "ln", "-s", available, path).CombinedOutput(); err != nil {
+				rollback()
+				return fmt.Errorf("failed to enable %s: %s", name, strings.TrimSpace(string(out)))
+			}
+			made = append(made, path)
+			continue
+		}
+		for _, path := range existing {
+			if target, err := os.Readlink(path); err != nil || filepath.Base(target) != name+".ini" {
+				continue // Not a phpenmod link; other files aren't ours to remove
+			}
+			if out, err := exec.Command("sudo", "rm", "-f", path).CombinedOutput(); err != nil {
+				rollback()
+				return fmt.Errorf("failed to disable %s: %s", name, strings.TrimSpace(string(out)))
+			}
+			removed = append(removed, path)
+		}
+	}
+	if len(made) == 0 && len(removed) == 0 {
+		return nil
+	}
+	return restartPHPFPM(version, name, rollback)
+}
+
+// restartPHPFPM checks the configuration of a version's FPM and restarts
+// it, as extensions only load when the master starts. what names the
+// change in errors; rollback, if set, undoes it when php-fpm rejects it.
+func restartPHPFPM(version, what string, rollback func()) error {
+	if _, err := os.Stat(phpPoolDir(version)); err != nil {
+		return nil // CLI only
+	}
+	if out, err := exec.Command("sudo", "php-fpm"+version, "-t").CombinedOutput(); err != nil {
+		if rollback != nil {
+			rollback()
+		}
+		return fmt.Errorf("php-fpm%s rejected %s: %s", version, what, strings.TrimSpace(string(out)))
+	}
+	service := fmt.Sprintf("php%s-fpm", version)
+	if out, err := exec.Command("sudo", "systemctl", "restart", service).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restart %s: %w (output: %s)", service, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
 func (l *LinuxAdapter) getRealUserHome() string {
 	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
 		if u, err := user.Lookup(sudoUser); err == nil {
@@ -785,7 +716,7 @@ func (l *LinuxAdapter) Doctor() error {
 	fmt.Println("--------------------------")
 
 	// Check Services
-	services := []string{"nginx", "dnsmasq", "systemd-resolved"}
+	services := []string{"nginx", "systemd-resolved"}
 	for _, s := range services {
 		running, err := l.IsServiceRunning(s)
 		status := "🔴 STOPPED"
@@ -813,21 +744,11 @@ func (l *LinuxAdapter) Doctor() error {
 	}
 	fmt.Printf("%-18s: %s (%s)\n", "WiFi Status", wifiStatus, wifiMsg)
 
-	// Check .test resolution
-	cmd := exec.Command("resolvectl", "query", "sld.test")
-	if err := cmd.Run(); err != nil {
-		fmt.Printf("%-18s: 🔴 FAILED (systemd-resolved not resolving .test)\n", ".test Resolution")
-	} else {
-		fmt.Printf("%-18s: 🟢 WORKING\n", ".test Resolution")
-	}
-
 	return nil
 }
 
 func (l *LinuxAdapter) GetLogPaths() map[string]string {
 	logs := make(map[string]string)
-	logs["nginx_error"] = "/var/log/nginx/error.log"
-	logs["nginx_access"] = "/var/log/nginx/access.log"
 
 	// Try to find specific php log
 	ver := l.GetPHPVersion()
@@ -859,15 +780,8 @@ func (l *LinuxAdapter) GetServices() ([]adapters.ServiceStatus, error) {
 		Version: phpVer,
 	})
 
-	// DNSMasq
-	dnsRunning, _ := l.IsServiceRunning("dnsmasq")
-	services = append(services, adapters.ServiceStatus{
-		Name:    "DNSMasq",
-		Running: dnsRunning,
-	})
-
 	// Core Services
-	core := []string{"nginx", "dnsmasq"}
+	core := []string{"nginx"}
 	for _, name := range core {
 		running, _ := l.IsServiceRunning(name)
 		services = append(services, adapters.ServiceStatus{
@@ -2,6 +2,7 @@ package macos
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -9,6 +10,8 @@ import (
 	"strings"
 
 	"github.com/supreme-majesty/supreme-local-dev/pkg/adapters"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/nginx"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/php"
 )
 
 type MacOSAdapter struct{}
@@ -54,9 +57,7 @@ func (m *MacOSAdapter) InstallDependencies() error {
 
 	packages := []string{
 		"nginx",
-		"dnsmasq",
-		"mkcert",
-		"nss", // for mkcert firefox support
+		"nss", // certutil, to trust the SLD CA in Firefox
 		"fnm",
 	}
 
@@ -106,6 +107,14 @@ func (m *MacOSAdapter) InstallNode(version string) error {
 	return cmd.Run()
 }
 
+func (m *MacOSAdapter) ListNodeVersions() ([]string, error) {
+	out, err := exec.Command("fnm", "list").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node versions: %w", err)
+	}
+	return adapters.ParseFnmList(out), nil
+}
+
 func (m *MacOSAdapter) GetNodePath(version string) (string, error) {
 	cmd := exec.Command("fnm", "exec", "--using", version, "which", "node")
 	out, err := cmd.Output()
@@ -115,11 +124,8 @@ func (m *MacOSAdapter) GetNodePath(version string) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
-func (m *MacOSAdapter) InstallCertificates() error                          { return nil }
-func (m *MacOSAdapter) InstallMkcert() error                                { return nil }
-func (m *MacOSAdapter) GenerateCert(homeDir string, domains []string) error { return nil }
-func (m *MacOSAdapter) InstallBinary() error                                { return nil }
-func (m *MacOSAdapter) Uninstall() error                                    { return nil }
+func (m *MacOSAdapter) InstallBinary() error           { return nil }
+func (m *MacOSAdapter) Uninstall(sldHome string) error { return nil }
 
 // Config Paths
 func (m *MacOSAdapter) getBrewPrefix() string {
@@ -143,10 +149,6 @@ func (m *MacOSAdapter) WriteNginxConfig(config string) error {
 		return err
 	}
 
-	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
-		return err
-	}
-
 	// Ensure this file is included in the main nginx.conf
 	mainConfig := filepath.Join(m.getBrewPrefix(), "etc", "nginx", "nginx.conf")
 
@@ -160,7 +162,14 @@ func (m *MacOSAdapter) WriteNginxConfig(config string) error {
 		fmt.Printf("Warning: Please manually add 'include %s;' to your http block in %s\n", path, mainConfig)
 	}
 
-	return nil
+	installer := nginx.Installer{
+		Path: path,
+		Test: func() ([]byte, error) {
+			return exec.Command("sudo", "nginx", "-t").CombinedOutput()
+		},
+		Reload: m.ReloadNginx,
+	}
+	return installer.Apply(config)
 }
 
 func (m *MacOSAdapter) ReloadNginx() error {
@@ -193,6 +202,131 @@ func (m *MacOSAdapter) CheckPHPSocket(version string) (string, error) {
 	return "127.0.0.1:" + port, nil
 }
 
+func (m *MacOSAdapter) PHPPoolSocket(version, pool string) string {
+	return filepath.Join(m.getBrewPrefix(), "var", "run", "sld", pool+".sock")
+}
+
+func (m *MacOSAdapter) SyncPHPPools(version string, pools map[string]string) error {
+	prefix := m.getBrewPrefix()
+	dir := filepath.Join(prefix, "etc", "php", version, "php-fpm.d")
+	if _, err := os.Stat(dir); err != nil {
+		if len(pools) == 0 {
+			return nil
+		}
+		return fmt.Errorf("PHP-FPM %s pool directory %s not found", version, dir)
+	}
+	if err := os.MkdirAll(filepath.Join(prefix, "var", "run", "sld"), 0755); err != nil {
+		return err
+	}
+
+	write := func(path string, data []byte) error { return os.WriteFile(path, data, 0644) }
+	changed, rollback, err := adapters.SyncPoolFiles(dir, pools, write, os.Remove)
+	if err != nil {
+		return fmt.Errorf("failed to write PHP-FPM %s pools: %w", version, err)
+	}
+	if !changed {
+		return nil
+	}
+
+	// One broken pool stops every site on this version, so test before restarting
+	fpm := filepath.Join(prefix, "opt", "php@"+version, "sbin", "php-fpm")
+	if out, err := exec.Command(fpm, "-t").CombinedOutput(); err != nil {
+		rollback()
+		return fmt.Errorf("php-fpm %s rejected the SLD pools: %s", version, strings.TrimSpace(string(out)))
+	}
+	return exec.Command("brew", "services", "restart", "php@"+version).Run()
+}
+
+func (m *MacOSAdapter) PHPRuntime(version string) php.Runtime {
+	return php.Runtime{
+		Version: version,
+		Binary:  filepath.Join(m.getBrewPrefix(), "opt", "php@"+version, "bin", "php"),
+		ScanDir: m.phpConfDir(version),
+	}
+}
+
+// phpConfDir is the conf.d Homebrew's FPM and CLI of a version share
+func (m *MacOSAdapter) phpConfDir(version string) string {
+	return filepath.Join(m.getBrewPrefix(), "etc", "php", version, "conf.d")
+}
+
+func (m *MacOSAdapter) SyncPHPIni(version, name, content string) error {
+	dir := m.phpConfDir(version)
+	if _, err := os.Stat(dir); err != nil {
+		if content == "" {
+			return nil
+		}
+		return fmt.Errorf("PHP %s conf.d directory %s not found", version, dir)
+	}
+
+	write := func(path string, data []byte) error { return os.WriteFile(path, data, 0644) }
+	changed, rollback, err := adapters.SyncIniFile([]string{dir}, name, content, write, os.Remove)
+	if err != nil {
+		return fmt.Errorf("failed to write PHP %s %s: %w", version, name, err)
+	}
+	if !changed {
+		return nil
+	}
+	return m.restartPHPFPM(version, name, rollback)
+}
+
+// Homebrew's PHP bundles the core extensions; the rest come from PECL
+func (m *MacOSAdapter) PHPExtensionPackage(version, name string) string {
+	return php.PECLPackage(name)
+}
+
+func (m *MacOSAdapter) InstallPHPExtension(version, name string) error {
+	pkg := php.PECLPackage(name)
+	if pkg == "" {
+		return fmt.Errorf("%s is built into Homebrew's PHP %s and can't be installed separately", name, version)
+	}
+	fmt.Printf("Installing %s...\n", pkg)
+	// pecl enables it in php.ini itself
+	cmd := exec.Command(filepath.Join(m.getBrewPrefix(), "opt", "php@"+version, "bin", "pecl"), "install", pkg)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to install %s: %w", pkg, err)
+	}
+	return m.restartPHPFPM(version, pkg, nil)
+}
+
+// SetPHPExtension writes or removes conf.d/ext-<name>.ini, the file
+// Homebrew uses for the extensions it enables
+func (m *MacOSAdapter) SetPHPExtension(version, name string, enabled bool) error {
+	path := filepath.Join(m.phpConfDir(version), "ext-"+name+".ini")
+	_, err := os.Stat(path)
+	exists := err == nil
+	switch {
+	case enabled && !exists:
+		content := php.Render("Enabled by SLD", []string{name}, nil)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to enable %s: %w", name, err)
+		}
+		return m.restartPHPFPM(version, name, func() { os.Remove(path) })
+	case !enabled && exists:
+		data, _ := os.ReadFile(path)
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to disable %s: %w", name, err)
+		}
+		return m.restartPHPFPM(version, name, func() { os.WriteFile(path, data, 0644) })
+	}
+	return nil
+}
+
+// restartPHPFPM checks the configuration of a version's FPM and restarts
+// it. rollback, if set, undoes the change when php-fpm rejects it.
+func (m *MacOSAdapter) restartPHPFPM(version, what string, rollback func()) error {
+	fpm := filepath.Join(m.getBrewPrefix(), "opt", "php@"+version, "sbin", "php-fpm")
+	if out, err := exec.Command(fpm, "-t").CombinedOutput(); err != nil {
+		if rollback != nil {
+			rollback()
+		}
+		return fmt.Errorf("php-fpm %s rejected %s: %s", version, what, strings.TrimSpace(string(out)))
+	}
+	return exec.Command("brew", "services", "restart", "php@"+version).Run()
+}
+
 func (m *MacOSAdapter) GetPHPVersion() string {
 	out, err := exec.Command("php", "-v").Output()
 	if err != nil {
@@ -219,30 +353,24 @@ func (m *MacOSAdapter) ListPHPVersions() ([]string, error) {
 	return versions, nil
 }
 
-func (m *MacOSAdapter) UpdateHosts(domains []string) error {
-	// Simple implementation: Append to /etc/hosts if not present
-	// Requires sudo
-
-	for _, domain := range domains {
-		// Check invalid chars
-		if strings.Contains(domain, "/") || strings.Contains(domain, " ") {
-			continue
-		}
+// ConfigureResolver adds /etc/resolver/<tld>, which macOS consults for
+// every lookup under tld
+func (m *MacOSAdapter) ConfigureResolver(tld, addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join("/etc/resolver", tld)
+	conf := fmt.Sprintf("# Generated by SLD\nnameserver %s\nport %s\n", host, port)
+	if current, err := os.ReadFile(path); err == nil && string(current) == conf {
+		return nil
+	}
 
-		// Grep check
-		checkCmd := exec.Command("grep", domain, "/etc/hosts")
-		if err := checkCmd.Run(); err != nil {
-			// Not found (exit status 1), append it
-			fmt.Printf("Adding %s to /etc/hosts (requires sudo)...\n", domain)
-			line := fmt.Sprintf("127.0.0.1 %s", domain)
-			// sudo sh -c "echo '...' >> /etc/hosts"
-			updateCmd := exec.Command("sudo", "sh", "-c", fmt.Sprintf("echo '%s' >> /etc/hosts", line))
-			updateCmd.Stdout = os.Stdout
-			updateCmd.Stderr = os.Stderr
-			if err := updateCmd.Run(); err != nil {
-				return fmt.Errorf("failed to update hosts for %s: %w", domain, err)
-			}
-		}
+	exec.Command("sudo", "mkdir", "-p", "/etc/resolver").Run()
+	cmd := exec.Command("sudo", "tee", path)
+	cmd.Stdin = strings.NewReader(conf)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write %s: %w (output: %s)", path, err, strings.TrimSpace(string(out)))
 	}
 	return nil
 }
@@ -271,16 +399,14 @@ func (m *MacOSAdapter) GetLogPaths() map[string]string {
 		prefix = "/opt/homebrew"
 	}
 	return map[string]string{
-		"nginx_access": filepath.Join(prefix, "var/log/nginx/access.log"),
-		"nginx_error":  filepath.Join(prefix, "var/log/nginx/error.log"),
-		"php_fpm":      filepath.Join(prefix, "var/log/php-fpm.log"),
+		"php_fpm": filepath.Join(prefix, "var/log/php-fpm.log"),
 	}
 }
 func (m *MacOSAdapter) GetServices() ([]adapters.ServiceStatus, error) {
 	services := []adapters.ServiceStatus{}
 
 	// Core Services
-	core := []string{"nginx", "dnsmasq"}
+	core := []string{"nginx"}
 	for _, name := range core {
 		// On macOS, it might be just 'nginx' or 'nginx-full' depending on install,
 		// but IsServiceRunning handles "brew services list" checks generally?
@@ -0,0 +1,19 @@
+package adapters
+
+import (
+	"strings"
+)
+
+// ParseFnmList extracts installed versions from `fnm list` output,
+// whose lines look like "* v20.11.1 default" or "* system"
+func ParseFnmList(out []byte) []string {
+	var versions []string
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "v") {
+			continue
+		}
+		versions = append(versions, strings.TrimPrefix(fields[0], "v"))
+	}
+	return versions
+}
@@ -0,0 +1,59 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// PoolFilePrefix marks the PHP-FPM pool files SLD owns in a pool directory
+const PoolFilePrefix = "sld-"
+
+// SyncPoolFiles makes dir hold exactly the given SLD pools (name -> config),
+// leaving other pools alone. changed reports whether anything was written or
+// removed; rollback puts the previous files back, e.g. when php-fpm rejects
+// the new ones. write and remove may go through sudo.
+func SyncPoolFiles(dir string, pools map[string]string, write func(path string, data []byte) error, remove func(path string) error) (changed bool, rollback func(), err error) {
+	existing, _ := filepath.Glob(filepath.Join(dir, PoolFilePrefix+"*.conf"))
+	previous := map[string][]byte{}
+	for _, path := range existing {
+		if data, err := os.ReadFile(path); err == nil {
+			previous[path] = data
+		}
+	}
+
+	rollback = func() {
+		for name := range pools {
+			path := filepath.Join(dir, name+".conf")
+			if _, ok := previous[path]; !ok {
+				remove(path)
+			}
+		}
+		for path, data := range previous {
+			write(path, data)
+		}
+	}
+
+	wanted := map[string]bool{}
+	for name, config := range pools {
+		path := filepath.Join(dir, name+".conf")
+		wanted[path] = true
+		if old, ok := previous[path]; ok && string(old) == config {
+			continue
+		}
+		if err := write(path, []byte(config)); err != nil {
+			rollback()
+			return false, nil, err
+		}
+		changed = true
+	}
+	for path := range previous {
+		if !wanted[path] {
+			if err := remove(path); err != nil {
+				rollback()
+				return false, nil, err
+			}
+			changed = true
+		}
+	}
+	return changed, rollback, nil
+}
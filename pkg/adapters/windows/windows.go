@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/supreme-majesty/supreme-local-dev/pkg/adapters"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/nginx"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/php"
 )
 
 type WindowsAdapter struct{}
@@ -51,7 +53,6 @@ func (w *WindowsAdapter) InstallDependencies() error {
 	packages := []string{
 		"Nginx.Nginx",
 		"Schniz.fnm",
-		"FiloSottile.mkcert",
 	}
 
 	fmt.Println("Installing core packages via Winget...")
@@ -100,6 +101,14 @@ func (w *WindowsAdapter) InstallNode(version string) error {
 	return cmd.Run()
 }
 
+func (w *WindowsAdapter) ListNodeVersions() ([]string, error) {
+	out, err := exec.Command("fnm", "list").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node versions: %w", err)
+	}
+	return adapters.ParseFnmList(out), nil
+}
+
 func (w *WindowsAdapter) GetNodePath(version string) (string, error) {
 	// fnm exec ... where node
 	cmd := exec.Command("fnm", "exec", "--using", version, "where", "node")
@@ -123,10 +132,15 @@ func (w *WindowsAdapter) GetNginxConfigPath() string {
 
 func (w *WindowsAdapter) WriteNginxConfig(config string) error {
 	path := w.GetNginxConfigPath()
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return err
+	installer := nginx.Installer{
+		Path: path,
+		Test: func() ([]byte, error) {
+			// nginx resolves its conf directory relative to the prefix
+			return exec.Command("nginx", "-t", "-p", filepath.Dir(filepath.Dir(path))).CombinedOutput()
+		},
+		Reload: w.ReloadNginx,
 	}
-	return os.WriteFile(path, []byte(config), 0644)
+	return installer.Apply(config)
 }
 
 func (w *WindowsAdapter) ReloadNginx() error {
@@ -161,32 +175,48 @@ func (w *WindowsAdapter) ListPHPVersions() ([]string, error) {
 }
 
 // System
-func (w *WindowsAdapter) UpdateHosts(domains []string) error {
-	// Requires Admin
-	// Read, check, append
-	// ...
-	return nil
+// ConfigureResolver is not supported: Windows can only route a domain to a
+// DNS server on port 53 (NRPT)
+func (w *WindowsAdapter) ConfigureResolver(tld, addr string) error {
+	return fmt.Errorf("routing .%s to %s is not supported on Windows; add hosts entries for your sites", tld, addr)
 }
 
-func (w *WindowsAdapter) InstallCertificates() error                          { return nil }
-func (w *WindowsAdapter) InstallMkcert() error                                { return nil }
-func (w *WindowsAdapter) GenerateCert(homeDir string, domains []string) error { return nil }
-func (w *WindowsAdapter) InstallBinary() error                                { return nil }
-func (w *WindowsAdapter) Uninstall() error                                    { return nil }
-func (w *WindowsAdapter) AddWebUserToGroup(group string) error                { return nil }
-func (w *WindowsAdapter) RestartPHP() error                                   { return nil }
-func (w *WindowsAdapter) CheckWifi() (bool, string)                           { return true, "Unknown" }
-func (w *WindowsAdapter) Doctor() error                                       { return nil }
-func (w *WindowsAdapter) GetLogPaths() map[string]string {
-	// Assuming standard install paths or derived from env
-	nginxHome := os.Getenv("NGINX_HOME")
-	if nginxHome == "" {
-		nginxHome = `C:\Program Files\nginx`
+// PHP runs as php-cgi on Windows, there is no FPM to give sites pools in
+func (w *WindowsAdapter) PHPPoolSocket(version, pool string) string { return "" }
+func (w *WindowsAdapter) SyncPHPPools(version string, pools map[string]string) error {
+	if len(pools) == 0 {
+		return nil
 	}
+	return fmt.Errorf("per-site PHP-FPM pools are not supported on Windows")
+}
+
+// PHP on Windows has a single php.ini and no conf.d for SLD to add to
+func (w *WindowsAdapter) PHPRuntime(version string) php.Runtime {
+	return php.Runtime{Version: version, Binary: "php"}
+}
+func (w *WindowsAdapter) SyncPHPIni(version, name, content string) error {
+	if content == "" {
+		return nil
+	}
+	return fmt.Errorf("managing PHP %s configuration is not supported on Windows; edit php.ini directly", version)
+}
+func (w *WindowsAdapter) PHPExtensionPackage(version, name string) string { return "" }
+func (w *WindowsAdapter) InstallPHPExtension(version, name string) error {
+	return fmt.Errorf("installing PHP extensions is not supported on Windows; add php_%s.dll to the ext directory", name)
+}
+func (w *WindowsAdapter) SetPHPExtension(version, name string, enabled bool) error {
+	return fmt.Errorf("enabling PHP extensions is not supported on Windows; edit php.ini directly")
+}
+
+func (w *WindowsAdapter) InstallBinary() error                 { return nil }
+func (w *WindowsAdapter) Uninstall(sldHome string) error       { return nil }
+func (w *WindowsAdapter) AddWebUserToGroup(group string) error { return nil }
+func (w *WindowsAdapter) RestartPHP() error                    { return nil }
+func (w *WindowsAdapter) CheckWifi() (bool, string)            { return true, "Unknown" }
+func (w *WindowsAdapter) Doctor() error                        { return nil }
+func (w *WindowsAdapter) GetLogPaths() map[string]string {
 	return map[string]string{
-		"nginx_access": filepath.Join(nginxHome, "logs", "access.log"),
-		"nginx_error":  filepath.Join(nginxHome, "logs", "error.log"),
-		"php_error":    `C:\tools\php\error.log`, // Example
+		"php_error": `C:\tools\php\error.log`, // Example
 	}
 }
 func (w *WindowsAdapter) GetServices() ([]adapters.ServiceStatus, error) {
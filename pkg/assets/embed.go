@@ -2,7 +2,6 @@ package assets
 
 import (
 	"embed"
-	"fmt"
 	"io/fs"
 	"net/http"
 	"os"
@@ -39,16 +38,6 @@ func Extract(destDir string) error {
 	})
 }
 
-// ReadTemplate reads an embedded template file.
-func ReadTemplate(name string) (string, error) {
-	path := fmt.Sprintf("runtime/nginx/%s", name)
-	data, err := assetsFS.ReadFile(path)
-	if err != nil {
-		return "", err
-	}
-	return string(data), nil
-}
-
 // GetGuiFS returns a file system for the GUI assets.
 func GetGuiFS() (http.FileSystem, error) {
 	sub, err := fs.Sub(assetsFS, "gui")
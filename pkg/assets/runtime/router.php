@@ -7,6 +7,23 @@
  * It routes requests to the appropriate project based on the hostname.
  */
 
+// Content type for static files (mime_content_type guesses text/plain for css/js)
+function sld_mime($file) {
+    switch (pathinfo($file, PATHINFO_EXTENSION)) {
+        case 'html': return 'text/html';
+        case 'css': return 'text/css';
+        case 'js':  return 'application/javascript';
+        case 'svg': return 'image/svg+xml';
+        case 'png': return 'image/png';
+        case 'jpg':
+        case 'jpeg': return 'image/jpeg';
+        case 'gif': return 'image/gif';
+        case 'webp': return 'image/webp';
+        case 'ico': return 'image/x-icon';
+        default: return mime_content_type($file);
+    }
+}
+
 // 1. Identify the host
 $host = $_SERVER['HTTP_HOST'];
 $tld = 'test'; // To be loaded from state in real impl
@@ -37,6 +54,7 @@ if (!file_exists($statePath)) {
 $state = json_decode(file_get_contents($statePath), true);
 $paths = $state['paths'] ?? [];
 $links = $state['links'] ?? [];
+$siteConfigs = $state['site_configs'] ?? [];
 
 $projectPath = null;
 
@@ -147,43 +165,63 @@ if (!$projectPath) {
 }
 
 // 3. Serve the project
-$publicPath = "$projectPath/public";
-$indexPath = "$publicPath/index.php";
+// The detected driver (pkg/drivers) and .sld.yaml decide web root and front controller
+$siteConfig = $siteConfigs["$domain.$tld"] ?? [];
+$driver = $siteConfig['driver'] ?? '';
+$front = ltrim($siteConfig['index'] ?? '', '/');
+if ($front === '') {
+    $front = in_array($driver, ['spa', 'static']) ? 'index.html' : 'index.php';
+}
 
-// If public/index.php doesn't exist, try project root
-if (!file_exists($indexPath)) {
-    $publicPath = $projectPath;
-    $indexPath = "$projectPath/index.php";
+if (!empty($siteConfig['web_root'])) {
+    $publicPath = "$projectPath/" . $siteConfig['web_root'];
+    $indexPath = "$publicPath/$front";
+} else {
+    $publicPath = "$projectPath/public";
+    $indexPath = "$publicPath/$front";
+
+    // If public/<front> doesn't exist, try project root
+    if (!file_exists($indexPath)) {
+        $publicPath = $projectPath;
+        $indexPath = "$projectPath/$front";
+    }
+}
+
+// Static sites and SPAs: serve files directly, SPAs fall back to index.html
+if (in_array($driver, ['spa', 'static'])) {
+    $uri = parse_url($_SERVER['REQUEST_URI'], PHP_URL_PATH);
+    $targetFile = realpath("$publicPath$uri");
+    if ($targetFile !== false && is_dir($targetFile)) {
+        $targetFile = realpath("$targetFile/index.html");
+    }
+    // The separator keeps siblings such as public-old/ out of public/
+    $publicRoot = realpath($publicPath);
+    $publicPrefix = $publicRoot === false ? false : rtrim($publicRoot, '/') . '/';
+    if ($targetFile === false || $publicPrefix === false || strpos($targetFile, $publicPrefix) !== 0) {
+        if ($driver === 'static') {
+            http_response_code(404);
+            echo "SLD: $uri not found.";
+            exit;
+        }
+        $targetFile = $indexPath;
+    }
+    header("Content-Type: " . sld_mime($targetFile));
+    readfile($targetFile);
+    exit;
 }
 
 if (file_exists($indexPath)) {
     // Simulate web server environment
     $_SERVER['DOCUMENT_ROOT'] = $publicPath;
     $_SERVER['SCRIPT_FILENAME'] = $indexPath;
-    $_SERVER['PHP_SELF'] = '/index.php'; // Simplified
+    $_SERVER['PHP_SELF'] = "/$front"; // Simplified
 
     // Serve static files if requested
     $uri = parse_url($_SERVER['REQUEST_URI'], PHP_URL_PATH);
     $targetFile = "$publicPath$uri";
 
     if ($uri !== '/' && file_exists($targetFile) && !is_dir($targetFile)) {
-        $ext = pathinfo($targetFile, PATHINFO_EXTENSION);
-        $mime = '';
-        
-        switch ($ext) {
-            case 'css': $mime = 'text/css'; break;
-            case 'js':  $mime = 'application/javascript'; break;
-            case 'svg': $mime = 'image/svg+xml'; break;
-            case 'png': $mime = 'image/png'; break;
-            case 'jpg': 
-            case 'jpeg': $mime = 'image/jpeg'; break;
-            case 'gif': $mime = 'image/gif'; break;
-            case 'webp': $mime = 'image/webp'; break;
-            case 'ico': $mime = 'image/x-icon'; break;
-            default: $mime = mime_content_type($targetFile);
-        }
-        
-        header("Content-Type: $mime");
+        header("Content-Type: " . sld_mime($targetFile));
         readfile($targetFile);
         exit;
     }
@@ -0,0 +1,309 @@
+// Package ca is SLD's own certificate authority. A root is created once per
+// SLD home, trusted by the system and the browsers' NSS databases, and signs
+// certificates for any list of site domains on demand, fully offline.
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	certFile = "rootCA.pem"
+	keyFile  = "rootCA-key.pem"
+
+	rootValidity = 10 * 365 * 24 * time.Hour
+	// leafValidity is the longest Apple platforms accept for TLS server certificates
+	leafValidity = 825 * 24 * time.Hour
+)
+
+// Authority is a root certificate and its key
+type Authority struct {
+	Dir  string
+	Cert *x509.Certificate
+
+	key     crypto.Signer
+	certPEM []byte
+}
+
+// Load reads the root kept in dir
+func Load(dir string) (*Authority, error) {
+	certPEM, err := os.ReadFile(filepath.Join(dir, certFile))
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dir, keyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := parseCert(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA certificate in %s: %w", dir, err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("invalid CA key in %s", dir)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported CA key in %s", dir)
+	}
+	return &Authority{Dir: dir, Cert: cert, key: signer, certPEM: certPEM}, nil
+}
+
+// LoadOrCreate reads the root kept in dir, generating it on first use.
+// created reports whether a new root was made (and so isn't trusted yet).
+func LoadOrCreate(dir string) (a *Authority, created bool, err error) {
+	a, err = Load(dir)
+	if err == nil {
+		return a, false, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, false, err
+	}
+	a, err = create(dir)
+	return a, err == nil, err
+}
+
+func create(dir string) (*Authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	name := "SLD Development CA"
+	if owner := owner(); owner != "" {
+		name += " (" + owner + ")"
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"Supreme Local Dev"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(rootValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whoever holds the root key can impersonate any site
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := writeFile(filepath.Join(dir, keyFile), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, certFile), certPEM, 0644); err != nil {
+		return nil, err
+	}
+	return &Authority{Dir: dir, Cert: cert, key: key, certPEM: certPEM}, nil
+}
+
+// CertFile is the path of the root certificate
+func (a *Authority) CertFile() string {
+	return filepath.Join(a.Dir, certFile)
+}
+
+// PEM is the root certificate, PEM encoded
+func (a *Authority) PEM() []byte {
+	return a.certPEM
+}
+
+// Fingerprint is the SHA-256 of the root certificate
+func (a *Authority) Fingerprint() string {
+	sum := sha256.Sum256(a.Cert.Raw)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// Signed reports whether cert was issued by this root
+func (a *Authority) Signed(cert *x509.Certificate) bool {
+	return cert.CheckSignatureFrom(a.Cert) == nil
+}
+
+// Issue signs a new server certificate for domains, which may include
+// wildcards (*.app.test) and IP addresses
+func (a *Authority) Issue(domains []string) (certPEM, keyPEM []byte, err error) {
+	if len(domains) == 0 {
+		return nil, nil, errors.New("no domains to issue a certificate for")
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: domains[0], Organization: []string{"Supreme Local Dev"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, d := range domains {
+		if ip := net.ParseIP(d); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, d)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, a.Cert, &key.PublicKey, a.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// IssueFiles issues a certificate for domains and installs it at certPath
+// and keyPath, replacing any previous one
+func (a *Authority) IssueFiles(certPath, keyPath string, domains []string) error {
+	certPEM, keyPEM, err := a.Issue(domains)
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{filepath.Dir(certPath), filepath.Dir(keyPath)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	// nginx's master process reads keys as root before dropping privileges
+	if err := writeFile(keyPath, keyPEM, 0600); err != nil {
+		return err
+	}
+	return writeFile(certPath, certPEM, 0644)
+}
+
+// ReadCert parses the (first) certificate in the PEM file at path
+func ReadCert(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseCert(data)
+}
+
+func parseCert(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// Uncovered returns the names cert isn't valid for. Wildcards are probed
+// with a subdomain, since VerifyHostname only takes host names.
+func Uncovered(cert *x509.Certificate, names []string) []string {
+	var missing []string
+	for _, name := range names {
+		probe := name
+		if rest, ok := strings.CutPrefix(name, "*."); ok {
+			probe = "any-site." + rest
+		}
+		if cert.VerifyHostname(probe) != nil {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// Names lists the DNS names and IP addresses cert was issued for
+func Names(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
+
+// writeFile replaces path atomically
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// owner names the root after the person it was made for (user@host), so
+// it can be told apart in trust store listings
+func owner() string {
+	name := os.Getenv("SUDO_USER")
+	if name == "" {
+		if u, err := user.Current(); err == nil {
+			name = u.Username
+		}
+	}
+	host, _ := os.Hostname()
+	switch {
+	case name != "" && host != "":
+		return name + "@" + host
+	case name != "":
+		return name
+	default:
+		return host
+	}
+}
@@ -0,0 +1,151 @@
+package ca
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadOrCreateKeepsRoot(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ca")
+	a, created, err := LoadOrCreate(dir)
+	if err != nil || !created {
+		t.Fatalf("LoadOrCreate = %v, %v; want a new root", created, err)
+	}
+	b, created, err := LoadOrCreate(dir)
+	if err != nil || created {
+		t.Fatalf("second LoadOrCreate = %v, %v; want the existing root", created, err)
+	}
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("root changed between loads")
+	}
+	if !a.Cert.IsCA {
+		t.Error("root is not a CA")
+	}
+	info, err := os.Stat(filepath.Join(dir, keyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestIssueVerifiesAgainstRoot(t *testing.T) {
+	a, _, err := LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "app.crt"), filepath.Join(dir, "app.key")
+	if err := a.IssueFiles(certPath, keyPath, []string{"app.test", "*.app.test", "127.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]os.FileMode{certPath: 0644, keyPath: 0600} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("%s mode = %v, want %v", filepath.Base(path), info.Mode().Perm(), want)
+		}
+	}
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(a.Cert)
+	for _, name := range []string{"app.test", "acme.app.test", "127.0.0.1"} {
+		if _, err := leaf.Verify(x509.VerifyOptions{DNSName: name, Roots: roots}); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{DNSName: "other.test", Roots: roots}); err == nil {
+		t.Error("other.test should not be covered")
+	}
+	if !a.Signed(leaf) {
+		t.Error("Signed = false for our own leaf")
+	}
+
+	other, _, err := LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Signed(leaf) {
+		t.Error("Signed = true for another root's leaf")
+	}
+}
+
+func TestUncovered(t *testing.T) {
+	a, _, err := LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(t.TempDir(), "dev.pem")
+	if err := a.IssueFiles(certPath, certPath+".key", []string{"*.test", "sld.test", "::1"}); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ReadCert(certPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := Uncovered(cert, []string{"sld.test", "blog.test", "*.test", "::1", "api.blog.test", "*.blog.test", "blog.dev"})
+	want := []string{"api.blog.test", "*.blog.test", "blog.dev"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Uncovered = %v, want %v", got, want)
+	}
+	if names := Names(cert); !reflect.DeepEqual(names, []string{"*.test", "sld.test", "::1"}) {
+		t.Errorf("Names = %v", names)
+	}
+}
+
+// A wildcard site's leaf covers its subdomains, one level deep
+func TestUncoveredWildcardSite(t *testing.T) {
+	a, _, err := LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(t.TempDir(), "app.test.pem")
+	if err := a.IssueFiles(certPath, certPath+".key", []string{"app.test", "*.app.test"}); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ReadCert(certPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Uncovered(cert, []string{"acme.app.test", "app.test", "*.app.test"}); len(got) != 0 {
+		t.Errorf("Uncovered = %v, want the wildcard leaf to cover acme.app.test", got)
+	}
+	if got := Uncovered(cert, []string{"api.acme.app.test"}); !reflect.DeepEqual(got, []string{"api.acme.app.test"}) {
+		t.Errorf("Uncovered = %v, want api.acme.app.test", got)
+	}
+}
+
+func TestSamePEM(t *testing.T) {
+	a, _, err := LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := append(append([]byte{}, other.PEM()...), a.PEM()...)
+	if !samePEM(bundle, a.Cert) {
+		t.Error("root not found in bundle")
+	}
+	if samePEM(other.PEM(), a.Cert) {
+		t.Error("another root matched")
+	}
+}
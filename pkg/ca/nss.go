@@ -0,0 +1,86 @@
+package ca
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// nssNickname is the root's name in NSS databases. Installs from before the
+// built-in CA used it for mkcert's root, which Install replaces.
+const nssNickname = "supremelocaldev"
+
+// nssStore is a browser certificate database (Firefox profiles, Chromium's
+// ~/.pki/nssdb), changed through certutil
+type nssStore struct {
+	dir   string
+	owner string
+}
+
+func (s nssStore) Name() string {
+	if strings.Contains(strings.ToLower(s.dir), "firefox") || strings.Contains(s.dir, ".mozilla") {
+		return "Firefox"
+	}
+	return "Chromium"
+}
+
+func (s nssStore) Path() string { return s.dir }
+
+func (s nssStore) Installed(root *x509.Certificate) (bool, error) {
+	out, err := s.certutil(nil, "-L", "-n", nssNickname, "-a")
+	if err != nil {
+		if _, lookErr := exec.LookPath("certutil"); lookErr != nil {
+			return false, errNoCertutil
+		}
+		return false, nil // Not listed
+	}
+	return samePEM(out, root), nil
+}
+
+func (s nssStore) Install(a *Authority) error {
+	if _, err := exec.LookPath("certutil"); err != nil {
+		return errNoCertutil
+	}
+	// Adding under a nickname that exists fails, so drop the old root first
+	s.certutil(nil, "-D", "-n", nssNickname)
+	// The root is piped in: the CA directory isn't readable by the owner
+	if out, err := s.certutil(a.PEM(), "-A", "-t", "C,,", "-n", nssNickname); err != nil {
+		return fmt.Errorf("certutil: %v (%s)", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// certutil runs against the database as its owner
+func (s nssStore) certutil(stdin []byte, args ...string) ([]byte, error) {
+	args = append([]string{"-d", "sql:" + s.dir}, args...)
+	var cmd *exec.Cmd
+	if s.owner != "" && os.Geteuid() == 0 {
+		cmd = exec.Command("sudo", append([]string{"-u", s.owner, "certutil"}, args...)...)
+	} else {
+		cmd = exec.Command("certutil", args...)
+	}
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	return cmd.CombinedOutput()
+}
+
+var errNoCertutil = fmt.Errorf("certutil not found (install libnss3-tools, or nss on macOS)")
+
+// findNSS returns the directories matching patterns that hold a cert9.db
+func findNSS(home string, patterns []string) []string {
+	var dirs []string
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(filepath.Join(home, pattern))
+		for _, dir := range matches {
+			if _, err := os.Stat(filepath.Join(dir, "cert9.db")); err == nil {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
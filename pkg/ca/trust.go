@@ -0,0 +1,89 @@
+package ca
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"os/exec"
+)
+
+// Store is somewhere the root can be trusted
+type Store interface {
+	Name() string // e.g. "system", "Firefox"
+	Path() string
+	Installed(root *x509.Certificate) (bool, error)
+	Install(a *Authority) error
+}
+
+// TrustResult is whether one store trusts the root
+type TrustResult struct {
+	Store   string `json:"store"`
+	Path    string `json:"path"`
+	Trusted bool   `json:"trusted"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Stores returns the system trust store and every browser NSS database
+// under home. NSS databases are changed as owner (sudo's SUDO_USER) when
+// running as root, so their files keep the right ownership.
+func Stores(home, owner string) []Store {
+	stores := []Store{systemStore()}
+	for _, dir := range nssDirs(home) {
+		stores = append(stores, nssStore{dir: dir, owner: owner})
+	}
+	return stores
+}
+
+// Trust installs the root into every store that doesn't hold it yet
+func (a *Authority) Trust(stores []Store) []TrustResult {
+	results := make([]TrustResult, 0, len(stores))
+	for _, s := range stores {
+		r := TrustResult{Store: s.Name(), Path: s.Path()}
+		if ok, _ := s.Installed(a.Cert); ok {
+			r.Trusted = true
+		} else if err := s.Install(a); err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Trusted = true
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// TrustStatus reports which stores hold the root, without changing any
+func (a *Authority) TrustStatus(stores []Store) []TrustResult {
+	results := make([]TrustResult, 0, len(stores))
+	for _, s := range stores {
+		r := TrustResult{Store: s.Name(), Path: s.Path()}
+		ok, err := s.Installed(a.Cert)
+		r.Trusted = ok
+		if err != nil {
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// samePEM reports whether data holds the certificate cert
+func samePEM(data []byte, cert *x509.Certificate) bool {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return false
+		}
+		if string(block.Bytes) == string(cert.Raw) {
+			return true
+		}
+	}
+}
+
+// privileged runs a command as root, through sudo unless already root
+func privileged(name string, args ...string) *exec.Cmd {
+	if os.Geteuid() == 0 {
+		return exec.Command(name, args...)
+	}
+	return exec.Command("sudo", append([]string{name}, args...)...)
+}
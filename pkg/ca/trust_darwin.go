@@ -0,0 +1,35 @@
+package ca
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+const systemKeychain = "/Library/Keychains/System.keychain"
+
+// keychainStore is the macOS System keychain
+type keychainStore struct{}
+
+func systemStore() Store { return keychainStore{} }
+
+func (keychainStore) Name() string { return "system" }
+func (keychainStore) Path() string { return systemKeychain }
+
+// Installed asks the platform verifier, which honours keychain trust settings
+func (keychainStore) Installed(root *x509.Certificate) (bool, error) {
+	_, err := root.Verify(x509.VerifyOptions{})
+	return err == nil, nil
+}
+
+func (keychainStore) Install(a *Authority) error {
+	cmd := privileged("security", "add-trusted-cert", "-d", "-r", "trustRoot", "-k", systemKeychain, a.CertFile())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("security add-trusted-cert: %v (%s)", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func nssDirs(home string) []string {
+	return findNSS(home, []string{"Library/Application Support/Firefox/Profiles/*"})
+}
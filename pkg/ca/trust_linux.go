@@ -0,0 +1,79 @@
+package ca
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// anchors are the distro trust stores, each with the command that rebuilds
+// the system bundle from it
+var anchors = []struct {
+	dir, file string
+	update    []string
+}{
+	{"/usr/local/share/ca-certificates", "sld-rootCA.crt", []string{"update-ca-certificates"}},           // Debian, Ubuntu
+	{"/etc/pki/ca-trust/source/anchors", "sld-rootCA.pem", []string{"update-ca-trust", "extract"}},       // Fedora, RHEL
+	{"/etc/ca-certificates/trust-source/anchors", "sld-rootCA.crt", []string{"trust", "extract-compat"}}, // Arch
+	{"/usr/share/pki/trust/anchors", "sld-rootCA.pem", []string{"update-ca-certificates"}},               // openSUSE
+}
+
+// linuxStore is the distro's anchor directory
+type linuxStore struct {
+	file   string
+	update []string
+}
+
+func systemStore() Store {
+	for _, a := range anchors {
+		if info, err := os.Stat(a.dir); err == nil && info.IsDir() {
+			return linuxStore{file: filepath.Join(a.dir, a.file), update: a.update}
+		}
+	}
+	return linuxStore{}
+}
+
+func (s linuxStore) Name() string { return "system" }
+func (s linuxStore) Path() string { return s.file }
+
+func (s linuxStore) Installed(root *x509.Certificate) (bool, error) {
+	if s.file == "" {
+		return false, errNoSystemStore
+	}
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return false, nil
+	}
+	return samePEM(data, root), nil
+}
+
+func (s linuxStore) Install(a *Authority) error {
+	if s.file == "" {
+		return errNoSystemStore
+	}
+	cp := privileged("tee", s.file)
+	cp.Stdin = bytes.NewReader(a.PEM())
+	if out, err := cp.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write %s: %v (%s)", s.file, err, strings.TrimSpace(string(out)))
+	}
+	if out, err := privileged(s.update[0], s.update[1:]...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v (%s)", strings.Join(s.update, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+var errNoSystemStore = fmt.Errorf("no supported system trust store found")
+
+func nssDirs(home string) []string {
+	return findNSS(home, []string{
+		".pki/nssdb",
+		".mozilla/firefox/*",
+		"snap/firefox/common/.mozilla/firefox/*",
+		"snap/chromium/current/.pki/nssdb",
+		".var/app/org.mozilla.firefox/.mozilla/firefox/*",
+		".var/app/org.chromium.Chromium/.pki/nssdb",
+	})
+}
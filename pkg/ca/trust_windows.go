@@ -0,0 +1,33 @@
+package ca
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// rootStore is the machine's Trusted Root Certification Authorities
+type rootStore struct{}
+
+func systemStore() Store { return rootStore{} }
+
+func (rootStore) Name() string { return "system" }
+func (rootStore) Path() string { return `Cert:\LocalMachine\Root` }
+
+// Installed asks the platform verifier, which reads the Windows stores
+func (rootStore) Installed(root *x509.Certificate) (bool, error) {
+	_, err := root.Verify(x509.VerifyOptions{})
+	return err == nil, nil
+}
+
+// Install needs an elevated prompt
+func (rootStore) Install(a *Authority) error {
+	if out, err := exec.Command("certutil", "-addstore", "-f", "ROOT", a.CertFile()).CombinedOutput(); err != nil {
+		return fmt.Errorf("certutil -addstore: %v (%s)", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Firefox on Windows trusts the system store (security.enterprise_roots)
+func nssDirs(home string) []string { return nil }
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the daemon configuration, read from /etc/sld/config.yaml and
+// then $SLD_HOME/config.yaml (later files override earlier ones).
+type Config struct {
+	Home        string `yaml:"home" json:"home"`                 // SLD home, overridden by $SLD_HOME
+	APIPort     int    `yaml:"api_port" json:"api_port"`         // Daemon API / dashboard port
+	BindAddress string `yaml:"bind_address" json:"bind_address"` // Interface the API listens on
+	HTTPSPort   int    `yaml:"https_port" json:"https_port"`     // Port nginx serves HTTPS sites on
+	LogDir      string `yaml:"log_dir" json:"log_dir"`           // SLD nginx logs (access, error, X-Ray)
+	ProjectsDir string `yaml:"projects_dir" json:"projects_dir"` // Default directory for new projects
+
+	DNSPort      int      `yaml:"dns_port" json:"dns_port"`           // Embedded resolver for the TLD on 127.0.0.1, 0 disables it
+	DNSUpstreams []string `yaml:"dns_upstreams" json:"dns_upstreams"` // Where other queries go; defaults to the system's nameservers
+}
+
+// Defaults returns the configuration used when no file sets a value
+func Defaults() *Config {
+	return &Config{
+		Home:        DefaultHome,
+		APIPort:     2025,
+		BindAddress: "127.0.0.1",
+		HTTPSPort:   443,
+		DNSPort:     2053,
+	}
+}
+
+// Load resolves the effective configuration
+func Load() (*Config, error) {
+	cfg := Defaults()
+
+	// 1. System file, which may also move the home
+	if err := cfg.mergeFile(SystemConfigFile); err != nil {
+		return nil, err
+	}
+
+	// 2. The environment wins for the home
+	cfg.Home = ResolveHome()
+
+	// 3. Per-home overrides, so isolated instances can use their own ports
+	home := cfg.Home
+	if err := cfg.mergeFile(filepath.Join(home, "config.yaml")); err != nil {
+		return nil, err
+	}
+	cfg.Home = home
+
+	if cfg.APIPort <= 0 || cfg.APIPort > 65535 {
+		return nil, fmt.Errorf("invalid api_port %d", cfg.APIPort)
+	}
+	if cfg.HTTPSPort <= 0 || cfg.HTTPSPort > 65535 {
+		return nil, fmt.Errorf("invalid https_port %d", cfg.HTTPSPort)
+	}
+	if cfg.DNSPort < 0 || cfg.DNSPort > 65535 {
+		return nil, fmt.Errorf("invalid dns_port %d", cfg.DNSPort)
+	}
+	return cfg, nil
+}
+
+// mergeFile overlays values set in path; a missing file is not an error
+func (c *Config) mergeFile(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Unmarshalling into the existing struct keeps fields the file doesn't set
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return nil
+}
+
+// Paths derives all locations from the configured home and log dir
+func (c *Config) Paths() Paths {
+	p := NewPaths(c.Home)
+	if c.LogDir != "" {
+		p.Logs = c.LogDir
+	}
+	return p
+}
+
+// ListenAddr is the address the API server binds to
+func (c *Config) ListenAddr() string {
+	return net.JoinHostPort(c.BindAddress, strconv.Itoa(c.APIPort))
+}
+
+// APIAddr is the host:port local clients (CLI, nginx) use to reach the API
+func (c *Config) APIAddr() string {
+	host := c.BindAddress
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(c.APIPort))
+}
+
+// DNSAddr is where the embedded resolver listens, empty when disabled
+func (c *Config) DNSAddr() string {
+	if c.DNSPort == 0 {
+		return ""
+	}
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(c.DNSPort))
+}
+
+// APIURL is the base URL of the daemon API
+func (c *Config) APIURL() string {
+	return "http://" + c.APIAddr()
+}
+
+// readFile parses a single config file
+func readFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadHomeOverrides(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("SLD_HOME", home)
+
+	yaml := "api_port: 3030\nbind_address: 0.0.0.0\nhttps_port: 8443\nlog_dir: /tmp/sld-logs\n"
+	if err := os.WriteFile(filepath.Join(home, "config.yaml"), []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Home != home {
+		t.Errorf("expected home %s, got %s", home, cfg.Home)
+	}
+	if cfg.APIPort != 3030 || cfg.HTTPSPort != 8443 {
+		t.Errorf("ports not applied: %+v", cfg)
+	}
+	// Wildcard binds are reached through loopback
+	if cfg.APIURL() != "http://127.0.0.1:3030" {
+		t.Errorf("unexpected API URL %s", cfg.APIURL())
+	}
+	if cfg.ListenAddr() != "0.0.0.0:3030" {
+		t.Errorf("unexpected listen address %s", cfg.ListenAddr())
+	}
+
+	paths := cfg.Paths()
+	if paths.State != filepath.Join(home, "state.json") || paths.Logs != "/tmp/sld-logs" {
+		t.Errorf("paths not derived from config: %+v", paths)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("SLD_HOME", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIPort != 2025 || cfg.BindAddress != "127.0.0.1" || cfg.HTTPSPort != 443 {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if cfg.DNSAddr() != "127.0.0.1:2053" {
+		t.Errorf("unexpected DNS address %s", cfg.DNSAddr())
+	}
+}
+
+func TestPathsRemovable(t *testing.T) {
+	home := t.TempDir()
+	paths := NewPaths(home)
+	if err := paths.Removable(); err == nil {
+		t.Error("a home without state.json should not be removable")
+	}
+	if err := os.WriteFile(paths.State, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := paths.Removable("/home/dev"); err != nil {
+		t.Errorf("SLD home should be removable: %v", err)
+	}
+
+	// Pointing SLD_HOME at a user's home must never wipe it
+	if err := paths.Removable(home); err == nil {
+		t.Error("a user's home should not be removable")
+	}
+	if err := paths.Removable(filepath.Join(home, "dev")); err == nil {
+		t.Error("a parent of a user's home should not be removable")
+	}
+	for _, dir := range []string{"/", "/var/lib", "relative/home"} {
+		if err := NewPaths(dir).Removable(); err == nil {
+			t.Errorf("%s should not be removable", dir)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultHome is used when neither SLD_HOME nor the config file set a home
+const DefaultHome = "/var/lib/sld"
+
+// SystemConfigFile is the machine-wide daemon configuration
+const SystemConfigFile = "/etc/sld/config.yaml"
+
+// Paths lists every location SLD writes to. All of them derive from Home,
+// so a different home gives a fully isolated instance.
+type Paths struct {
+	Home      string // Root of everything below
+	State     string // state.json
+	Plugins   string // Plugin data and binaries
+	Certs     string // TLS certificates served by nginx
+	CA        string // Root certificate authority that signs Certs (its key stays here)
+	Snapshots string // Database snapshots
+	Runtime   string // Extracted runtime assets (router.php, ...)
+	Bin       string // Downloaded helper binaries (cloudflared)
+	Logs      string // SLD specific nginx logs (access, error, X-Ray)
+	Hooks     string // Global lifecycle hooks (post-link, pre-unlink, ...)
+}
+
+// NewPaths derives all locations from home
+func NewPaths(home string) Paths {
+	// The default install keeps its logs where logrotate already looks
+	logs := filepath.Join(home, "logs")
+	if home == DefaultHome {
+		logs = "/var/log/nginx"
+	}
+
+	return Paths{
+		Home:      home,
+		State:     filepath.Join(home, "state.json"),
+		Plugins:   filepath.Join(home, "plugins"),
+		Certs:     filepath.Join(home, "certs"),
+		CA:        filepath.Join(home, "ca"),
+		Snapshots: filepath.Join(home, "snapshots"),
+		Runtime:   filepath.Join(home, "runtime"),
+		Bin:       filepath.Join(home, "bin"),
+		Logs:      logs,
+		Hooks:     filepath.Join(home, "hooks"),
+	}
+}
+
+// ResolveHome picks the SLD home: $SLD_HOME first, then `home:` from the
+// system config file, then DefaultHome.
+func ResolveHome() string {
+	if home := os.Getenv("SLD_HOME"); home != "" {
+		if abs, err := filepath.Abs(home); err == nil {
+			return abs
+		}
+		return home
+	}
+
+	if cfg, err := readFile(SystemConfigFile); err == nil && cfg.Home != "" {
+		return cfg.Home
+	}
+
+	return DefaultHome
+}
+
+// XRayLog is where nginx writes the JSON access log X-Ray tails
+func (p Paths) XRayLog() string {
+	return filepath.Join(p.Logs, "sld-xray.log")
+}
+
+// AccessLog is the plain access log of SLD's nginx servers
+func (p Paths) AccessLog() string {
+	return filepath.Join(p.Logs, "sld-access.log")
+}
+
+// ErrorLog is the error log of SLD's nginx servers
+func (p Paths) ErrorLog() string {
+	return filepath.Join(p.Logs, "sld-error.log")
+}
+
+// systemDirs are never removed as an SLD home, whatever the config says
+var systemDirs = []string{"/", "/home", "/root", "/usr", "/usr/local", "/var", "/var/lib", "/etc", "/opt", "/tmp", "/Users"}
+
+// Removable reports why Home can't be deleted wholesale on uninstall. It
+// has to hold SLD's state and must not be a system directory or one of
+// userHomes (or a parent of them), which SLD_HOME or home: may point at.
+func (p Paths) Removable(userHomes ...string) error {
+	home := filepath.Clean(p.Home)
+	if !filepath.IsAbs(home) {
+		return fmt.Errorf("%s is not an absolute path", p.Home)
+	}
+	for _, dir := range systemDirs {
+		if home == dir {
+			return fmt.Errorf("%s is a system directory", home)
+		}
+	}
+	for _, user := range userHomes {
+		if user == "" {
+			continue
+		}
+		user = filepath.Clean(user)
+		if home == user || strings.HasPrefix(user, home+string(filepath.Separator)) {
+			return fmt.Errorf("%s contains the home directory %s", home, user)
+		}
+	}
+	if _, err := os.Stat(p.State); err != nil {
+		return fmt.Errorf("%s holds no SLD state", home)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon"
+)
+
+// handleCerts lists every certificate SLD manages with its expiry, names
+// and status
+func (s *Server) handleCerts(w http.ResponseWriter, r *http.Request) {
+	d, _ := daemon.GetClient()
+	certs, err := d.Certificates()
+	if err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+		return
+	}
+	jsonResponse(w, certs, 200)
+}
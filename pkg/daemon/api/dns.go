@@ -0,0 +1,14 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon"
+)
+
+// handleDNSStatus reports whether the built-in DNS server answers and the
+// system resolver routes the TLD to it
+func (s *Server) handleDNSStatus(w http.ResponseWriter, r *http.Request) {
+	d, _ := daemon.GetClient()
+	jsonResponse(w, d.DNSStatus(), 200)
+}
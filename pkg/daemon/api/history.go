@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon"
+)
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
+		limit = v
+	}
+
+	d, _ := daemon.GetClient()
+	entries, err := d.History(limit)
+	if err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+		return
+	}
+	jsonResponse(w, entries, 200)
+}
+
+func (s *Server) handleHistoryUndo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		return
+	}
+	var req struct {
+		Count int `json:"count"`
+	}
+	// An empty body means "undo the last operation"
+	json.NewDecoder(r.Body).Decode(&req)
+
+	d, _ := daemon.GetClient()
+	undone, err := d.Undo(req.Count)
+	if err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+		return
+	}
+	jsonResponse(w, map[string]interface{}{
+		"success": true,
+		"undone":  undone,
+	}, 200)
+}
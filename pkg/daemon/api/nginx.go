@@ -0,0 +1,14 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon"
+)
+
+// handleNginxStatus reports whether nginx accepted the last generated config
+// and, if not, the offending line and the plugin or site it came from
+func (s *Server) handleNginxStatus(w http.ResponseWriter, r *http.Request) {
+	d, _ := daemon.GetClient()
+	jsonResponse(w, d.NginxStatus(), 200)
+}
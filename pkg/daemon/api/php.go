@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon"
+)
+
+// handlePHPExtensions lists the extensions of a PHP version (GET) or
+// enables or disables one (POST), installing its package when needed
+func (s *Server) handlePHPExtensions(w http.ResponseWriter, r *http.Request) {
+	d, _ := daemon.GetClient()
+	version := r.PathValue("version")
+
+	switch r.Method {
+	case "GET":
+		extensions, err := d.PHPExtensions(version)
+		if err != nil {
+			jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+			return
+		}
+		jsonResponse(w, extensions, 200)
+	case "POST":
+		var req struct {
+			Name    string `json:"name"`
+			Enabled bool   `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+			jsonResponse(w, ErrorResponse{Error: "Invalid request"}, 400)
+			return
+		}
+		var err error
+		if req.Enabled {
+			err = d.EnablePHPExtension(version, req.Name)
+		} else {
+			err = d.DisablePHPExtension(version, req.Name)
+		}
+		if err != nil {
+			jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+			return
+		}
+		jsonResponse(w, SuccessResponse{Success: true}, 200)
+	}
+}
+
+// handlePHPIni returns the php.ini settings of a PHP version (GET) or
+// overrides one (POST); unset drops the override
+func (s *Server) handlePHPIni(w http.ResponseWriter, r *http.Request) {
+	d, _ := daemon.GetClient()
+	version := r.PathValue("version")
+
+	switch r.Method {
+	case "GET":
+		ini, err := d.PHPIniSettings(version)
+		if err != nil {
+			jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+			return
+		}
+		jsonResponse(w, ini, 200)
+	case "POST":
+		var req struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+			Unset bool   `json:"unset"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+			jsonResponse(w, ErrorResponse{Error: "Invalid request"}, 400)
+			return
+		}
+		var err error
+		if req.Unset {
+			err = d.UnsetPHPIni(version, req.Name)
+		} else {
+			err = d.SetPHPIni(version, req.Name, req.Value)
+		}
+		if err != nil {
+			jsonResponse(w, ErrorResponse{Error: err.Error()}, 400)
+			return
+		}
+		jsonResponse(w, SuccessResponse{Success: true}, 200)
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon"
+)
+
+// handleExport streams an environment archive.
+// Query: snapshots=a.sql,b.sql or all_snapshots=true
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		return
+	}
+
+	opts := daemon.ExportOptions{
+		AllSnapshots: r.URL.Query().Get("all_snapshots") == "true",
+	}
+	if list := r.URL.Query().Get("snapshots"); list != "" {
+		opts.Snapshots = strings.Split(list, ",")
+	}
+
+	// Build into a temp file first so errors can still be reported as JSON
+	tmp, err := os.CreateTemp("", "sld-export-*.tar.gz")
+	if err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	d, _ := daemon.GetClient()
+	if _, err := d.Export(tmp, opts); err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+		return
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+		return
+	}
+
+	filename := fmt.Sprintf("sld-export-%s.tar.gz", time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	io.Copy(w, tmp)
+}
+
+// handleImport restores an uploaded environment archive (multipart field "file")
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		return
+	}
+
+	const maxUploadSize = 1 << 30 // 1GB, archives may carry snapshots
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		jsonResponse(w, ErrorResponse{Error: "Error parsing form: " + err.Error()}, 400)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		jsonResponse(w, ErrorResponse{Error: "Error retrieving file"}, 400)
+		return
+	}
+	defer file.Close()
+
+	d, _ := daemon.GetClient()
+	result, err := d.Import(file)
+	if err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+		return
+	}
+	jsonResponse(w, result, 200)
+}
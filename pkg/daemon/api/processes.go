@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon"
+)
+
+// handleProcesses returns the supervised processes of ?site=
+func (s *Server) handleProcesses(w http.ResponseWriter, r *http.Request) {
+	site := r.URL.Query().Get("site")
+	if site == "" {
+		jsonResponse(w, ErrorResponse{Error: "site parameter required"}, 400)
+		return
+	}
+
+	d, _ := daemon.GetClient()
+	statuses, err := d.ProcessStatus(site)
+	if err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 404)
+		return
+	}
+	jsonResponse(w, statuses, 200)
+}
+
+// handleProcessControl starts, stops or restarts a site's processes
+func (s *Server) handleProcessControl(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			return
+		}
+		var req struct {
+			Site string `json:"site"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Site == "" {
+			jsonResponse(w, ErrorResponse{Error: "Invalid request"}, 400)
+			return
+		}
+
+		d, _ := daemon.GetClient()
+		var err error
+		switch action {
+		case "start":
+			_, err = d.StartProcesses(req.Site)
+		case "stop":
+			err = d.StopProcesses(req.Site)
+		case "restart":
+			_, err = d.RestartProcesses(req.Site)
+		}
+		if err != nil {
+			jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+			return
+		}
+
+		statuses, _ := d.ProcessStatus(req.Site)
+		jsonResponse(w, statuses, 200)
+	}
+}
+
+// handleProcessLogs returns recent output of ?site=, limited by ?lines= (default 100)
+func (s *Server) handleProcessLogs(w http.ResponseWriter, r *http.Request) {
+	site := r.URL.Query().Get("site")
+	if site == "" {
+		jsonResponse(w, ErrorResponse{Error: "site parameter required"}, 400)
+		return
+	}
+	lines := 100
+	if v := r.URL.Query().Get("lines"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			lines = n
+		}
+	}
+
+	d, _ := daemon.GetClient()
+	logs, err := d.ProcessLogs(site, lines)
+	if err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 404)
+		return
+	}
+	jsonResponse(w, logs, 200)
+}
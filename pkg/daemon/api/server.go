@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -15,15 +16,18 @@ import (
 	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/metrics"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/plugins"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/project"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/services"
 )
 
 type Server struct {
+	Bind string
 	Port int
 }
 
-func NewServer(port int) *Server {
-	return &Server{Port: port}
+func NewServer(bind string, port int) *Server {
+	return &Server{Bind: bind, Port: port}
 }
 
 func (s *Server) Start() error {
@@ -35,9 +39,16 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/forget", s.handleForget)
 	mux.HandleFunc("/api/link", s.handleLink)
 	mux.HandleFunc("/api/unlink", s.handleUnlink)
+	mux.HandleFunc("/api/proxy", s.handleProxy)
+	mux.HandleFunc("/api/dns", s.handleDNSStatus)
+	mux.HandleFunc("/api/unproxy", s.handleUnproxy)
 	mux.HandleFunc("/api/php", s.handlePHP)
 	mux.HandleFunc("/api/php/versions", s.handlePHPVersions)
+	mux.HandleFunc("/api/php/{version}/extensions", s.handlePHPExtensions)
+	mux.HandleFunc("/api/php/{version}/ini", s.handlePHPIni)
 	mux.HandleFunc("/api/secure", s.handleSecure)
+	mux.HandleFunc("/api/unsecure", s.handleUnsecure)
+	mux.HandleFunc("/api/certs", s.handleCerts)
 	mux.HandleFunc("/api/restart", s.handleRestart)
 	mux.HandleFunc("/api/sites", s.handleSites)
 	mux.HandleFunc("/api/sites/update", s.handleSiteUpdate)
@@ -52,6 +63,14 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/share/start", s.handleShareStart)
 	mux.HandleFunc("/api/share/stop", s.handleShareStop)
 	mux.HandleFunc("/api/share/status", s.handleShareStatus)
+	mux.HandleFunc("/api/export", s.handleExport)
+	mux.HandleFunc("/api/import", s.handleImport)
+	mux.HandleFunc("/api/history", s.handleHistory)
+	mux.HandleFunc("/api/history/undo", s.handleHistoryUndo)
+	mux.HandleFunc("/api/workspaces", s.handleWorkspaces)
+	mux.HandleFunc("/api/workspaces/use", s.handleWorkspaceUse)
+	mux.HandleFunc("/api/workspaces/delete", s.handleWorkspaceDelete)
+	mux.HandleFunc("/api/config", s.handleConfig)
 
 	// Database Manager
 	mux.HandleFunc("/api/db/status", s.handleDBStatus)
@@ -75,12 +94,20 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/services", s.handleServices)
 	mux.HandleFunc("/api/services/control", s.handleServiceControl)
 	mux.HandleFunc("/api/system/doctor", s.handleSystemDoctor)
+	mux.HandleFunc("/api/nginx", s.handleNginxStatus)
 
 	// Logging
 	mux.HandleFunc("/api/logs/sources", s.handleLogSources)
 	mux.HandleFunc("/api/logs/watch", s.handleLogWatch)
 	mux.HandleFunc("/api/logs/unwatch", s.handleLogUnwatch)
 
+	// Process Supervisor
+	mux.HandleFunc("/api/proc", s.handleProcesses)
+	mux.HandleFunc("/api/proc/start", s.handleProcessControl("start"))
+	mux.HandleFunc("/api/proc/stop", s.handleProcessControl("stop"))
+	mux.HandleFunc("/api/proc/restart", s.handleProcessControl("restart"))
+	mux.HandleFunc("/api/proc/logs", s.handleProcessLogs)
+
 	// Supreme Healer
 	mux.HandleFunc("/api/healer/issues", s.handleHealerIssues)
 	mux.HandleFunc("/api/healer/resolve", s.handleHealerResolve)
@@ -90,6 +117,8 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/projects/ghost", s.handleProjectGhost)
 	mux.HandleFunc("/api/projects/ghost/discard", s.handleProjectGhostDiscard)
 	mux.HandleFunc("/api/projects/templates", s.handleGetTemplates) // New route
+	mux.HandleFunc("/api/projects/validate", s.handleProjectValidate)
+	mux.HandleFunc("/api/projects/schema", s.handleProjectSchema)
 	mux.HandleFunc("/api/system/editors", s.handleSystemEditors)
 	mux.HandleFunc("/api/system/open-editor", s.handleSystemOpenEditor)
 	mux.HandleFunc("/api/system/directories", s.handleSystemDirectories)
@@ -136,8 +165,19 @@ func (s *Server) Start() error {
 		fileServer.ServeHTTP(w, r)
 	})
 
-	fmt.Printf("SLD Daemon listening on port %d...\n", s.Port)
-	return http.ListenAndServe(fmt.Sprintf(":%d", s.Port), s.corsMiddleware(mux))
+	addr := net.JoinHostPort(s.Bind, strconv.Itoa(s.Port))
+	fmt.Printf("SLD Daemon listening on %s...\n", addr)
+	return http.ListenAndServe(addr, s.corsMiddleware(mux))
+}
+
+// handleConfig exposes the effective daemon configuration to the dashboard
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	d, _ := daemon.GetClient()
+	jsonResponse(w, map[string]interface{}{
+		"config":  d.Config,
+		"api_url": d.Config.APIURL(),
+		"paths":   d.Paths,
+	}, 200)
 }
 
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
@@ -165,6 +205,23 @@ type SuccessResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// HookResponse is returned by actions that run lifecycle hooks. Success
+// refers to the action itself; hooks that failed are listed in HookErrors.
+type HookResponse struct {
+	Success    bool               `json:"success"`
+	Message    string             `json:"message,omitempty"`
+	Hooks      []services.HookRun `json:"hooks,omitempty"`
+	HookErrors []string           `json:"hook_errors,omitempty"`
+}
+
+func hookResponse(runs []services.HookRun) HookResponse {
+	res := HookResponse{Success: true, Hooks: runs}
+	for _, r := range services.FailedHooks(runs) {
+		res.HookErrors = append(res.HookErrors, fmt.Sprintf("%s hook %q failed: %s", r.Event, r.Command, r.Error))
+	}
+	return res
+}
+
 func jsonResponse(w http.ResponseWriter, data interface{}, code int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*") // For dev
@@ -175,7 +232,7 @@ func jsonResponse(w http.ResponseWriter, data interface{}, code int) {
 
 func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
 	d, _ := daemon.GetClient()
-	jsonResponse(w, d.State.Data, 200)
+	jsonResponse(w, d.State.Snapshot(), 200)
 }
 
 func (s *Server) handlePark(w http.ResponseWriter, r *http.Request) {
@@ -191,11 +248,12 @@ func (s *Server) handlePark(w http.ResponseWriter, r *http.Request) {
 	}
 
 	d, _ := daemon.GetClient()
-	if err := d.Park(req.Path); err != nil {
+	runs, err := d.Park(req.Path)
+	if err != nil {
 		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
 		return
 	}
-	jsonResponse(w, SuccessResponse{Success: true}, 200)
+	jsonResponse(w, hookResponse(runs), 200)
 }
 
 func (s *Server) handleForget(w http.ResponseWriter, r *http.Request) {
@@ -237,11 +295,12 @@ func (s *Server) handleLink(w http.ResponseWriter, r *http.Request) {
 	// Best to assume GUI sends valid paths, but we can verify.
 	path, _ := filepath.Abs(req.Path)
 
-	if err := d.Link(req.Name, path); err != nil {
+	runs, err := d.Link(req.Name, path)
+	if err != nil {
 		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
 		return
 	}
-	jsonResponse(w, SuccessResponse{Success: true}, 200)
+	jsonResponse(w, hookResponse(runs), 200)
 }
 
 func (s *Server) handleUnlink(w http.ResponseWriter, r *http.Request) {
@@ -257,11 +316,12 @@ func (s *Server) handleUnlink(w http.ResponseWriter, r *http.Request) {
 	}
 
 	d, _ := daemon.GetClient()
-	if err := d.Unlink(req.Name); err != nil {
+	runs, err := d.Unlink(req.Name)
+	if err != nil {
 		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
 		return
 	}
-	jsonResponse(w, SuccessResponse{Success: true}, 200)
+	jsonResponse(w, hookResponse(runs), 200)
 }
 
 func (s *Server) handlePHP(w http.ResponseWriter, r *http.Request) {
@@ -297,12 +357,42 @@ func (s *Server) handlePHPVersions(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, versions, 200)
 }
 
+// handleSecure enables HTTPS globally, or for one site when "site" is given
 func (s *Server) handleSecure(w http.ResponseWriter, r *http.Request) {
+	s.handleSecureToggle(w, r, true)
+}
+
+// handleUnsecure disables HTTPS globally, or for one site when "site" is given
+func (s *Server) handleUnsecure(w http.ResponseWriter, r *http.Request) {
+	s.handleSecureToggle(w, r, false)
+}
+
+func (s *Server) handleSecureToggle(w http.ResponseWriter, r *http.Request, secure bool) {
 	if r.Method != "POST" {
 		return
 	}
+	var req struct {
+		Site string `json:"site"`
+	}
+	// The body is optional: no site means every site
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 400)
+		return
+	}
+
 	d, _ := daemon.GetClient()
-	if err := d.Secure(); err != nil {
+	var err error
+	switch {
+	case req.Site != "" && secure:
+		err = d.SecureSite(req.Site)
+	case req.Site != "":
+		err = d.UnsecureSite(req.Site)
+	case secure:
+		err = d.Secure()
+	default:
+		err = d.Unsecure()
+	}
+	if err != nil {
 		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
 		return
 	}
@@ -347,7 +437,7 @@ func (s *Server) handleSiteUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	d, _ := daemon.GetClient()
-	conf, ok := d.State.Data.SiteConfigs[req.Domain]
+	conf, ok := d.State.Snapshot().SiteConfigs[req.Domain]
 	if !ok {
 		conf = state.SiteConfig{}
 	}
@@ -447,8 +537,9 @@ func (s *Server) handleProjectCreate(w http.ResponseWriter, r *http.Request) {
 		projectPath := filepath.Join(base, req.Name)
 
 		// Check if the project is in a parked directory (avoid duplicate listing)
+		snapshot := d.State.Snapshot()
 		isInParkedPath := false
-		for _, parkedPath := range d.State.Data.Paths {
+		for _, parkedPath := range snapshot.Paths {
 			if strings.HasPrefix(projectPath, parkedPath) {
 				isInParkedPath = true
 				break
@@ -460,7 +551,7 @@ func (s *Server) handleProjectCreate(w http.ResponseWriter, r *http.Request) {
 
 		if isInParkedPath {
 			// Project is in a parked path, just regenerate certs if secure mode is on
-			if d.State.Data.Secure {
+			if snapshot.Secure {
 				if err := d.Refresh(); err != nil {
 					fmt.Printf("[ERROR] Failed to refresh after project creation: %v\n", err)
 					return
@@ -469,13 +560,16 @@ func (s *Server) handleProjectCreate(w http.ResponseWriter, r *http.Request) {
 			fmt.Printf("[INFO] Project %s created in parked path %s\n", req.Name, projectPath)
 		} else {
 			// Project is NOT in a parked path, link it explicitly
-			if err := d.Link(req.Name, projectPath); err != nil {
+			if _, err := d.Link(req.Name, projectPath); err != nil {
 				fmt.Printf("[ERROR] Failed to link project %s: %v\n", req.Name, err)
 				return
 			}
 			fmt.Printf("[INFO] Project %s created and linked at %s\n", req.Name, projectPath)
 		}
 
+		// Results stream to the dashboard as hook events
+		d.RunPostCreateHooks(req.Name, projectPath)
+
 		// Emit event to update UI
 		d.Events.Publish(events.Event{Type: events.SitesUpdated})
 	}()
@@ -516,7 +610,7 @@ func (s *Server) handleProjectGhost(w http.ResponseWriter, r *http.Request) {
 
 		// Link the new ghost project
 		ghostName := filepath.Base(targetPath)
-		if err := d.Link(ghostName, targetPath); err != nil {
+		if _, err := d.Link(ghostName, targetPath); err != nil {
 			fmt.Printf("[GHOST MODE] Failed to link %s: %v\n", ghostName, err)
 			return
 		}
@@ -528,6 +622,38 @@ func (s *Server) handleProjectGhost(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, SuccessResponse{Success: true, Message: "Ghost clone started in background"}, 202)
 }
 
+func (s *Server) handleProjectValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		return
+	}
+
+	var req struct {
+		Path string `json:"path"` // Project directory or .sld.yaml file
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 400)
+		return
+	}
+	if req.Path == "" {
+		jsonResponse(w, ErrorResponse{Error: "path required"}, 400)
+		return
+	}
+
+	d, _ := daemon.GetClient()
+	result, err := d.ValidateProject(req.Path)
+	if err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 400)
+		return
+	}
+	jsonResponse(w, result, 200)
+}
+
+// handleProjectSchema publishes the .sld.yaml JSON Schema for editors
+func (s *Server) handleProjectSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(project.Schema)
+}
+
 func (s *Server) handleProjectGhostDiscard(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		return
@@ -607,7 +733,7 @@ func (s *Server) handlePlugins(w http.ResponseWriter, r *http.Request) {
 	d, _ := daemon.GetClient()
 
 	// Convert map to slice for simpler JSON
-	plugins := d.PluginManager.GetAll()
+	all := d.PluginManager.GetAll()
 
 	// Create a response struct that maps Plugin interface to JSON fields
 	type PluginResponse struct {
@@ -617,18 +743,23 @@ func (s *Server) handlePlugins(w http.ResponseWriter, r *http.Request) {
 		Version     string `json:"version"`
 		Status      string `json:"status"`
 		Installed   bool   `json:"installed"`
+		UIPort      int    `json:"ui_port,omitempty"`
 	}
 
 	var response []PluginResponse
-	for _, p := range plugins {
-		response = append(response, PluginResponse{
+	for _, p := range all {
+		plugin := PluginResponse{
 			ID:          p.ID(),
 			Name:        p.Name(),
 			Description: p.Description(),
 			Version:     p.Version(),
 			Status:      string(p.Status()),
 			Installed:   p.IsInstalled(),
-		})
+		}
+		if ui, ok := p.(plugins.UIProvider); ok {
+			plugin.UIPort = ui.UIPort()
+		}
+		response = append(response, plugin)
 	}
 
 	jsonResponse(w, response, 200)
@@ -785,13 +916,14 @@ func (s *Server) handleShareStart(w http.ResponseWriter, r *http.Request) {
 	d, _ := daemon.GetClient()
 
 	// Determine target based on Secure mode
+	snapshot := d.State.Snapshot()
 	target := "http://localhost:80" // Default
-	if d.State.Data.Port != "" {
-		target = fmt.Sprintf("http://localhost:%s", d.State.Data.Port)
+	if snapshot.Port != "" {
+		target = fmt.Sprintf("http://localhost:%s", snapshot.Port)
 	}
 
-	if d.State.Data.Secure {
-		target = "https://localhost:443"
+	if snapshot.Secure {
+		target = fmt.Sprintf("https://localhost:%d", d.Config.HTTPSPort)
 	}
 
 	url, err := d.TunnelManager.StartTunnel(req.Site, target)
@@ -1617,7 +1749,7 @@ func (s *Server) handleServices(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleSystemDoctor(w http.ResponseWriter, r *http.Request) {
 	d, _ := daemon.GetClient()
-	checks, err := d.Adapter.GetSystemHealth()
+	checks, err := d.SystemHealth()
 	if err != nil {
 		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
 		return
@@ -140,4 +140,29 @@ func SetupEventBridge(hub *Hub) {
 			"data": e.Payload,
 		}
 	})
+
+	// Forward lifecycle hook output and results
+	for _, t := range []events.EventType{events.HookOutput, events.HookFinished} {
+		d.Events.Subscribe(t, func(e events.Event) {
+			hub.broadcast <- map[string]interface{}{
+				"type": string(t),
+				"data": e.Payload,
+			}
+		})
+	}
+
+	// Forward fine-grained state changes
+	for _, t := range []events.EventType{
+		events.SiteAdded,
+		events.SiteRemoved,
+		events.SiteConfigChanged,
+		events.PHPVersionChanged,
+	} {
+		d.Events.Subscribe(t, func(e events.Event) {
+			hub.broadcast <- map[string]interface{}{
+				"type": string(t),
+				"data": e.Payload,
+			}
+		})
+	}
 }
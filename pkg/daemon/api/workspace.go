@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon"
+)
+
+// handleWorkspaces lists workspaces (GET) or creates one (POST)
+func (s *Server) handleWorkspaces(w http.ResponseWriter, r *http.Request) {
+	d, _ := daemon.GetClient()
+
+	switch r.Method {
+	case "GET":
+		jsonResponse(w, d.ListWorkspaces(), 200)
+	case "POST":
+		var req struct {
+			Name string `json:"name"`
+			Copy bool   `json:"copy"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonResponse(w, ErrorResponse{Error: "Invalid request"}, 400)
+			return
+		}
+		if err := d.CreateWorkspace(req.Name, req.Copy); err != nil {
+			jsonResponse(w, ErrorResponse{Error: err.Error()}, 400)
+			return
+		}
+		jsonResponse(w, SuccessResponse{Success: true, Message: "Workspace created"}, 200)
+	}
+}
+
+func (s *Server) handleWorkspaceUse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, ErrorResponse{Error: "Invalid request"}, 400)
+		return
+	}
+
+	d, _ := daemon.GetClient()
+	if err := d.UseWorkspace(req.Name); err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 500)
+		return
+	}
+	jsonResponse(w, SuccessResponse{Success: true, Message: "Switched to workspace " + req.Name}, 200)
+}
+
+func (s *Server) handleWorkspaceDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, ErrorResponse{Error: "Invalid request"}, 400)
+		return
+	}
+
+	d, _ := daemon.GetClient()
+	if err := d.DeleteWorkspace(req.Name); err != nil {
+		jsonResponse(w, ErrorResponse{Error: err.Error()}, 400)
+		return
+	}
+	jsonResponse(w, SuccessResponse{Success: true}, 200)
+}
@@ -0,0 +1,246 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/ca"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/nginx"
+)
+
+// sharedCertPaths is the certificate of the wildcard and dashboard servers
+func (d *Daemon) sharedCertPaths() (cert, key string) {
+	return filepath.Join(d.Paths.Certs, "dev.pem"), filepath.Join(d.Paths.Certs, "dev-key.pem")
+}
+
+// siteCertPaths is the certificate issued for one domain
+func (d *Daemon) siteCertPaths(domain string) (cert, key string) {
+	return filepath.Join(d.Paths.Certs, domain+".pem"), filepath.Join(d.Paths.Certs, domain+"-key.pem")
+}
+
+// sharedCertDomains are the names the shared certificate covers.
+// *.test doesn't match sld.test itself, so the dashboard is listed too.
+func sharedCertDomains(tld string) []string {
+	return []string{"*." + tld, "sld." + tld, "localhost", "127.0.0.1", "::1"}
+}
+
+// siteTLS returns the site's own certificate when it has been issued
+func (d *Daemon) siteTLS(domain string) *nginx.TLS {
+	if !d.State.HasCertificate(domain) {
+		return nil
+	}
+	cert, key := d.siteCertPaths(domain)
+	if _, err := os.Stat(cert); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(key); err != nil {
+		return nil
+	}
+	return &nginx.TLS{Certificate: cert, Key: key}
+}
+
+// ensureCerts issues the certificates HTTPS sites are missing and rebuilds
+// nginx. Certificates that still cover their names are left alone, so
+// linking or securing one site doesn't touch the others.
+func (d *Daemon) ensureCerts() error {
+	return d.syncCerts(false)
+}
+
+// reissueCerts issues every certificate again, e.g. under a new CA
+func (d *Daemon) reissueCerts() error {
+	return d.syncCerts(true)
+}
+
+func (d *Daemon) syncCerts(reissue bool) error {
+	s := d.State.Snapshot()
+	sites, err := d.GetSites()
+	if err != nil {
+		return err
+	}
+	var secured []Site
+	for _, site := range sites {
+		config := s.SiteConfigs[site.Domain]
+		// In secure mode isolated sites get their own certificate too, so aliases are covered
+		if config.Secure || (s.Secure && config.NeedsServerBlock()) {
+			secured = append(secured, site)
+		}
+	}
+	// Without HTTPS anywhere the CA isn't needed, and isn't created
+	if !s.Secure && len(secured) == 0 {
+		return d.refreshNginxConfig()
+	}
+
+	auth, err := d.authority()
+	if err != nil {
+		return err
+	}
+
+	if s.Secure {
+		cert, key := d.sharedCertPaths()
+		// Probe the wildcard with an arbitrary site name
+		tld := s.TLD
+		if reissue || !certCovers(auth, cert, []string{"sld." + tld, "any-site." + tld}) {
+			if err := auth.IssueFiles(cert, key, sharedCertDomains(s.TLD)); err != nil {
+				return fmt.Errorf("failed to generate certs: %w", err)
+			}
+		}
+	}
+
+	for _, site := range secured {
+		config := s.SiteConfigs[site.Domain]
+		names := serverNames(s.TLD, site.Domain, config)
+		cert, _ := d.siteCertPaths(site.Domain)
+		if !reissue && d.State.HasCertificate(site.Domain) && certCovers(auth, cert, names) {
+			continue
+		}
+		if err := d.issueSiteCert(auth, site.Domain, names); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	return d.refreshNginxConfig()
+}
+
+// issueSiteCert issues and records the certificate of one domain
+func (d *Daemon) issueSiteCert(auth *ca.Authority, domain string, names []string) error {
+	cert, key := d.siteCertPaths(domain)
+	if err := auth.IssueFiles(cert, key, names); err != nil {
+		return fmt.Errorf("failed to issue a certificate for %s: %w", domain, err)
+	}
+	return d.State.AddCertificate(domain)
+}
+
+// SecureSite serves one site over HTTPS with its own certificate, leaving
+// other sites as they are
+func (d *Daemon) SecureSite(name string) error {
+	s := d.State.Snapshot()
+	site, err := d.findSite(name)
+	if err != nil {
+		return err
+	}
+	auth, err := d.TrustCA()
+	if err != nil {
+		return err
+	}
+
+	config := s.SiteConfigs[site.Domain]
+	config.Secure = true
+	if err := d.issueSiteCert(auth, site.Domain, serverNames(s.TLD, site.Domain, config)); err != nil {
+		return err
+	}
+	if err := d.State.SetSiteConfig(site.Domain, config); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	d.Events.Publish(events.Event{Type: events.SitesUpdated})
+	if err := d.refreshNginxConfig(); err != nil {
+		return err
+	}
+	fmt.Printf("%s is now served over HTTPS 🔒\n", site.Domain)
+	return nil
+}
+
+// UnsecureSite serves one site over plain HTTP again. Its certificate is
+// kept for the next `sld secure`.
+func (d *Daemon) UnsecureSite(name string) error {
+	s := d.State.Snapshot()
+	site, err := d.findSite(name)
+	if err != nil {
+		return err
+	}
+
+	config := s.SiteConfigs[site.Domain]
+	if config.Secure {
+		config.Secure = false
+		if err := d.State.SetSiteConfig(site.Domain, config); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		d.Events.Publish(events.Event{Type: events.SitesUpdated})
+		if err := d.refreshNginxConfig(); err != nil {
+			return err
+		}
+	}
+
+	if s.Secure {
+		fmt.Printf("HTTPS is enabled for all sites; run `sld unsecure` to serve %s over HTTP.\n", site.Domain)
+		return nil
+	}
+	fmt.Printf("%s is now served over HTTP 🔓\n", site.Domain)
+	return nil
+}
+
+// removeSiteCert deletes the certificate of a site that is no longer served
+func (d *Daemon) removeSiteCert(domain string) {
+	if !d.State.HasCertificate(domain) {
+		return
+	}
+	cert, key := d.siteCertPaths(domain)
+	os.Remove(cert)
+	os.Remove(key)
+	d.State.RemoveCertificate(domain)
+}
+
+// certCovers reports whether the PEM certificate at path was signed by
+// auth and is valid now and for every name. Certificates from another root
+// (e.g. mkcert's, before the built-in CA) are issued again.
+func certCovers(auth *ca.Authority, path string, names []string) bool {
+	cert, err := ca.ReadCert(path)
+	if err != nil || !auth.Signed(cert) {
+		return false
+	}
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return false
+	}
+	return len(ca.Uncovered(cert, names)) == 0
+}
+
+// authority loads SLD's root certificate authority, creating it on first use
+func (d *Daemon) authority() (*ca.Authority, error) {
+	auth, created, err := ca.LoadOrCreate(d.Paths.CA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the certificate authority: %w", err)
+	}
+	if created {
+		fmt.Printf("Created certificate authority %q in %s\n", auth.Cert.Subject.CommonName, d.Paths.CA)
+	}
+	return auth, nil
+}
+
+// trustStores are the system store and the invoking user's browser databases
+func (d *Daemon) trustStores() []ca.Store {
+	return ca.Stores(getRealUserHome(), os.Getenv("SUDO_USER"))
+}
+
+// TrustCA installs the root into every trust store that doesn't hold it yet
+// and prints which ones do. A store that can't be updated is only a warning:
+// sites are still served, that browser just shows a warning.
+func (d *Daemon) TrustCA() (*ca.Authority, error) {
+	auth, err := d.authority()
+	if err != nil {
+		return nil, err
+	}
+	results := auth.Trust(d.trustStores())
+
+	fmt.Printf("Certificate authority: %s\n", auth.Cert.Subject.CommonName)
+	fmt.Printf("  SHA-256 %s\n", auth.Fingerprint())
+	for _, r := range results {
+		if r.Trusted {
+			fmt.Printf("  ✓ %-8s %s\n", r.Store, r.Path)
+		} else {
+			fmt.Printf("  ✗ %-8s %s: %s\n", r.Store, r.Path, r.Error)
+		}
+	}
+	return auth, nil
+}
+
+// CATrust reports which trust stores hold the root, without changing any
+func (d *Daemon) CATrust() ([]ca.TrustResult, error) {
+	auth, err := d.authority()
+	if err != nil {
+		return nil, err
+	}
+	return auth.TrustStatus(d.trustStores()), nil
+}
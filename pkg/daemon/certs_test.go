@@ -0,0 +1,44 @@
+package daemon
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/ca"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
+)
+
+func TestServerNames(t *testing.T) {
+	got := serverNames("test", "acme.test", state.SiteConfig{Wildcard: true, Aliases: []string{"api.acme", " ", "admin.test"}})
+	want := []string{"acme.test", "*.acme.test", "api.acme.test", "admin.test"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("serverNames = %v, want %v", got, want)
+	}
+}
+
+func TestCertCoversWildcardSite(t *testing.T) {
+	auth, _, err := ca.LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := ca.LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "acme.test.pem")
+	if err := auth.IssueFiles(cert, filepath.Join(dir, "acme.test-key.pem"), []string{"acme.test", "*.acme.test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !certCovers(auth, cert, []string{"acme.test", "*.acme.test", "api.acme.test"}) {
+		t.Error("leaf issued for the wildcard site doesn't cover it")
+	}
+	if certCovers(auth, cert, []string{"acme.test", "admin.test"}) {
+		t.Error("leaf covers an alias it wasn't issued for")
+	}
+	if certCovers(other, cert, []string{"acme.test"}) {
+		t.Error("leaf of another root counts as covered")
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"os/exec"
 	"os/user"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"runtime"
 
@@ -18,8 +20,11 @@ import (
 	"github.com/supreme-majesty/supreme-local-dev/pkg/adapters/macos"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/adapters/windows"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/assets"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/config"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/dns"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/nginx"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/plugins"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/project"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/services"
@@ -28,6 +33,8 @@ import (
 type Daemon struct {
 	State           *state.Manager
 	Events          *events.Bus
+	Config          *config.Config
+	Paths           config.Paths
 	Adapter         adapters.SystemAdapter
 	PluginManager   *plugins.Manager
 	TunnelManager   *services.TunnelManager
@@ -38,6 +45,24 @@ type Daemon struct {
 	EnvManager      *services.EnvManager
 	ArtisanService  *services.ArtisanService
 	HealerService   *services.HealerService
+	Supervisor      *services.Supervisor
+	Hooks           *services.HookRunner
+	DNS             *dns.Server // Embedded resolver, only in the long-running daemon (see StartDNS)
+
+	supervising bool       // Set in the long-running daemon, see StartSupervisor
+	stopWatch   func()     // Stops the state watcher, see WatchState
+	syncMu      sync.Mutex // Serializes syncProcesses
+
+	nginxMu     sync.Mutex
+	nginxStatus NginxStatus // Outcome of the last refreshNginxConfig
+
+	certMu      sync.Mutex  // Guards certTimer
+	certTimer   *time.Timer // Pending check after site changes, see StartCertRenewal
+	certCheckMu sync.Mutex  // Serializes checkCerts
+	certIssues  map[string]bool
+
+	phpMu     sync.Mutex // Serializes SyncPluginPHP
+	phpIssues map[string]bool
 }
 
 var instance *Daemon
@@ -48,8 +73,13 @@ func Initialize() (*Daemon, error) {
 		return instance, nil
 	}
 
-	// 1. Load State
-	stateManager, err := state.NewManager()
+	// 1. Load config and State from the SLD home
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	paths := cfg.Paths()
+	stateManager, err := state.NewManager(paths.Home)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load state: %w", err)
 	}
@@ -62,14 +92,19 @@ func Initialize() (*Daemon, error) {
 	eventBus := events.NewBus()
 
 	// 3. Initialize Plugin Manager
-	// We use /var/lib/sld/plugins for shared plugin data/binaries
-	pluginManager := plugins.NewManager("/var/lib/sld/plugins", stateManager)
-	tunnelManager := services.NewTunnelManager("/var/lib/sld")
-	xrayService := services.NewXRayService(eventBus)
+	// Plugins keep their shared data/binaries under the SLD home
+	pluginManager := plugins.NewManager(paths.Plugins, stateManager)
+	tunnelManager := services.NewTunnelManager(paths.Home)
+	xrayService := services.NewXRayService(eventBus, paths.XRayLog())
 	// LogWatcher moved down to depend on adapter
-	databaseService := services.NewDatabaseService()
+	databaseService := services.NewDatabaseService(paths.Snapshots)
 	home := getRealUserHome()
-	baseDir := findBestDevDir(home)
+	baseDir := cfg.ProjectsDir
+	if baseDir == "" {
+		baseDir = findBestDevDir(home)
+	} else if strings.HasPrefix(baseDir, "~/") {
+		baseDir = filepath.Join(home, baseDir[2:])
+	}
 	projectManager := services.NewProjectManager(baseDir)
 
 	// Start X-Ray immediately
@@ -96,11 +131,17 @@ func Initialize() (*Daemon, error) {
 		return nil, fmt.Errorf("unsupported OS: %s", runtime.GOOS)
 	}
 
-	logWatcher := services.NewLogWatcher(eventBus, adapter.GetLogPaths)
+	logWatcher := services.NewLogWatcher(eventBus, func() map[string]string {
+		logs := adapter.GetLogPaths()
+		logs["nginx_access"], logs["nginx_error"] = paths.AccessLog(), paths.ErrorLog()
+		return logs
+	})
 
 	instance = &Daemon{
 		State:           stateManager,
 		Events:          eventBus,
+		Config:          cfg,
+		Paths:           paths,
 		Adapter:         adapter,
 		PluginManager:   pluginManager,
 		TunnelManager:   tunnelManager,
@@ -111,11 +152,25 @@ func Initialize() (*Daemon, error) {
 		EnvManager:      services.NewEnvManager(),
 		ArtisanService:  services.NewArtisanService(eventBus),
 		HealerService:   services.NewHealerService(eventBus),
+		Supervisor:      services.NewSupervisor(logWatcher),
+		Hooks:           services.NewHookRunner(eventBus, paths.Hooks),
 	}
 
 	// Start Healer
 	instance.HealerService.Start()
 
+	// Plugins can need PHP extensions and settings
+	pluginManager.OnChange = func() {
+		if err := instance.SyncPluginPHP(); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	// "install_php_ext:redis@8.2"; without a version the default PHP is used
+	instance.HealerService.RegisterFix("install_php_ext", func(arg string) error {
+		name, version, _ := strings.Cut(arg, "@")
+		return instance.EnablePHPExtension(version, name)
+	})
+
 	return instance, nil
 }
 
@@ -138,34 +193,15 @@ func (d *Daemon) EnsureInstalled() error {
 		return fmt.Errorf("failed to install binary: %w", err)
 	}
 
-	// Extract to /var/lib/sld (globally accessible for Nginx)
-	sldBase := "/var/lib/sld"
+	// Extract to the SLD home (must be readable by Nginx)
+	sldBase := d.Paths.Home
 	fmt.Printf("Extracting runtime assets to %s...\n", sldBase)
 	if err := assets.Extract(sldBase); err != nil {
 		return fmt.Errorf("failed to extract assets: %w", err)
 	}
 
-	// Create config.inc.php pointing to user state
-	realHome := getRealUserHome()
-	userState := filepath.Join(realHome, ".sld", "state.json")
-
-	// Create .sld directory for user if not exists and fix permissions
-	userSld := filepath.Join(realHome, ".sld")
-	if _, err := os.Stat(userSld); os.IsNotExist(err) {
-		// We are sudo, so we must be careful with ownership
-		// For now, let's create it with 755 permissions.
-		os.MkdirAll(userSld, 0755)
-		// We should chown it to real user, but Go `os.Chown` requires Uid/Gid lookup.
-		// exec "chown" is easier.
-		// sudoUser := os.Getenv("SUDO_USER")
-		// exec.Command("chown", "-R", sudoUser+":"+sudoUser, userSld).Run()
-	}
-	// Make sure it is world readable (or at least Nginx readable)
-	exec.Command("chmod", "755", userSld).Run()
-	// And state.json if exists
-	exec.Command("chmod", "644", userState).Run()
-
 	// 3a. Fix Permissions for Web Server (Add www-data to user group)
+	realHome := getRealUserHome()
 	sudoUser := os.Getenv("SUDO_USER")
 	if sudoUser != "" {
 		fmt.Printf("Adding web user to group %s...\n", sudoUser)
@@ -181,29 +217,26 @@ func (d *Daemon) EnsureInstalled() error {
 		d.Adapter.RestartPHP()
 	}
 
-	// 4. Global State Setup for Multi-User Support
-	globalState := "/var/lib/sld/state.json"
-
-	// Create state if not exists
-	if _, err := os.Stat(globalState); os.IsNotExist(err) {
-		emptyState := `{"services":{},"certificates":[],"php_version":"","secure":false,"tld":"test","paths":[],"links":{}}`
-		os.WriteFile(globalState, []byte(emptyState), 0666)
+	// 4. State lives in the SLD home; loading creates it on first install
+	if err := d.State.Load(); err != nil {
+		fmt.Printf("Warning: Failed to initialize state: %v\n", err)
 	}
 
-	// Ensure state is world writable so any user can park paths
-	exec.Command("chmod", "666", globalState).Run()
-	// Ensure directory is world writable/executable
-	exec.Command("chmod", "777", "/var/lib/sld").Run()
+	// Hand the home to the real user instead of making it world writable,
+	// so they can run sld without sudo. Others can be added to their group.
+	d.fixHomeOwnership(sudoUser)
 
-	configFile := filepath.Join(sldBase, "runtime", "config.inc.php")
-	phpConfig := fmt.Sprintf("<?php $sld_state_path = '%s'; ?>", globalState)
+	configFile := filepath.Join(d.Paths.Runtime, "config.inc.php")
+	phpConfig := fmt.Sprintf("<?php $sld_state_path = '%s'; ?>", d.Paths.State)
+	os.MkdirAll(filepath.Dir(configFile), 0755)
 	os.WriteFile(configFile, []byte(phpConfig), 0644)
 
 	// Set PHP Version in State if detection succeeds
-	if v := d.Adapter.GetPHPVersion(); v != "" && d.State.Data.PHPVersion == "" {
+	if v := d.Adapter.GetPHPVersion(); v != "" && d.State.Snapshot().PHPVersion == "" {
 		fmt.Printf("Detected PHP %s. Setting as default.\n", v)
-		d.State.Data.PHPVersion = v
-		d.State.Save()
+		if err := d.State.SetPHPVersion(v); err != nil {
+			fmt.Printf("Warning: Failed to save PHP version: %v\n", err)
+		}
 	}
 
 	fmt.Println("Configuring Nginx...")
@@ -225,32 +258,6 @@ func (d *Daemon) EnsureInstalled() error {
 		return fmt.Errorf("failed to configure nginx: %w", err)
 	}
 
-	// Create TLD in State if not exists (default test)
-	// This saves to the loaded state path (user's home, if Daemon loaded correctly).
-	// Since we run as sudo, `d.State` might be pointing to /root/.sld/state.json if initialized naïvely.
-	// But `daemon.Initialize` calls `state.NewManager` which uses `os.UserHomeDir`.
-	// If running as sudo, `os.UserHomeDir` is /root.
-	// So `d.State` is modifying ROOT's state.
-	// But `router.php` is configured to read REAL USER's state.
-	// We need to `Switch` the state manager to the real user's path?
-	// Or just copy the TLD init logic.
-	// Actually, `sld install` is mostly for SYSTEM setup.
-	// The USER will run `sld park` later (as user).
-	// `sld park` (as user) will initialize `~/.sld/state.json`.
-	// So we don't strictly need to populate `~/.sld/state.json` here.
-	// BUT `router.php` will fail if file doesn't exist.
-	// So we should initialize an empty state for the user.
-
-	if _, err := os.Stat(userState); os.IsNotExist(err) {
-		emptyState := `{"services":{},"certificates":[],"php_version":"","secure":false,"tld":"test","paths":[],"links":{}}`
-		os.WriteFile(userState, []byte(emptyState), 0644)
-		// Fix ownership
-		sudoUser := os.Getenv("SUDO_USER")
-		if sudoUser != "" {
-			exec.Command("chown", sudoUser, userState).Run()
-		}
-	}
-
 	// Sync hosts initially
 	if err := instance.syncHosts(); err != nil {
 		fmt.Printf("Warning: Failed to initial sync hosts: %v\n", err)
@@ -259,246 +266,188 @@ func (d *Daemon) EnsureInstalled() error {
 	return nil
 }
 
-func (d *Daemon) syncHosts() error {
-	// Reverted: User requested to not hardcode projects in /etc/hosts
-	return nil
-}
+// fixHomeOwnership gives the SLD home to owner (the real user behind sudo).
+// Files stay group-writable for other developers but never world-writable.
+func (d *Daemon) fixHomeOwnership(owner string) {
+	home := d.Paths.Home
+	os.MkdirAll(home, 0755)
 
-func replaceSocket(config, newSocket string) string {
-	// Our templates use this default socket path
-	defaultSocket := "unix:/run/php/php-fpm.sock"
-	// newSocket usually is "/run/php/php8.1-fpm.sock"
-	target := "unix:" + newSocket
-	return strings.ReplaceAll(config, defaultSocket, target)
+	if owner != "" {
+		// "user:" also sets the group to the user's login group
+		exec.Command("chown", "-R", owner+":", home).Run()
+	}
+	exec.Command("chmod", "-R", "u+rwX,g+rwX,o-w", home).Run()
 }
 
-func (d *Daemon) replacePaths(config string) string {
-	// Global runtime path
-	runtimePath := "/var/lib/sld/runtime"
-	config = strings.ReplaceAll(config, "{{SLD_RUNTIME_PATH}}", runtimePath)
-
-	// Certs path: /var/lib/.sld/certs
-	// Template has {{HOME}}/.sld/certs
-	// We map {{HOME}}/.sld -> /var/lib/.sld
-	config = strings.ReplaceAll(config, "{{HOME}}/.sld", "/var/lib/.sld")
-
-	// Just in case {{HOME}} is used elsewhere
-	config = strings.ReplaceAll(config, "{{HOME}}", "/var/lib")
-
-	return config
+func (d *Daemon) syncHosts() error {
+	// Reverted: User requested to not hardcode projects in /etc/hosts
+	return nil
 }
 
 // Helper to write Nginx config with current state (PHP version, etc)
 func (d *Daemon) refreshNginxConfig() error {
-	// 1. Get Base Config
-	templateName := "sld.conf"
-	if d.State.Data.Secure {
-		templateName = "sld-ssl.conf"
+	s := d.State.Snapshot()
+	httpPort, err := strconv.Atoi(s.Port)
+	if err != nil || httpPort <= 0 {
+		httpPort = 80
+	}
+	opts := nginx.Options{
+		TLD:        s.TLD,
+		HTTPPort:   httpPort,
+		HTTPSPort:  d.Config.HTTPSPort,
+		Secure:     s.Secure,
+		RuntimeDir: d.Paths.Runtime,
+		LogDir:     d.Paths.Logs,
+		CertsDir:   d.Paths.Certs,
+		APIAddr:    d.Config.APIAddr(),
+	}
+	if s.PHPVersion != "" {
+		if socket, err := d.Adapter.CheckPHPSocket(s.PHPVersion); err == nil {
+			opts.PHPSocket = socket
+		}
 	}
 
-	baseConfig, err := assets.ReadTemplate(templateName)
-	if err != nil {
-		return fmt.Errorf("failed to read embedded template %s: %w", templateName, err)
-	}
+	sites, versions := d.isolatedSites(s)
+	pools := d.sitePools(s, sites, versions)
+	blocks := d.pluginNginxBlocks()
 
-	// 2. Perform Standard Replacements on Base Config
-	baseConfig = d.replacePaths(baseConfig)
+	// write serves each pooled site from its pool, unless its version's
+	// pools failed to install
+	write := func(failed map[string]bool) error {
+		served := append([]nginx.Site(nil), sites...)
+		for i, pool := range pools {
+			if !failed[versions[i]] {
+				served[i].Socket = pool.Socket
+			}
+		}
+		config, err := nginx.Build(opts, served, blocks).Render()
+		if err != nil {
+			return fmt.Errorf("failed to render nginx config: %w", err)
+		}
+		err = d.Adapter.WriteNginxConfig(config)
+		d.recordNginxResult(err)
+		return err
+	}
 
-	port := d.State.Data.Port
-	if port == "" {
-		port = "80"
+	if err := write(nil); err != nil {
+		return err
 	}
-	baseConfig = strings.ReplaceAll(baseConfig, "listen 80;", fmt.Sprintf("listen %s;\n    listen [::]:%s;", port, port))
-	baseConfig = strings.ReplaceAll(baseConfig, "listen 443 ssl http2;", "listen 443 ssl http2;\n    listen [::]:443 ssl http2;")
+	// Pools only change once nginx has accepted the config that uses them,
+	// so a rejected config never leaves it pointing at removed sockets
+	if failed := d.syncPools(pools, versions); len(failed) > 0 {
+		return write(failed)
+	}
+	return nil
+}
 
-	if d.State.Data.PHPVersion != "" {
-		socketPath, err := d.Adapter.CheckPHPSocket(d.State.Data.PHPVersion)
-		if err == nil {
-			baseConfig = replaceSocket(baseConfig, socketPath)
+// isolatedSites collects the sites that can't be served by the shared
+// wildcard server and resolves each one's path. versions maps the index of
+// each site that gets its own PHP-FPM pool to its PHP version.
+func (d *Daemon) isolatedSites(s *state.State) ([]nginx.Site, map[int]string) {
+	var sites []nginx.Site
+	versions := map[int]string{} // PHP version of each site that gets its own pool
+	for domain, config := range s.SiteConfigs {
+		if !config.NeedsServerBlock() {
+			continue
 		}
-	}
 
-	// 3. Generate Isolated Server Blocks
-	isolationBlocks := ""
-	for domain, config := range d.State.Data.SiteConfigs {
-		if config.PHPVersion != "" {
-			// Find path for this domain
-			projectPath := ""
-			// Check Links
-			linkPath, ok := d.State.Data.Links[strings.TrimSuffix(domain, "."+d.State.Data.TLD)]
-			if ok {
-				projectPath = linkPath
-			} else {
-				// Check Parked Paths (Scan again? Optimization needed for real app)
-				// For now, let's assume if it's in SiteConfigs, it exists.
-				// But we need the PATH to set root/router.
-				// Wait, router.php logic handles path routing dynamically.
-				// But for isolation, we are bypassing the wildcard server block.
-				// So we need to set `root` correctly in the isolated block.
-
-				// Re-scanning parked paths to find where this domain lives
-				name := strings.TrimSuffix(domain, "."+d.State.Data.TLD)
-				for _, p := range d.State.Data.Paths {
-					if _, err := os.Stat(filepath.Join(p, name)); err == nil {
-						projectPath = filepath.Join(p, name)
-						break
-					}
+		projectPath := siteDir(s, strings.TrimSuffix(domain, "."+s.TLD))
+		// Proxy sites registered with `sld proxy` have no project directory
+		if projectPath == "" && !config.Standalone {
+			continue
+		}
+
+		// Sites that only customise serving fall back to the global PHP version
+		phpVersion := config.PHPVersion
+		if phpVersion == "" {
+			phpVersion = s.PHPVersion
+		}
+		socket := nginx.DefaultSocket
+		if phpVersion != "" && config.Proxy == "" {
+			var err error
+			if socket, err = d.Adapter.CheckPHPSocket(phpVersion); err != nil {
+				// Only warn if version is >= 7.4
+				if v, err := strconv.ParseFloat(phpVersion, 64); err != nil || v >= 7.4 {
+					fmt.Printf("Warning: PHP socket for %s not found. Skipping isolation for %s.\n", phpVersion, domain)
 				}
+				continue
 			}
+		}
 
-			if projectPath != "" {
-				socket, err := d.Adapter.CheckPHPSocket(config.PHPVersion)
-				if err == nil {
-					// Use WebRoot override if present
-					webRoot := projectPath
-					if config.WebRoot != "" {
-						webRoot = filepath.Join(projectPath, config.WebRoot)
-					}
+		// Secured sites use their own certificate; secure mode falls back to the shared one
+		var tls *nginx.TLS
+		if config.Secure || s.Secure {
+			tls = d.siteTLS(domain)
+			if tls == nil && !s.Secure {
+				fmt.Printf("Warning: no certificate for %s yet. Serving it over HTTP.\n", domain)
+			}
+		}
 
-					// Basic Server Block Template for Isolation
-					// We add support for Cloudflare Tunnel headers (X-Forwarded-Host, X-Forwarded-Proto)
-					// to ensure Laravel/PHP generates correct public URLs and handles SSL correctly behind the tunnel.
-
-					proxyLogic := `
-    # Proxy Header Support for Cloudflare Tunnels
-    set $proxy_host $host;
-    if ($http_x_forwarded_host) {
-        set $proxy_host $http_x_forwarded_host;
-    }
-    
-    set $proxy_https $https;
-    if ($http_x_forwarded_proto = "https") {
-        set $proxy_https "on";
-    }
-`
-
-					var block string
-					if d.State.Data.Secure {
-						block = fmt.Sprintf(`
-server {
-    listen %s;
-    listen [::]:%s;
-    server_name %s;
-    return 301 https://$host$request_uri;
-}
-`, port, port, domain)
-					} else {
-						block = fmt.Sprintf(`
-server {
-    listen %s;
-    listen [::]:%s;
-    server_name %s;
-    root "%s";
-    
-    index index.html index.htm index.php;
-
-    %s
-
-    location / {
-        try_files $uri $uri/ /index.php?$query_string;
-    }
-
-    location ~ \.php$ {
-        fastcgi_pass unix:%s;
-        fastcgi_index index.php;
-        fastcgi_param SCRIPT_FILENAME $realpath_root$fastcgi_script_name;
-        include fastcgi_params;
-        
-        # Override Host/Proto for Tunnel
-        fastcgi_param HTTP_HOST $proxy_host;
-        fastcgi_param SERVER_NAME $proxy_host;
-        fastcgi_param HTTPS $proxy_https;
-
-        fastcgi_param PHP_VALUE "error_reporting=E_ALL & ~E_DEPRECATED";
-        fastcgi_buffers 16 32k;
-        fastcgi_buffer_size 64k;
-        fastcgi_busy_buffers_size 64k;
-    }
-}
-`, port, port, domain, webRoot, proxyLogic, socket)
-					}
+		if config.Proxy == "" && phpVersion != "" {
+			versions[len(sites)] = phpVersion
+		}
+		sites = append(sites, nginx.Site{
+			Domain:            domain,
+			ServerNames:       serverNames(s.TLD, domain, config),
+			Path:              projectPath,
+			WebRoot:           config.WebRoot,
+			Driver:            config.Driver,
+			Index:             config.Index,
+			Socket:            socket,
+			ClientMaxBodySize: config.ClientMaxBodySize,
+			Nginx:             config.Nginx,
+			Env:               config.Env,
+			Proxy:             config.Proxy,
+			TLS:               tls,
+		})
+	}
 
-					// If secure, add SSL block too
-					if d.State.Data.Secure {
-						// We assume certs are at /var/lib/sld/certs/dev.pem
-						certPath := "/var/lib/sld/certs/dev.pem"
-						keyPath := "/var/lib/sld/certs/dev-key.pem"
-
-						block += fmt.Sprintf(`
-server {
-    listen 443 ssl http2;
-    listen [::]:443 ssl http2;
-    server_name %s;
-    root "%s";
-    
-    ssl_certificate %s;
-    ssl_certificate_key %s;
-
-    index index.html index.htm index.php;
-
-    %s
-
-    location / {
-        try_files $uri $uri/ /index.php?$query_string;
-    }
-
-    location ~ \.php$ {
-        fastcgi_pass unix:%s;
-        fastcgi_index index.php;
-        fastcgi_param SCRIPT_FILENAME $realpath_root$fastcgi_script_name;
-        include fastcgi_params;
-        
-        # Override Host/Proto for Tunnel
-        fastcgi_param HTTP_HOST $proxy_host;
-        fastcgi_param SERVER_NAME $proxy_host;
-        fastcgi_param HTTPS $proxy_https;  # Prioritize proxy logic, fallback to explicit HTTPS on
-
-        fastcgi_buffers 16 32k;
-        fastcgi_buffer_size 64k;
-        fastcgi_busy_buffers_size 64k;
-    }
+	return sites, versions
 }
-`, domain, webRoot, certPath, keyPath, proxyLogic, socket)
-					}
 
-					isolationBlocks += block
-				} else {
-					// Only warn if version is >= 7.4
-					shouldWarn := true
-					if v, err := strconv.ParseFloat(config.PHPVersion, 64); err == nil {
-						if v < 7.4 {
-							shouldWarn = false
-						}
-					}
-					if shouldWarn {
-						fmt.Printf("Warning: PHP socket for %s not found. Skipping isolation for %s.\n", config.PHPVersion, domain)
-					}
-				}
-			}
+// pluginNginxBlocks collects the config blocks of enabled plugins
+func (d *Daemon) pluginNginxBlocks() []nginx.PluginBlock {
+	if d.PluginManager == nil {
+		return nil
+	}
+	var blocks []nginx.PluginBlock
+	for _, p := range d.PluginManager.GetAll() {
+		if !d.State.IsPluginEnabled(p.ID()) {
+			continue
+		}
+		hook, ok := p.(plugins.NginxHook)
+		if !ok {
+			continue
+		}
+		configs, err := hook.NginxConfig()
+		if err != nil {
+			continue
+		}
+		for name, cfg := range configs {
+			blocks = append(blocks, nginx.PluginBlock{Plugin: p.Name(), Name: name, Config: cfg})
 		}
 	}
+	return blocks
+}
 
-	// 4. Collect Plugin Configs
-	pluginBlocks := ""
-	if d.PluginManager != nil {
-		for _, p := range d.PluginManager.GetAll() {
-			if d.State.IsPluginEnabled(p.ID()) {
-				if hook, ok := p.(plugins.NginxHook); ok {
-					configs, err := hook.NginxConfig()
-					if err == nil {
-						for name, cfg := range configs {
-							pluginBlocks += fmt.Sprintf("\n# --- Plugin: %s (%s) ---\n%s\n", p.Name(), name, cfg)
-						}
-					}
-				}
-			}
+// serverNames returns the primary domain (and *.domain for wildcard sites)
+// followed by the site's aliases. Aliases without the TLD get it appended (api.app -> api.app.test).
+func serverNames(tld, domain string, config state.SiteConfig) []string {
+	names := []string{domain}
+	if config.Wildcard {
+		names = append(names, "*."+domain)
+	}
+	for _, alias := range config.Aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" {
+			continue
 		}
+		if !strings.HasSuffix(alias, "."+tld) {
+			alias += "." + tld
+		}
+		names = append(names, alias)
 	}
-
-	// Append isolation blocks to config
-	finalConfig := baseConfig + "\n# --- Plugin Blocks ---\n" + pluginBlocks + "\n# --- Isolated Sites ---\n" + isolationBlocks
-
-	return d.Adapter.WriteNginxConfig(finalConfig)
+	return names
 }
 
 func getRealUserHome() string {
@@ -516,7 +465,7 @@ func getRealUserHome() string {
 // ensureProjectPHPVersions installs any PHP versions required by projects but not yet installed
 func (d *Daemon) ensureProjectPHPVersions() {
 	versions := make(map[string]bool)
-	for _, config := range d.State.Data.SiteConfigs {
+	for _, config := range d.State.Snapshot().SiteConfigs {
 		if config.PHPVersion != "" {
 			versions[config.PHPVersion] = true
 		}
@@ -541,83 +490,57 @@ func (d *Daemon) ensureProjectPHPVersions() {
 
 // ensureProjectNodeVersions installs Node.js versions required by projects
 func (d *Daemon) ensureProjectNodeVersions() {
-	// Scan all projects to find node requirements
-	// For simplicity, we iterate known sites. Ideally, we scan all paths.
-	// But `SiteConfigs` might be empty initially.
-	// Let's rely on parked paths.
-	for _, path := range d.State.Data.Paths {
-		version, err := d.ProjectManager.ScanNodeRequirement(path)
-		if err != nil {
-			fmt.Printf("Warning: Failed to scan node version for %s: %v\n", path, err)
+	sites, err := d.GetSites()
+	if err != nil {
+		return
+	}
+
+	installed := map[string]bool{}
+	for _, site := range sites {
+		if site.Path == "" {
+			continue // Proxy sites
+		}
+		req := project.DetectNode(site.Path)
+		if req.Constraint == "" {
 			continue
 		}
 
-		if version != "" {
-			// Clean version string (e.g. ">=18.0.0" -> "18", "v20" -> "20")
-			// This is a naive cleaner. fnm handles some semver, but let's be safe.
-			// If it contains specific version, we try to use it.
-			// For now, let's assume valid semver or simple version.
-			// fnm supports "18", "20", "lts", etc.
-			// We remove >=, ^, ~ chars for better matching if simple
-			cleanVer := strings.TrimLeft(version, ">=^~v")
-			cleanVer = strings.Split(cleanVer, " ")[0] // Take first part if range
-
-			fmt.Printf("Project at %s requires Node %s (clean: %s). Ensuring installed...\n", path, version, cleanVer)
-			if err := d.Adapter.InstallNode(cleanVer); err != nil {
-				fmt.Printf("Warning: Failed to install Node %s: %v\n", cleanVer, err)
-			}
+		res := d.ResolveNode(req)
+		if res.Chosen != "" && !req.IsAlias() {
+			continue // Already installed
 		}
-	}
-}
-
-// Project Management
 
-// HTTPS
+		target := res.Chosen
+		if target == "" {
+			target = nodeInstallTarget(req.Constraint)
+		}
+		if target == "" || installed[target] {
+			continue
+		}
+		installed[target] = true
 
-func (d *Daemon) regenerateCerts() error {
-	fmt.Println("Regenerating certificates...")
-
-	// Collect domains from state
-	domains := []string{"sld.test", "*.test"} // Explicitly add system domains
-	// Linked sites
-	for name := range d.State.Data.Links {
-		domains = append(domains, name+".test")
-	}
-	// Parked sites (scan directories)
-	for _, p := range d.State.Data.Paths {
-		entries, err := os.ReadDir(p)
-		if err == nil {
-			for _, entry := range entries {
-				if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
-					domains = append(domains, entry.Name()+".test")
-				}
-			}
+		fmt.Printf("%s requires Node %s (from %s). Ensuring %s is installed...\n", site.Domain, req.Constraint, req.Source, target)
+		if err := d.Adapter.InstallNode(target); err != nil {
+			fmt.Printf("Warning: Failed to install Node %s: %v\n", target, err)
 		}
 	}
+}
 
-	if err := d.Adapter.GenerateCert("", domains); err != nil {
-		return fmt.Errorf("failed to generate certs: %w", err)
-	}
+// Project Management
 
-	return d.refreshNginxConfig()
-}
+// HTTPS
 
 func (d *Daemon) Secure() error {
-	fmt.Println("Installing mkcert...")
-	if err := d.Adapter.InstallMkcert(); err != nil {
-		return fmt.Errorf("failed to install mkcert: %w", err)
+	if _, err := d.TrustCA(); err != nil {
+		return err
 	}
 
-	d.State.Data.Secure = true
-	d.State.Save()
-
-	if err := d.regenerateCerts(); err != nil {
-		return err
+	if err := d.State.SetSecure(true); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
 	}
 
-	// Trust certificates in Snap browsers etc
-	if err := d.Adapter.InstallCertificates(); err != nil {
-		fmt.Printf("Warning: Failed to install certificates to browsers: %v\n", err)
+	if err := d.reissueCerts(); err != nil {
+		return err
 	}
 
 	fmt.Println("HTTPS Enabled! 🔒")
@@ -627,15 +550,16 @@ func (d *Daemon) Secure() error {
 func (d *Daemon) Unsecure() error {
 	fmt.Println("Disabling HTTPS...")
 
-	d.State.Data.Secure = false
-	d.State.Save()
+	if err := d.State.SetSecure(false); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
 
 	fmt.Println("Updating Nginx configuration...")
 	if err := d.refreshNginxConfig(); err != nil {
 		return err
 	}
 
-	// We don't uninstall mkcert, just switch config.
+	// The CA stays trusted, so `sld secure` is instant next time
 	fmt.Println("HTTPS Disabled. Switched back to HTTP. 🔓")
 	return nil
 }
@@ -643,11 +567,14 @@ func (d *Daemon) Unsecure() error {
 // Project Management
 
 func (d *Daemon) scanPath(path string) error {
+	s := d.State.Snapshot()
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return err
 	}
-	d.State.AddPath(absPath)
+	if err := d.State.AddPath(absPath); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
 
 	entries, err := os.ReadDir(absPath)
 	if err == nil {
@@ -655,16 +582,12 @@ func (d *Daemon) scanPath(path string) error {
 			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
 				subPath := filepath.Join(absPath, entry.Name())
 				// Detect config
-				if conf, err := project.Detect(subPath); err == nil && (conf.PHP != "" || conf.Public != "") {
-					domain := fmt.Sprintf("%s.%s", entry.Name(), d.State.Data.TLD)
-					resolvedPHP := d.resolvePHPVersion(conf.PHP)
-					d.State.SetSiteConfig(domain, state.SiteConfig{
-						PHPVersion:  resolvedPHP,
-						WebRoot:     conf.Public,
-						NodeVersion: conf.Node,
-					})
+				if conf, err := project.Detect(subPath); err == nil && !conf.IsEmpty() {
+					domain := fmt.Sprintf("%s.%s", entry.Name(), s.TLD)
+					resolvedPHP := d.resolvePHPVersion(conf.PHPRequirement())
+					d.applyProjectConfig(domain, conf, resolvedPHP)
 					if resolvedPHP != "" {
-						fmt.Printf("Detected config for %s: PHP %s (from %s)\n", domain, resolvedPHP, conf.PHP)
+						fmt.Printf("Detected config for %s: PHP %s (%s in %s)\n", domain, resolvedPHP, conf.PHP, conf.PHPSource)
 					} else {
 						fmt.Printf("Detected config for %s: Using default PHP (satisfied %s)\n", domain, conf.PHP)
 					}
@@ -675,9 +598,50 @@ func (d *Daemon) scanPath(path string) error {
 	return nil
 }
 
-func (d *Daemon) Park(path string) error {
+// applyProjectConfig stores what a project declares about itself and starts
+// the plugins it needs. Dashboard tags and categories are kept.
+func (d *Daemon) applyProjectConfig(domain string, conf *project.Config, phpVersion string) {
+	existing := d.State.Snapshot().SiteConfigs[domain]
+	d.State.SetSiteConfig(domain, state.SiteConfig{
+		PHPVersion:        phpVersion,
+		WebRoot:           conf.Public,
+		NodeVersion:       conf.Node,
+		Driver:            conf.Driver,
+		Tags:              existing.Tags,
+		Category:          existing.Category,
+		Supervise:         existing.Supervise,
+		Secure:            existing.Secure,
+		Aliases:           conf.Aliases,
+		Wildcard:          conf.Wildcard,
+		Plugins:           conf.Plugins,
+		Env:               conf.Env,
+		Nginx:             conf.Nginx,
+		ClientMaxBodySize: conf.ClientMaxBodySize,
+		Index:             conf.Index,
+		Processes:         conf.Processes,
+		Proxy:             conf.Proxy,
+		FPM:               conf.FPM,
+	})
+
+	if len(conf.Plugins) > 0 && d.PluginManager != nil {
+		if err := d.PluginManager.EnsureRequired(conf.Plugins); err != nil {
+			fmt.Printf("Warning: %s: %v\n", domain, err)
+		}
+	}
+}
+
+// Park serves every project in path and runs the post-link hooks of the
+// sites it added
+func (d *Daemon) Park(path string) ([]services.HookRun, error) {
+	before := map[string]bool{}
+	if sites, err := d.GetSites(); err == nil {
+		for _, s := range sites {
+			before[s.Name] = true
+		}
+	}
+
 	if err := d.scanPath(path); err != nil {
-		return err
+		return nil, err
 	}
 
 	d.Events.Publish(events.Event{Type: events.SitesUpdated})
@@ -686,10 +650,18 @@ func (d *Daemon) Park(path string) error {
 		fmt.Printf("Warning: Failed to sync hosts: %v\n", err)
 	}
 
-	if d.State.Data.Secure {
-		return d.regenerateCerts()
+	if err := d.ensureCerts(); err != nil {
+		return nil, err
+	}
+
+	var runs []services.HookRun
+	sites, _ := d.GetSites()
+	for _, s := range sites {
+		if !before[s.Name] {
+			runs = append(runs, d.runHooks(services.HookPostLink, s.Name, s.Path)...)
+		}
 	}
-	return d.refreshNginxConfig()
+	return runs, nil
 }
 
 func (d *Daemon) Forget(path string) error {
@@ -697,7 +669,9 @@ func (d *Daemon) Forget(path string) error {
 	if err != nil {
 		return err
 	}
-	d.State.RemovePath(absPath)
+	if err := d.State.RemovePath(absPath); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
 
 	d.Events.Publish(events.Event{Type: events.SitesUpdated})
 
@@ -705,64 +679,68 @@ func (d *Daemon) Forget(path string) error {
 		fmt.Printf("Warning: Failed to sync hosts: %v\n", err)
 	}
 
-	if d.State.Data.Secure {
-		return d.regenerateCerts()
-	}
-	return d.refreshNginxConfig()
+	return d.ensureCerts()
 }
 
 func (d *Daemon) linkInternal(name, path string) error {
+	s := d.State.Snapshot()
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return err
 	}
-	d.State.AddLink(name, absPath)
+	if err := d.State.AddLink(name, absPath); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
 
 	// Detect config
-	if conf, err := project.Detect(absPath); err == nil && (conf.PHP != "" || conf.Public != "") {
-		domain := fmt.Sprintf("%s.%s", name, d.State.Data.TLD)
-		resolvedPHP := d.resolvePHPVersion(conf.PHP)
-		d.State.SetSiteConfig(domain, state.SiteConfig{
-			PHPVersion:  resolvedPHP,
-			WebRoot:     conf.Public,
-			NodeVersion: conf.Node,
-		})
+	if conf, err := project.Detect(absPath); err == nil && !conf.IsEmpty() {
+		domain := fmt.Sprintf("%s.%s", name, s.TLD)
+		resolvedPHP := d.resolvePHPVersion(conf.PHPRequirement())
+		d.applyProjectConfig(domain, conf, resolvedPHP)
 		if resolvedPHP != "" {
-			fmt.Printf("Detected config for %s: PHP %s (from %s)\n", domain, resolvedPHP, conf.PHP)
+			fmt.Printf("Detected config for %s: PHP %s (%s in %s)\n", domain, resolvedPHP, conf.PHP, conf.PHPSource)
 		}
 	}
 	return nil
 }
 
-func (d *Daemon) Link(name, path string) error {
+// Link serves path as name and then runs its post-link hooks
+func (d *Daemon) Link(name, path string) ([]services.HookRun, error) {
 	if err := d.linkInternal(name, path); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := d.syncHosts(); err != nil {
 		fmt.Printf("Warning: Failed to sync hosts: %v\n", err)
 	}
 
-	if d.State.Data.Secure {
-		if err := d.regenerateCerts(); err != nil {
-			return err
-		}
-		// Reload nginx to pick up the new certificate
-		return d.Adapter.ReloadNginx()
+	d.Events.Publish(events.Event{Type: events.SitesUpdated})
+	if err := d.ensureCerts(); err != nil {
+		return nil, err
 	}
 
-	d.Events.Publish(events.Event{Type: events.SitesUpdated})
-	return d.refreshNginxConfig()
+	// Hooks run once the site is served, so they may talk to it
+	return d.runHooks(services.HookPostLink, name, d.State.Snapshot().Links[name]), nil
 }
 
-func (d *Daemon) Unlink(name string) error {
-	d.State.RemoveLink(name)
+// Unlink runs the site's pre-unlink hooks and stops serving it. Failing
+// hooks are reported but don't block the unlink.
+func (d *Daemon) Unlink(name string) ([]services.HookRun, error) {
+	s := d.State.Snapshot()
+	var runs []services.HookRun
+	if path, ok := s.Links[name]; ok {
+		runs = d.runHooks(services.HookPreUnlink, name, path)
+	}
+
+	if err := d.State.RemoveLink(name); err != nil {
+		return runs, fmt.Errorf("failed to save state: %w", err)
+	}
 	// Remove config if any
-	domain := fmt.Sprintf("%s.%s", name, d.State.Data.TLD)
-	if _, ok := d.State.Data.SiteConfigs[domain]; ok {
-		delete(d.State.Data.SiteConfigs, domain)
-		d.State.Save()
+	domain := fmt.Sprintf("%s.%s", name, s.TLD)
+	if _, ok := s.SiteConfigs[domain]; ok {
+		d.State.RemoveSiteConfig(domain)
 	}
+	d.removeSiteCert(domain)
 
 	d.Events.Publish(events.Event{Type: events.SitesUpdated})
 
@@ -770,48 +748,88 @@ func (d *Daemon) Unlink(name string) error {
 		fmt.Printf("Warning: Failed to sync hosts: %v\n", err)
 	}
 
-	if d.State.Data.Secure {
-		return d.regenerateCerts()
+	return runs, d.ensureCerts()
+}
+
+// siteDir finds the project directory of a site: its link, or a directory
+// of that name in a parked path. It is empty when there is none.
+func siteDir(s *state.State, name string) string {
+	if p, ok := s.Links[name]; ok {
+		return p
+	}
+	for _, p := range s.Paths {
+		if _, err := os.Stat(filepath.Join(p, name)); err == nil {
+			return filepath.Join(p, name)
+		}
+	}
+	return ""
+}
+
+// pruneSiteConfigs drops the configs of projects whose directory is gone,
+// so their domains aren't served or listed anymore
+func (d *Daemon) pruneSiteConfigs() {
+	s := d.State.Snapshot()
+	tld := s.TLD
+	var missing []string
+	for domain, config := range s.SiteConfigs {
+		if config.Standalone || !strings.HasSuffix(domain, "."+tld) {
+			continue
+		}
+		if dir := siteDir(s, strings.TrimSuffix(domain, "."+tld)); dir != "" {
+			if _, err := os.Stat(dir); err == nil {
+				continue
+			}
+		}
+		missing = append(missing, domain)
+	}
+
+	for _, domain := range missing {
+		if err := d.State.RemoveSiteConfig(domain); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			continue
+		}
+		d.removeSiteCert(domain)
+		fmt.Printf("Removed config of missing project %s\n", domain)
 	}
-	return d.refreshNginxConfig()
 }
 
 // Refresh re-scans all projects for configuration changes
 func (d *Daemon) Refresh() error {
+	s := d.State.Snapshot()
 	fmt.Println("Scanning parked paths...")
-	for _, p := range d.State.Data.Paths {
+	for _, p := range s.Paths {
 		d.scanPath(p) // Re-scan internal
 	}
 
 	fmt.Println("Scanning linked sites...")
-	for name, path := range d.State.Data.Links {
+	for name, path := range s.Links {
 		d.linkInternal(name, path) // Re-scan internal
 	}
+	d.pruneSiteConfigs()
 
 	if err := d.syncHosts(); err != nil {
 		fmt.Printf("Warning: Failed to sync hosts: %v\n", err)
 	}
 
-	if d.State.Data.Secure {
-		return d.regenerateCerts()
+	if err := d.SyncPluginPHP(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
 	}
-	return d.refreshNginxConfig()
+
+	return d.ensureCerts()
 }
 
-// GetSites returns a list of all available sites (parked + linked)
+// GetSites returns a list of all available sites (parked, linked and proxy)
 func (d *Daemon) GetSites() ([]Site, error) {
-	// Reload state from disk to ensure we have CLI changes
-	d.State.Load()
-
+	s := d.State.Snapshot()
 	sites := []Site{}
-	tld := d.State.Data.TLD
+	tld := s.TLD
 	if tld == "" {
 		tld = "test"
 	}
 
 	// Helper to check if ignored
 	isIgnored := func(path string) bool {
-		for _, ignored := range d.State.Data.Ignored {
+		for _, ignored := range s.Ignored {
 			if ignored == path {
 				return true
 			}
@@ -823,12 +841,12 @@ func (d *Daemon) GetSites() ([]Site, error) {
 	// This prevents projects from appearing twice if they are both
 	// in a parked directory AND explicitly linked
 	linkedPaths := make(map[string]bool)
-	for _, linkPath := range d.State.Data.Links {
+	for _, linkPath := range s.Links {
 		linkedPaths[linkPath] = true
 	}
 
 	// 1. Scan Parked Paths
-	for _, path := range d.State.Data.Paths {
+	for _, path := range s.Paths {
 		entries, err := os.ReadDir(path)
 		if err != nil {
 			// Log error but continue? Or skip
@@ -850,15 +868,17 @@ func (d *Daemon) GetSites() ([]Site, error) {
 
 				// PHP Version override?
 				domain := name + "." + tld
-				phpVer := d.State.Data.PHPVersion
+				phpVer := s.PHPVersion
 				var tags []string
-				var category string
-				if conf, ok := d.State.Data.SiteConfigs[domain]; ok {
+				var category, driver, proxy string
+				if conf, ok := s.SiteConfigs[domain]; ok {
 					if conf.PHPVersion != "" {
 						phpVer = conf.PHPVersion
 					}
 					tags = conf.Tags
 					category = conf.Category
+					driver = conf.Driver
+					proxy = conf.Proxy
 				}
 
 				sites = append(sites, Site{
@@ -866,17 +886,20 @@ func (d *Daemon) GetSites() ([]Site, error) {
 					Path:       fullPath,
 					Domain:     domain,
 					PHPVersion: phpVer,
-					Secure:     d.State.Data.Secure,
+					Secure:     s.Secure || s.SiteConfigs[domain].Secure,
 					Type:       "parked",
 					Tags:       tags,
 					Category:   category,
+					Driver:     driver,
+					Proxy:      proxy,
+					Processes:  d.Supervisor.Status(name),
 				})
 			}
 		}
 	}
 
 	// 2. Add Linked Sites
-	for name, path := range d.State.Data.Links {
+	for name, path := range s.Links {
 		// Verify path exists
 		if _, err := os.Stat(path); os.IsNotExist(err) {
 			continue
@@ -884,15 +907,17 @@ func (d *Daemon) GetSites() ([]Site, error) {
 
 		// PHP Version override?
 		domain := name + "." + tld
-		phpVer := d.State.Data.PHPVersion
+		phpVer := s.PHPVersion
 		var tags []string
-		var category string
-		if conf, ok := d.State.Data.SiteConfigs[domain]; ok {
+		var category, driver, proxy string
+		if conf, ok := s.SiteConfigs[domain]; ok {
 			if conf.PHPVersion != "" {
 				phpVer = conf.PHPVersion
 			}
 			tags = conf.Tags
 			category = conf.Category
+			driver = conf.Driver
+			proxy = conf.Proxy
 		}
 
 		sites = append(sites, Site{
@@ -900,10 +925,38 @@ func (d *Daemon) GetSites() ([]Site, error) {
 			Path:       path,
 			Domain:     domain,
 			PHPVersion: phpVer,
-			Secure:     d.State.Data.Secure,
+			Secure:     s.Secure || s.SiteConfigs[domain].Secure,
 			Type:       "linked",
 			Tags:       tags,
 			Category:   category,
+			Driver:     driver,
+			Proxy:      proxy,
+			Processes:  d.Supervisor.Status(name),
+		})
+	}
+
+	// 3. Add proxy sites registered with `sld proxy`
+	listed := make(map[string]bool, len(sites))
+	for _, site := range sites {
+		listed[site.Domain] = true
+	}
+	var proxied []string
+	for domain, conf := range s.SiteConfigs {
+		if conf.Standalone && !listed[domain] && strings.HasSuffix(domain, "."+tld) {
+			proxied = append(proxied, domain)
+		}
+	}
+	sort.Strings(proxied)
+	for _, domain := range proxied {
+		conf := s.SiteConfigs[domain]
+		sites = append(sites, Site{
+			Name:     strings.TrimSuffix(domain, "."+tld),
+			Domain:   domain,
+			Secure:   s.Secure || conf.Secure,
+			Type:     "proxy",
+			Tags:     conf.Tags,
+			Category: conf.Category,
+			Proxy:    conf.Proxy,
 		})
 	}
 
@@ -911,22 +964,34 @@ func (d *Daemon) GetSites() ([]Site, error) {
 }
 
 func (d *Daemon) Ignore(path string) error {
-	d.State.AddIgnore(path)
+	if err := d.State.AddIgnore(path); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
 	fmt.Printf("Ignored path: %s\n", path)
 	d.Events.Publish(events.Event{Type: events.SitesUpdated})
 	return nil
 }
 
 func (d *Daemon) Unignore(path string) error {
-	d.State.RemoveIgnore(path)
+	if err := d.State.RemoveIgnore(path); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
 	fmt.Printf("Unignored path: %s\n", path)
 	d.Events.Publish(events.Event{Type: events.SitesUpdated})
 	return nil
 }
 
-// Uninstall removes SLD from the system
+// Uninstall removes SLD from the system. The home is only deleted when it
+// is SLD's own directory: it comes from SLD_HOME or the config file, which
+// may point anywhere.
 func (d *Daemon) Uninstall() error {
-	return d.Adapter.Uninstall()
+	home := d.Paths.Home
+	current, _ := os.UserHomeDir()
+	if err := d.Paths.Removable(getRealUserHome(), current); err != nil {
+		fmt.Printf("Keeping %s: %v. Remove SLD's files from it by hand.\n", home, err)
+		home = ""
+	}
+	return d.Adapter.Uninstall(home)
 }
 
 // Service Management
@@ -946,12 +1011,9 @@ func (d *Daemon) Restart() error {
 		fmt.Println("PHP restarted.")
 	}
 
-	// Dnsmasq might be separate, but let's try
-	if err := d.Adapter.RestartService("dnsmasq"); err != nil {
-		// Log but don't fail, dnsmasq might be managed differently on some OS
-		fmt.Printf("Warning: Failed to restart dnsmasq: %v\n", err)
-	} else {
-		fmt.Println("Dnsmasq restarted.")
+	// The DNS server lives in the daemon; make sure the OS still routes to it
+	if err := d.configureResolver(); err != nil {
+		fmt.Printf("Warning: Failed to configure DNS: %v\n", err)
 	}
 
 	return nil
@@ -960,21 +1022,31 @@ func (d *Daemon) Restart() error {
 // Diagnostics
 
 func (d *Daemon) Doctor() error {
-	return d.Adapter.Doctor()
+	if err := d.Adapter.Doctor(); err != nil {
+		return err
+	}
+
+	check := d.dnsHealthCheck()
+	icon := map[string]string{"pass": "🟢", "warn": "🟡", "fail": "🔴"}[check.Status]
+	fmt.Printf("%-18s: %s %s\n", check.Name, icon, check.Message)
+	return nil
 }
 
-// Logs returns map of log names to paths
+// GetLogPaths maps the logs `sld logs` tails to their files: those of
+// SLD's nginx servers in the log directory, and PHP's log where the OS
+// keeps it
 func (d *Daemon) GetLogPaths() map[string]string {
-	// Ideally adapter gives these paths as they vary by OS.
-	// For now, assuming standard Linux/Nginx locations or getting from config.
-	// TODO: move to Adapter.GetLogPaths()
-
-	logs := make(map[string]string)
-	logs["nginx-error"] = "/var/log/nginx/error.log"
-	logs["nginx-access"] = "/var/log/nginx/access.log"
-	// PHP logs vary
-	logs["php-fpm"] = "/var/log/php-fpm.log" // Generic fallback
-
+	logs := map[string]string{
+		"nginx-error":  d.Paths.ErrorLog(),
+		"nginx-access": d.Paths.AccessLog(),
+		"xray":         d.Paths.XRayLog(),
+	}
+	system := d.Adapter.GetLogPaths()
+	if path, ok := system["php_fpm"]; ok {
+		logs["php-fpm"] = path
+	} else if path, ok := system["php_error"]; ok {
+		logs["php-fpm"] = path
+	}
 	return logs
 }
 
@@ -1000,8 +1072,9 @@ func (d *Daemon) SwitchPHP(version string) error {
 	fmt.Printf("Found socket: %s\n", socketPath)
 
 	// 2. Update State
-	d.State.Data.PHPVersion = version
-	d.State.Save()
+	if err := d.State.SetPHPVersion(version); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
 
 	// 3. Update Config
 	if err := d.refreshNginxConfig(); err != nil {
@@ -1022,61 +1095,3 @@ func findBestDevDir(home string) string {
 	}
 	return home // Fallback to home if none found
 }
-func (d *Daemon) resolvePHPVersion(constraint string) string {
-	if constraint == "" {
-		return ""
-	}
-
-	// 1. Extract base version using regex (e.g. 8.1 from ^8.1)
-	re := regexp.MustCompile(`(\d+\.\d+)`)
-	matches := re.FindStringSubmatch(constraint)
-	if len(matches) < 2 {
-		return ""
-	}
-	baseVer := matches[1]
-
-	// 2. Get all installed PHP versions
-	installed, err := d.Adapter.ListPHPVersions()
-	if err != nil {
-		return ""
-	}
-
-	// 3. Find the highest compatible version
-	// installed is already sorted descending (newest first) by the adapter.
-	for _, v := range installed {
-		vNum, _ := strconv.ParseFloat(v, 64)
-		baseNum, _ := strconv.ParseFloat(baseVer, 64)
-
-		isCompatible := false
-		if strings.Contains(constraint, "^") || strings.Contains(constraint, ">=") {
-			// Compatible if same major or if installed is higher (major check avoids 7.x vs 8.x unless >= used)
-			vMajor := int(vNum)
-			baseMajor := int(baseNum)
-
-			if strings.Contains(constraint, ">=") {
-				if vNum >= baseNum {
-					isCompatible = true
-				}
-			} else { // caret ^
-				if vMajor == baseMajor && vNum >= baseNum {
-					isCompatible = true
-				}
-			}
-		} else {
-			// Exact or range fallback
-			if v == baseVer {
-				isCompatible = true
-			}
-		}
-
-		if isCompatible {
-			// Prefer system default if it's the one we found
-			if v == d.State.Data.PHPVersion {
-				return "" // Use system default (implies Nginx base config)
-			}
-			return v
-		}
-	}
-
-	return "" // Fallback to default if no compatibility found
-}
@@ -0,0 +1,146 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/adapters"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/dns"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNSStatus describes the embedded resolver, see `sld dns status`
+type DNSStatus struct {
+	Enabled   bool     `json:"enabled"`
+	Addr      string   `json:"addr,omitempty"`
+	TLD       string   `json:"tld"`
+	Upstreams []string `json:"upstreams"`
+	Listening bool     `json:"listening"` // The server answers for the TLD
+	Resolving bool     `json:"resolving"` // The system resolver sends TLD lookups to it
+	Error     string   `json:"error,omitempty"`
+}
+
+// StartDNS serves the TLD from the daemon and points the system resolver
+// at it. A resolver that can't be configured is only a warning: the server
+// still answers anyone querying it directly.
+func (d *Daemon) StartDNS() error {
+	addr := d.Config.DNSAddr()
+	if addr == "" {
+		return nil
+	}
+
+	server := &dns.Server{TLD: d.tld(), Upstreams: d.dnsUpstreams()}
+	if err := server.ListenAndServe(addr); err != nil {
+		return fmt.Errorf("failed to start DNS server on %s: %w", addr, err)
+	}
+	d.DNS = server
+	fmt.Printf("DNS server listening on %s for *.%s\n", addr, server.TLD)
+
+	if err := d.configureResolver(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	return nil
+}
+
+// StopDNS shuts the embedded resolver down
+func (d *Daemon) StopDNS() {
+	if d.DNS != nil {
+		d.DNS.Close()
+	}
+}
+
+// configureResolver (re)installs the OS integration that routes the TLD
+// to the embedded server
+func (d *Daemon) configureResolver() error {
+	addr := d.Config.DNSAddr()
+	if addr == "" {
+		return nil
+	}
+	return d.Adapter.ConfigureResolver(d.tld(), addr)
+}
+
+// DNSStatus probes the resolver. It works from the CLI too, where the
+// server runs in another process.
+func (d *Daemon) DNSStatus() DNSStatus {
+	status := DNSStatus{TLD: d.tld(), Addr: d.Config.DNSAddr(), Upstreams: []string{}}
+	if status.Addr == "" {
+		status.Error = "disabled (dns_port: 0)"
+		return status
+	}
+	status.Enabled = true
+	if upstreams := d.dnsUpstreams(); upstreams != nil {
+		status.Upstreams = upstreams
+	}
+
+	// A name no site uses, so neither /etc/hosts nor a cache answers for it
+	probe := "sld-dns-probe." + status.TLD
+	if _, err := dns.Lookup(status.Addr, probe, dnsmessage.TypeA, time.Second); err != nil {
+		status.Error = fmt.Sprintf("not answering on %s (is the daemon running?)", status.Addr)
+		return status
+	}
+	status.Listening = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, probe)
+	for _, a := range addrs {
+		if a.IP.IsLoopback() {
+			status.Resolving = true
+		}
+	}
+	if !status.Resolving {
+		status.Error = fmt.Sprintf("the system resolver doesn't route .%s to %s", status.TLD, status.Addr)
+		if err != nil {
+			status.Error += ": " + err.Error()
+		}
+	}
+	return status
+}
+
+// dnsHealthCheck is the Doctor's view of DNSStatus
+func (d *Daemon) dnsHealthCheck() adapters.HealthCheck {
+	s := d.DNSStatus()
+	check := adapters.HealthCheck{Name: "DNS (." + s.TLD + ")", Status: "pass"}
+	switch {
+	case !s.Enabled:
+		check.Status, check.Message = "warn", "Built-in DNS server is disabled"
+	case s.Error != "":
+		check.Status, check.Message = "fail", s.Error
+	default:
+		check.Message = fmt.Sprintf("*.%s resolves to loopback via %s", s.TLD, s.Addr)
+	}
+	return check
+}
+
+// SystemHealth is the adapter's checks plus SLD's own
+func (d *Daemon) SystemHealth() ([]adapters.HealthCheck, error) {
+	checks, err := d.Adapter.GetSystemHealth()
+	if err != nil {
+		return nil, err
+	}
+	return append(checks, d.dnsHealthCheck()), nil
+}
+
+// dnsUpstreams are the servers non-site queries are forwarded to
+func (d *Daemon) dnsUpstreams() []string {
+	if len(d.Config.DNSUpstreams) > 0 {
+		upstreams := make([]string, 0, len(d.Config.DNSUpstreams))
+		for _, u := range d.Config.DNSUpstreams {
+			if _, _, err := net.SplitHostPort(u); err != nil {
+				u = net.JoinHostPort(u, "53")
+			}
+			upstreams = append(upstreams, u)
+		}
+		return upstreams
+	}
+	return dns.SystemUpstreams(d.Config.DNSAddr())
+}
+
+func (d *Daemon) tld() string {
+	if tld := d.State.Snapshot().TLD; tld != "" {
+		return tld
+	}
+	return "test"
+}
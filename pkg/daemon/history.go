@@ -0,0 +1,34 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+)
+
+// History returns the most recent state operations, newest first
+func (d *Daemon) History(limit int) ([]state.JournalEntry, error) {
+	return d.State.History(limit)
+}
+
+// Undo reverts the last n state operations and re-applies nginx, hosts and
+// certificates for the restored state.
+func (d *Daemon) Undo(n int) ([]state.JournalEntry, error) {
+	undone, err := d.State.Undo(n)
+	if err != nil {
+		return nil, err
+	}
+
+	d.PluginManager.StartEnabled()
+	if err := d.SyncPluginPHP(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	d.Events.Publish(events.Event{Type: events.SitesUpdated})
+
+	if err := d.syncHosts(); err != nil {
+		fmt.Printf("Warning: Failed to sync hosts: %v\n", err)
+	}
+
+	return undone, d.ensureCerts()
+}
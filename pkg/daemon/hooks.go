@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/project"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/services"
+)
+
+// runHooks runs the global and .sld.yaml hooks for event in the site's directory
+func (d *Daemon) runHooks(event services.HookEvent, name, path string) []services.HookRun {
+	var commands []string
+	var env map[string]string
+	conf, err := project.Detect(path)
+	if err == nil {
+		commands = conf.Hooks[string(event)]
+		env = conf.Env
+	}
+
+	ctx := services.HookContext{
+		Site:   name,
+		Domain: fmt.Sprintf("%s.%s", name, d.State.Snapshot().TLD),
+		Path:   path,
+	}
+	runs := d.Hooks.Run(event, ctx, commands, env)
+	for _, r := range services.FailedHooks(runs) {
+		fmt.Printf("Warning: %s hook for %s failed: %s (%s)\n", r.Event, name, r.Command, r.Error)
+	}
+	return runs
+}
+
+// RunPostCreateHooks runs the post-create hooks of a newly created project
+func (d *Daemon) RunPostCreateHooks(name, path string) []services.HookRun {
+	return d.runHooks(services.HookPostCreate, name, path)
+}
@@ -0,0 +1,305 @@
+package daemon
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/ca"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/services"
+)
+
+const (
+	// renewBefore is how long before expiry leaf certificates are issued again
+	renewBefore = 30 * 24 * time.Hour
+	// caWarnBefore is how long before the root expires the healer warns. The
+	// root isn't renewed automatically: a new one has to be trusted again.
+	caWarnBefore = 90 * 24 * time.Hour
+
+	certCheckInterval = 12 * time.Hour
+	// certCheckDelay lets a burst of site changes (and the certificates they
+	// issue) settle before checking
+	certCheckDelay = 5 * time.Second
+)
+
+// Certificate states, see CertInfo.Status
+const (
+	CertOK       = "ok"
+	CertExpiring = "expiring"
+	CertExpired  = "expired"
+	CertMismatch = "mismatch" // Doesn't cover every name it is served for
+	CertForeign  = "foreign"  // Not issued by the SLD CA, e.g. left over from mkcert
+	CertInvalid  = "invalid"  // Missing or unreadable
+)
+
+// CertInfo describes one certificate SLD manages, see `sld certs`
+type CertInfo struct {
+	Kind      string    `json:"kind"` // ca, shared or site
+	Domain    string    `json:"domain,omitempty"`
+	Path      string    `json:"path"`
+	InUse     bool      `json:"in_use"` // Served by nginx; the CA always is
+	Subject   string    `json:"subject,omitempty"`
+	Issuer    string    `json:"issuer,omitempty"`
+	SANs      []string  `json:"sans"`
+	NotBefore time.Time `json:"not_before,omitzero"`
+	NotAfter  time.Time `json:"not_after,omitzero"`
+	DaysLeft  int       `json:"days_left"`
+	Status    string    `json:"status"`
+	Missing   []string  `json:"missing,omitempty"` // Served names the certificate doesn't cover
+	Error     string    `json:"error,omitempty"`
+}
+
+// Certificates parses every certificate SLD manages: the root CA, the
+// shared certificate of secure mode and each site's own certificate
+func (d *Daemon) Certificates() ([]CertInfo, error) {
+	s := d.State.Snapshot()
+	auth, err := ca.Load(d.Paths.CA)
+	if errors.Is(err, os.ErrNotExist) && !s.Secure && len(s.Certificates) == 0 {
+		return []CertInfo{}, nil // Nothing issued yet; the CA is created when HTTPS is first used
+	}
+	if err != nil {
+		if auth, err = d.authority(); err != nil {
+			return nil, err
+		}
+	}
+	sites, err := d.GetSites()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	root := CertInfo{Kind: "ca", Path: auth.CertFile(), InUse: true}
+	describeCert(&root, auth.Cert, now)
+	root.Status = CertOK
+	if now.After(auth.Cert.NotAfter) {
+		root.Status = CertExpired
+	} else if auth.Cert.NotAfter.Sub(now) < caWarnBefore {
+		root.Status = CertExpiring
+	}
+	certs := []CertInfo{root}
+
+	shared, _ := d.sharedCertPaths()
+	if _, err := os.Stat(shared); err == nil || s.Secure {
+		// Sites without a server block of their own are served with it
+		names := sharedCertDomains(s.TLD)
+		for _, site := range sites {
+			if !s.SiteConfigs[site.Domain].NeedsServerBlock() {
+				names = append(names, site.Domain)
+			}
+		}
+		info := CertInfo{Kind: "shared", Path: shared, InUse: s.Secure}
+		inspectCert(auth, &info, names, now)
+		certs = append(certs, info)
+	}
+
+	for _, domain := range s.Certificates {
+		config := s.SiteConfigs[domain]
+		path, _ := d.siteCertPaths(domain)
+		info := CertInfo{
+			Kind:   "site",
+			Domain: domain,
+			Path:   path,
+			InUse:  config.Secure || (s.Secure && config.NeedsServerBlock()),
+		}
+		inspectCert(auth, &info, serverNames(s.TLD, domain, config), now)
+		certs = append(certs, info)
+	}
+	return certs, nil
+}
+
+// inspectCert fills info from the certificate at info.Path, which should
+// cover names
+func inspectCert(auth *ca.Authority, info *CertInfo, names []string, now time.Time) {
+	cert, err := ca.ReadCert(info.Path)
+	if err != nil {
+		info.SANs = []string{}
+		info.Status, info.Error = CertInvalid, err.Error()
+		return
+	}
+	describeCert(info, cert, now)
+	info.Missing = ca.Uncovered(cert, names)
+
+	switch {
+	case now.After(cert.NotAfter):
+		info.Status = CertExpired
+	case !auth.Signed(cert):
+		info.Status = CertForeign
+	case len(info.Missing) > 0:
+		info.Status = CertMismatch
+	case cert.NotAfter.Sub(now) < renewBefore:
+		info.Status = CertExpiring
+	default:
+		info.Status = CertOK
+	}
+}
+
+func describeCert(info *CertInfo, cert *x509.Certificate, now time.Time) {
+	info.Subject = cert.Subject.CommonName
+	info.Issuer = cert.Issuer.CommonName
+	info.SANs = ca.Names(cert)
+	info.NotBefore = cert.NotBefore
+	info.NotAfter = cert.NotAfter
+	info.DaysLeft = int(cert.NotAfter.Sub(now).Hours() / 24)
+}
+
+// StartCertRenewal checks certificates now, every certCheckInterval and
+// after site changes. Leaf certificates close to expiry are issued again
+// and nginx reloaded; other problems become healer issues. Only the
+// long-running daemon calls it.
+func (d *Daemon) StartCertRenewal() {
+	d.HealerService.RegisterFix("reissue_cert", func(domain string) error {
+		if err := d.reissueCert(domain); err != nil {
+			return err
+		}
+		return d.refreshNginxConfig()
+	})
+
+	d.Events.Subscribe(events.SitesUpdated, func(events.Event) {
+		d.certMu.Lock()
+		defer d.certMu.Unlock()
+		if d.certTimer != nil {
+			d.certTimer.Stop()
+		}
+		d.certTimer = time.AfterFunc(certCheckDelay, d.checkCerts)
+	})
+
+	go func() {
+		for {
+			d.checkCerts()
+			time.Sleep(certCheckInterval)
+		}
+	}()
+}
+
+// checkCerts renews expiring certificates and keeps the healer's
+// certificate issues in line with the inventory
+func (d *Daemon) checkCerts() {
+	d.certCheckMu.Lock()
+	defer d.certCheckMu.Unlock()
+
+	certs, err := d.Certificates()
+	if err != nil {
+		fmt.Printf("Warning: certificate check failed: %v\n", err)
+		return
+	}
+
+	renewed := 0
+	raised := map[string]bool{}
+	for _, c := range certs {
+		if !c.InUse {
+			continue
+		}
+		if c.Kind != "ca" && (c.Status == CertExpiring || c.Status == CertExpired) {
+			if err := d.reissueCert(c.Domain); err != nil {
+				c.Error = fmt.Sprintf("renewal failed: %v", err)
+			} else {
+				fmt.Printf("Renewed the certificate for %s (was valid until %s)\n", certName(c), c.NotAfter.Format("2006-01-02"))
+				renewed++
+				continue
+			}
+		}
+		if issue, ok := certIssue(c, d.tld()); ok {
+			raised[issue.ID] = true
+			d.HealerService.ReportIssue(issue)
+		}
+	}
+
+	for id := range d.certIssues {
+		if !raised[id] {
+			d.HealerService.ClearIssue(id)
+		}
+	}
+	d.certIssues = raised
+
+	if renewed > 0 {
+		if err := d.refreshNginxConfig(); err != nil {
+			fmt.Printf("Warning: failed to reload nginx after renewing certificates: %v\n", err)
+		}
+	}
+}
+
+// reissueCert issues the certificate of a site again, or the shared
+// certificate when domain is empty
+func (d *Daemon) reissueCert(domain string) error {
+	s := d.State.Snapshot()
+	auth, err := d.authority()
+	if err != nil {
+		return err
+	}
+	if domain == "" {
+		cert, key := d.sharedCertPaths()
+		return auth.IssueFiles(cert, key, sharedCertDomains(s.TLD))
+	}
+	return d.issueSiteCert(auth, domain, serverNames(s.TLD, domain, s.SiteConfigs[domain]))
+}
+
+// certIssue is the healer issue for a certificate in a bad state
+func certIssue(c CertInfo, tld string) (services.HealerIssue, bool) {
+	name := certName(c)
+	issue := services.HealerIssue{
+		ID:         fmt.Sprintf("cert-%s-%s", c.Status, c.Kind),
+		Severity:   services.SeverityWarning,
+		Source:     services.LogSourceCerts,
+		FixAction:  "reissue_cert:" + c.Domain,
+		CanAutoFix: true,
+	}
+	if c.Domain != "" {
+		issue.ID += "-" + c.Domain
+	}
+
+	if c.Kind == "ca" {
+		if c.Status != CertExpiring && c.Status != CertExpired {
+			return issue, false
+		}
+		issue.Title = "SLD Certificate Authority Expiring"
+		issue.Description = fmt.Sprintf("The SLD root CA expires on %s, after which no site certificate is trusted. Remove %s and run `sld secure` to create and trust a new one.",
+			c.NotAfter.Format("2006-01-02"), filepath.Dir(c.Path))
+		issue.FixAction, issue.CanAutoFix = "", false
+		if c.Status == CertExpired {
+			issue.Title = "SLD Certificate Authority Expired"
+			issue.Severity = services.SeverityCritical
+		}
+		return issue, true
+	}
+
+	switch c.Status {
+	case CertExpiring, CertExpired:
+		issue.Title = "Certificate Expiring: " + name
+		if c.Status == CertExpired {
+			issue.Title = "Certificate Expired: " + name
+			issue.Severity = services.SeverityCritical
+		}
+		issue.Description = fmt.Sprintf("The certificate for %s is valid until %s and could not be renewed: %s.", name, c.NotAfter.Format("2006-01-02"), c.Error)
+	case CertMismatch:
+		issue.Title = "Certificate Doesn't Cover " + name
+		issue.Description = fmt.Sprintf("The certificate for %s is missing %v, so browsers reject those names. Issue it again to include them.", name, c.Missing)
+	case CertForeign:
+		issue.Title = "Certificate Not Issued by SLD: " + name
+		issue.Description = fmt.Sprintf("The certificate for %s was signed by %q, which SLD doesn't manage (e.g. mkcert). Issue it again from the SLD CA.", name, c.Issuer)
+	case CertInvalid:
+		issue.Title = "Certificate Missing: " + name
+		issue.Description = fmt.Sprintf("%s is served over HTTPS but its certificate can't be read: %s.", name, c.Error)
+		issue.Severity = services.SeverityCritical
+	default:
+		return issue, false
+	}
+	if c.Kind == "shared" {
+		issue.Description += fmt.Sprintf(" It serves every *.%s site in secure mode.", tld)
+	}
+	return issue, true
+}
+
+// certName is how a certificate is referred to in messages
+func certName(c CertInfo) string {
+	switch c.Kind {
+	case "ca":
+		return "the SLD CA"
+	case "shared":
+		return "the shared certificate"
+	}
+	return c.Domain
+}
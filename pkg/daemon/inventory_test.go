@@ -0,0 +1,181 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/ca"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/services"
+)
+
+func TestInspectCert(t *testing.T) {
+	auth, _, err := ca.LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, _, err := ca.LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	own := filepath.Join(dir, "acme.test.pem")
+	if err := auth.IssueFiles(own, filepath.Join(dir, "acme.test-key.pem"), []string{"acme.test", "*.acme.test"}); err != nil {
+		t.Fatal(err)
+	}
+	mkcert := filepath.Join(dir, "mkcert.pem")
+	if err := foreign.IssueFiles(mkcert, filepath.Join(dir, "mkcert-key.pem"), []string{"acme.test"}); err != nil {
+		t.Fatal(err)
+	}
+	garbage := filepath.Join(dir, "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := ca.ReadCert(own)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	tests := []struct {
+		name    string
+		path    string
+		names   []string
+		now     time.Time
+		status  string
+		missing []string
+	}{
+		{name: "ok", path: own, names: []string{"acme.test", "api.acme.test"}, now: now, status: CertOK},
+		{name: "expiring", path: own, names: []string{"acme.test"}, now: leaf.NotAfter.Add(-renewBefore / 2), status: CertExpiring},
+		{name: "expired", path: own, names: []string{"acme.test"}, now: leaf.NotAfter.Add(time.Hour), status: CertExpired},
+		{name: "mismatch", path: own, names: []string{"acme.test", "admin.test"}, now: now, status: CertMismatch, missing: []string{"admin.test"}},
+		{name: "foreign", path: mkcert, names: []string{"acme.test"}, now: now, status: CertForeign},
+		{name: "expired beats foreign", path: mkcert, names: []string{"acme.test"}, now: leaf.NotAfter.Add(24 * time.Hour), status: CertExpired},
+		{name: "missing file", path: filepath.Join(dir, "none.pem"), names: []string{"acme.test"}, now: now, status: CertInvalid},
+		{name: "not a certificate", path: garbage, names: []string{"acme.test"}, now: now, status: CertInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := CertInfo{Kind: "site", Domain: "acme.test", Path: tt.path}
+			inspectCert(auth, &info, tt.names, tt.now)
+			if info.Status != tt.status {
+				t.Errorf("Status = %s, want %s (error %q)", info.Status, tt.status, info.Error)
+			}
+			if strings.Join(info.Missing, ",") != strings.Join(tt.missing, ",") {
+				t.Errorf("Missing = %v, want %v", info.Missing, tt.missing)
+			}
+			if tt.status == CertInvalid && (info.Error == "" || info.SANs == nil) {
+				t.Errorf("invalid certificate without an error or SANs: %+v", info)
+			}
+		})
+	}
+}
+
+func TestCertIssue(t *testing.T) {
+	notAfter := time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		cert     CertInfo
+		raised   bool
+		id       string
+		title    string
+		severity services.IssueSeverity
+		fix      string
+	}{
+		{
+			name:   "site ok",
+			cert:   CertInfo{Kind: "site", Domain: "acme.test", Status: CertOK},
+			raised: false,
+		},
+		{
+			name:     "site expired",
+			cert:     CertInfo{Kind: "site", Domain: "acme.test", Status: CertExpired, NotAfter: notAfter, Error: "renewal failed"},
+			raised:   true,
+			id:       "cert-expired-site-acme.test",
+			title:    "Certificate Expired: acme.test",
+			severity: services.SeverityCritical,
+			fix:      "reissue_cert:acme.test",
+		},
+		{
+			name:     "site expiring",
+			cert:     CertInfo{Kind: "site", Domain: "acme.test", Status: CertExpiring, NotAfter: notAfter},
+			raised:   true,
+			id:       "cert-expiring-site-acme.test",
+			title:    "Certificate Expiring: acme.test",
+			severity: services.SeverityWarning,
+			fix:      "reissue_cert:acme.test",
+		},
+		{
+			name:     "site mismatch",
+			cert:     CertInfo{Kind: "site", Domain: "acme.test", Status: CertMismatch, Missing: []string{"admin.test"}},
+			raised:   true,
+			id:       "cert-mismatch-site-acme.test",
+			title:    "Certificate Doesn't Cover acme.test",
+			severity: services.SeverityWarning,
+			fix:      "reissue_cert:acme.test",
+		},
+		{
+			name:     "shared foreign",
+			cert:     CertInfo{Kind: "shared", Status: CertForeign, Issuer: "mkcert development CA"},
+			raised:   true,
+			id:       "cert-foreign-shared",
+			title:    "Certificate Not Issued by SLD: the shared certificate",
+			severity: services.SeverityWarning,
+			fix:      "reissue_cert:",
+		},
+		{
+			name:     "shared invalid",
+			cert:     CertInfo{Kind: "shared", Status: CertInvalid, Error: "no such file"},
+			raised:   true,
+			id:       "cert-invalid-shared",
+			title:    "Certificate Missing: the shared certificate",
+			severity: services.SeverityCritical,
+			fix:      "reissue_cert:",
+		},
+		{
+			name:     "ca expiring",
+			cert:     CertInfo{Kind: "ca", Path: "/var/lib/sld/ca/rootCA.pem", Status: CertExpiring, NotAfter: notAfter},
+			raised:   true,
+			id:       "cert-expiring-ca",
+			title:    "SLD Certificate Authority Expiring",
+			severity: services.SeverityWarning,
+		},
+		{
+			name:     "ca expired",
+			cert:     CertInfo{Kind: "ca", Path: "/var/lib/sld/ca/rootCA.pem", Status: CertExpired, NotAfter: notAfter},
+			raised:   true,
+			id:       "cert-expired-ca",
+			title:    "SLD Certificate Authority Expired",
+			severity: services.SeverityCritical,
+		},
+		{
+			name:   "ca ok",
+			cert:   CertInfo{Kind: "ca", Status: CertOK},
+			raised: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue, raised := certIssue(tt.cert, "test")
+			if raised != tt.raised {
+				t.Fatalf("raised = %v, want %v", raised, tt.raised)
+			}
+			if !raised {
+				return
+			}
+			if issue.ID != tt.id || issue.Title != tt.title || issue.Severity != tt.severity {
+				t.Errorf("issue = %q %q %s, want %q %q %s", issue.ID, issue.Title, issue.Severity, tt.id, tt.title, tt.severity)
+			}
+			if issue.FixAction != tt.fix || issue.CanAutoFix != (tt.fix != "") {
+				t.Errorf("fix = %q (auto %v), want %q", issue.FixAction, issue.CanAutoFix, tt.fix)
+			}
+			// The shared certificate serves every site in secure mode
+			if tt.cert.Kind == "shared" && !strings.Contains(issue.Description, "*.test") {
+				t.Errorf("shared certificate issue doesn't mention the sites it serves: %s", issue.Description)
+			}
+		})
+	}
+}
@@ -43,14 +43,15 @@ func Collect(d *daemon.Daemon) (*Stats, error) {
 	}
 
 	// 2. Daemon Stats
-	stats.SitesParked = len(d.State.Data.Paths)
-	stats.SitesLinked = len(d.State.Data.Links)
+	snapshot := d.State.Snapshot()
+	stats.SitesParked = len(snapshot.Paths)
+	stats.SitesLinked = len(snapshot.Links)
 
 	// Count services (simple check)
-	services := []string{"nginx", "dnsmasq"}
+	services := []string{"nginx"}
 	// Add php-fpm if version set
-	if d.State.Data.PHPVersion != "" {
-		services = append(services, fmt.Sprintf("php%s-fpm", d.State.Data.PHPVersion))
+	if snapshot.PHPVersion != "" {
+		services = append(services, fmt.Sprintf("php%s-fpm", snapshot.PHPVersion))
 	}
 
 	runningCount := 0
@@ -59,6 +60,9 @@ func Collect(d *daemon.Daemon) (*Stats, error) {
 			runningCount++
 		}
 	}
+	if d.DNS != nil {
+		runningCount++ // Built-in DNS server
+	}
 	stats.ServicesRunning = runningCount
 
 	// 3. Nginx Stats (stub_status)
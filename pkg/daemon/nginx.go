@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/nginx"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/services"
+)
+
+// nginxIssueID is the healer issue raised while nginx rejects the generated config
+const nginxIssueID = "nginx-config-rejected"
+
+// NginxStatus is the outcome of the last config apply
+type NginxStatus struct {
+	Path      string             `json:"path"`
+	Backup    string             `json:"backup"`
+	AppliedAt time.Time          `json:"applied_at,omitzero"`
+	Error     *nginx.ConfigError `json:"error,omitempty"`
+}
+
+// NginxStatus reports whether the last generated config was accepted
+func (d *Daemon) NginxStatus() NginxStatus {
+	d.nginxMu.Lock()
+	defer d.nginxMu.Unlock()
+	status := d.nginxStatus
+	status.Path = d.Adapter.GetNginxConfigPath()
+	status.Backup = status.Path + ".bak"
+	return status
+}
+
+// recordNginxResult remembers the outcome of an apply and raises or clears
+// the healer issue for a rejected config
+func (d *Daemon) recordNginxResult(err error) {
+	var configErr *nginx.ConfigError
+	if err != nil && !errors.As(err, &configErr) {
+		return // Not a config problem (e.g. nginx is down); nothing to point at
+	}
+
+	d.nginxMu.Lock()
+	if configErr == nil {
+		d.nginxStatus = NginxStatus{AppliedAt: time.Now()}
+	} else {
+		d.nginxStatus.Error = configErr
+	}
+	d.nginxMu.Unlock()
+
+	if d.HealerService == nil {
+		return
+	}
+	if configErr == nil {
+		d.HealerService.ClearIssue(nginxIssueID)
+		return
+	}
+
+	culprit := "the generated config"
+	switch configErr.Source {
+	case "plugin":
+		culprit = "plugin " + configErr.Name
+	case "site":
+		culprit = "site " + configErr.Name
+	}
+	description := fmt.Sprintf("nginx -t failed in %s: %s.", culprit, configErr.Message)
+	if configErr.Text != "" {
+		description += fmt.Sprintf(" Line %d: %s.", configErr.Line, configErr.Text)
+	}
+	description += " The previous config is still being served."
+
+	d.HealerService.ReportIssue(services.HealerIssue{
+		ID:          nginxIssueID,
+		Title:       "Nginx Config Rejected",
+		Description: description,
+		Severity:    services.SeverityCritical,
+		Source:      services.LogSourceNginxError,
+	})
+}
@@ -0,0 +1,160 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/php"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/plugins"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/services"
+)
+
+// pluginPHP collects the extensions and php.ini values enabled plugins
+// need, and which plugin asked for each. Plugins are read in ID order, so
+// when two set the same key differently the first one's value is kept,
+// every sync, and the clash is returned as an issue.
+func (d *Daemon) pluginPHP() (extensions, values map[string]string, conflicts []services.HealerIssue, errs []error) {
+	extensions, values = map[string]string{}, map[string]string{}
+	if d.PluginManager == nil {
+		return extensions, values, nil, nil
+	}
+	all := d.PluginManager.GetAll()
+	sort.Slice(all, func(i, j int) bool { return all[i].ID() < all[j].ID() })
+
+	setBy := map[string]string{} // php.ini key -> plugin name
+	for _, p := range all {
+		if !d.State.IsPluginEnabled(p.ID()) {
+			continue
+		}
+		hook, ok := p.(plugins.PHPHook)
+		if !ok {
+			continue
+		}
+		for _, ext := range hook.PHPExtensions() {
+			if name := php.ExtensionName(ext); extensions[name] == "" {
+				extensions[name] = p.Name()
+			}
+		}
+		config, err := hook.PHPConfig()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		keys := make([]string, 0, len(config))
+		for key := range config {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			owner, taken := setBy[key]
+			if !taken {
+				setBy[key] = p.Name()
+				values[key] = config[key]
+			} else if values[key] != config[key] {
+				conflicts = append(conflicts, iniConflictIssue(key, owner, values[key], p.Name(), config[key]))
+			}
+		}
+	}
+	return extensions, values, conflicts, errs
+}
+
+// SyncPluginPHP writes the extensions and settings of enabled plugins to
+// SLD's own conf.d ini for every installed PHP version, restarting FPM where
+// it changed. Extensions PHP already loads are left to their own ini;
+// missing ones are reported to the healer.
+func (d *Daemon) SyncPluginPHP() error {
+	d.phpMu.Lock()
+	defer d.phpMu.Unlock()
+
+	extensions, values, conflicts, errs := d.pluginPHP()
+	versions, err := d.Adapter.ListPHPVersions()
+	if err != nil {
+		return fmt.Errorf("failed to list PHP versions: %w", err)
+	}
+
+	names := make([]string, 0, len(extensions))
+	for name := range extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	raised := map[string]bool{}
+	for _, issue := range conflicts {
+		raised[issue.ID] = true
+		d.HealerService.ReportIssue(issue)
+	}
+	for _, version := range versions {
+		var load []string
+		if len(names) > 0 {
+			runtime := d.Adapter.PHPRuntime(version)
+			loaded, err := runtime.Loaded(php.PluginsIni)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			dir, _ := runtime.ExtensionDir()
+			for _, name := range names {
+				switch {
+				case loaded[name]:
+				case php.HasExtension(dir, name):
+					load = append(load, name)
+				default:
+					issue := missingExtensionIssue(version, name, extensions[name], d.Adapter.PHPExtensionPackage(version, name))
+					raised[issue.ID] = true
+					d.HealerService.ReportIssue(issue)
+				}
+			}
+		}
+
+		content := ""
+		if len(load) > 0 || len(values) > 0 {
+			content = php.Render("Generated by SLD for enabled plugins. Changes are overwritten.", load, values)
+		}
+		if err := d.Adapter.SyncPHPIni(version, php.PluginsIni, content); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for id := range d.phpIssues {
+		if !raised[id] {
+			d.HealerService.ClearIssue(id)
+		}
+	}
+	d.phpIssues = raised
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to apply plugin PHP settings: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// iniConflictIssue is the healer issue for two plugins setting the same
+// php.ini key to different values
+func iniConflictIssue(key, kept, keptValue, other, otherValue string) services.HealerIssue {
+	return services.HealerIssue{
+		ID:          "php-ini-conflict-" + key,
+		Title:       "Conflicting PHP Setting: " + key,
+		Description: fmt.Sprintf("%s sets %s = %s but %s sets it to %s. SLD uses %s's value; disable one of them to choose.", kept, key, keptValue, other, otherValue, kept),
+		Severity:    services.SeverityWarning,
+		Source:      services.LogSourcePHPFPM,
+	}
+}
+
+// missingExtensionIssue is the healer issue for an extension a plugin needs
+// that isn't installed for a PHP version
+func missingExtensionIssue(version, name, plugin, pkg string) services.HealerIssue {
+	issue := services.HealerIssue{
+		ID:          fmt.Sprintf("php-ext-missing-%s-%s", version, name),
+		Title:       fmt.Sprintf("PHP %s Extension Missing: %s", version, name),
+		Description: fmt.Sprintf("%s needs the %s extension, which isn't installed for PHP %s.", plugin, name, version),
+		Severity:    services.SeverityWarning,
+		Source:      services.LogSourcePHPFPM,
+	}
+	if pkg != "" {
+		issue.Description += fmt.Sprintf(" Install %s, or run `sld php ext enable %s --php %s`.", pkg, name, version)
+		issue.FixAction = fmt.Sprintf("install_php_ext:%s@%s", name, version)
+		issue.CanAutoFix = true
+	}
+	return issue
+}
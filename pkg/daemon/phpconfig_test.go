@@ -0,0 +1,48 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/plugins"
+)
+
+// phpPlugin is a plugin that only sets php.ini values
+type phpPlugin struct {
+	plugins.Plugin
+	id     string
+	config map[string]string
+}
+
+func (p phpPlugin) ID() string                            { return p.id }
+func (p phpPlugin) Name() string                          { return p.id }
+func (p phpPlugin) PHPExtensions() []string               { return nil }
+func (p phpPlugin) PHPConfig() (map[string]string, error) { return p.config, nil }
+
+func TestPluginPHPConflictsAreStable(t *testing.T) {
+	d := testDaemon(t, t.TempDir())
+	d.PluginManager = plugins.NewManager(t.TempDir(), d.State)
+	for _, p := range []phpPlugin{
+		{id: "xdebug", config: map[string]string{"memory_limit": "1G", "xdebug.mode": "debug"}},
+		{id: "blackfire", config: map[string]string{"memory_limit": "512M"}},
+		{id: "mailpit", config: map[string]string{"memory_limit": "512M", "sendmail_path": "mailpit sendmail"}},
+	} {
+		d.PluginManager.Register(p)
+		d.State.SetPluginEnabled(p.id, true)
+	}
+
+	for i := 0; i < 10; i++ {
+		_, values, conflicts, errs := d.pluginPHP()
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		want := map[string]string{"memory_limit": "512M", "xdebug.mode": "debug", "sendmail_path": "mailpit sendmail"}
+		if !reflect.DeepEqual(values, want) {
+			t.Fatalf("values = %v, want %v", values, want)
+		}
+		// mailpit agrees with blackfire; only xdebug clashes
+		if len(conflicts) != 1 || conflicts[0].ID != "php-ini-conflict-memory_limit" {
+			t.Fatalf("conflicts = %+v, want one on memory_limit", conflicts)
+		}
+	}
+}
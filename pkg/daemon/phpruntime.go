@@ -0,0 +1,203 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/php"
+)
+
+// PHPIni is the php.ini of a PHP version, see `sld php ini`
+type PHPIni struct {
+	Version   string            `json:"version"`
+	Values    map[string]string `json:"values"`    // Effective value of every directive FPM sees
+	Overrides map[string]string `json:"overrides"` // Set with `sld php ini set`
+}
+
+// phpRuntime resolves an installed PHP version, the default one when
+// version is empty
+func (d *Daemon) phpRuntime(version string) (php.Runtime, error) {
+	if version == "" {
+		version = d.State.Snapshot().PHPVersion
+	}
+	versions, err := d.Adapter.ListPHPVersions()
+	if err != nil {
+		return php.Runtime{}, fmt.Errorf("failed to list PHP versions: %w", err)
+	}
+	for _, v := range versions {
+		if v == version {
+			return d.Adapter.PHPRuntime(version), nil
+		}
+	}
+	return php.Runtime{}, fmt.Errorf("PHP %s is not installed (installed: %v)", version, versions)
+}
+
+// PHPExtensions lists the extensions a PHP version loads or has installed,
+// with the package each one comes from
+func (d *Daemon) PHPExtensions(version string) ([]php.Extension, error) {
+	runtime, err := d.phpRuntime(version)
+	if err != nil {
+		return nil, err
+	}
+	list, err := runtime.Extensions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if !list[i].Builtin {
+			list[i].Package = d.Adapter.PHPExtensionPackage(runtime.Version, list[i].Name)
+		}
+	}
+	return list, nil
+}
+
+// EnablePHPExtension loads an extension in a PHP version, installing its
+// package first when it isn't there
+func (d *Daemon) EnablePHPExtension(version, name string) error {
+	runtime, err := d.phpRuntime(version)
+	if err != nil {
+		return err
+	}
+	name = php.ExtensionName(name)
+	if loaded, err := runtime.Modules(true); err != nil {
+		return err
+	} else if loaded[name] {
+		fmt.Printf("%s is already enabled for PHP %s\n", name, runtime.Version)
+		return nil
+	}
+
+	dir, err := runtime.ExtensionDir()
+	if err != nil {
+		return err
+	}
+	if !php.HasExtension(dir, name) {
+		if d.Adapter.PHPExtensionPackage(runtime.Version, name) == "" {
+			return fmt.Errorf("no package provides the %s extension for PHP %s", name, runtime.Version)
+		}
+		if err := d.Adapter.InstallPHPExtension(runtime.Version, name); err != nil {
+			return err
+		}
+	}
+	if err := d.Adapter.SetPHPExtension(runtime.Version, name, true); err != nil {
+		return err
+	}
+
+	if loaded, err := runtime.Modules(true); err == nil && !loaded[name] {
+		return fmt.Errorf("%s is installed but PHP %s still doesn't load it; check `%s -m` for errors", name, runtime.Version, runtime.Binary)
+	}
+	// A plugin may have been loading it through SLD's own ini
+	if err := d.SyncPluginPHP(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	fmt.Printf("Enabled %s for PHP %s\n", name, runtime.Version)
+	return nil
+}
+
+// DisablePHPExtension stops a PHP version loading a shared extension. The
+// package stays installed.
+func (d *Daemon) DisablePHPExtension(version, name string) error {
+	runtime, err := d.phpRuntime(version)
+	if err != nil {
+		return err
+	}
+	name = php.ExtensionName(name)
+	list, err := runtime.Extensions()
+	if err != nil {
+		return err
+	}
+	var ext *php.Extension
+	for i := range list {
+		if list[i].Name == name {
+			ext = &list[i]
+		}
+	}
+
+	switch {
+	case ext == nil || !ext.Loaded:
+		fmt.Printf("%s is not enabled for PHP %s\n", name, runtime.Version)
+		return nil
+	case ext.Builtin:
+		return fmt.Errorf("%s is compiled into PHP %s and can't be disabled", name, runtime.Version)
+	case filepath.Base(ext.IniFile) == php.PluginsIni:
+		extensions, _, _, _ := d.pluginPHP()
+		return fmt.Errorf("%s is enabled for the %s plugin; disable the plugin instead", name, extensions[name])
+	}
+
+	if err := d.Adapter.SetPHPExtension(runtime.Version, name, false); err != nil {
+		return err
+	}
+	if loaded, err := runtime.Modules(true); err == nil && loaded[name] {
+		return fmt.Errorf("%s is still loaded by %s, which SLD doesn't manage", name, ext.IniFile)
+	}
+	fmt.Printf("Disabled %s for PHP %s\n", name, runtime.Version)
+	return nil
+}
+
+// PHPIniSettings returns the php.ini settings of a PHP version and the
+// overrides set through SLD
+func (d *Daemon) PHPIniSettings(version string) (PHPIni, error) {
+	runtime, err := d.phpRuntime(version)
+	if err != nil {
+		return PHPIni{}, err
+	}
+	values, err := runtime.Ini()
+	if err != nil {
+		return PHPIni{}, err
+	}
+	return PHPIni{Version: runtime.Version, Values: values, Overrides: phpOverrides(runtime)}, nil
+}
+
+// SetPHPIni overrides a php.ini directive for a PHP version and restarts
+// its FPM
+func (d *Daemon) SetPHPIni(version, name, value string) error {
+	if err := php.ValidateDirective(name, value); err != nil {
+		return err
+	}
+	runtime, err := d.phpRuntime(version)
+	if err != nil {
+		return err
+	}
+	values, err := runtime.Ini()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[name]; !ok {
+		return fmt.Errorf("PHP %s has no %s setting; is the extension that defines it enabled?", runtime.Version, name)
+	}
+
+	overrides := phpOverrides(runtime)
+	overrides[name] = value
+	return d.writePHPOverrides(runtime, overrides)
+}
+
+// UnsetPHPIni drops an override, returning the directive to its php.ini value
+func (d *Daemon) UnsetPHPIni(version, name string) error {
+	runtime, err := d.phpRuntime(version)
+	if err != nil {
+		return err
+	}
+	overrides := phpOverrides(runtime)
+	if _, ok := overrides[name]; !ok {
+		return fmt.Errorf("%s is not overridden for PHP %s", name, runtime.Version)
+	}
+	delete(overrides, name)
+	return d.writePHPOverrides(runtime, overrides)
+}
+
+// phpOverrides reads SLD's override ini of a runtime
+func phpOverrides(runtime php.Runtime) map[string]string {
+	data, err := os.ReadFile(filepath.Join(runtime.ScanDir, php.OverridesIni))
+	if runtime.ScanDir == "" || err != nil {
+		return map[string]string{}
+	}
+	return php.ParseValues(string(data))
+}
+
+func (d *Daemon) writePHPOverrides(runtime php.Runtime, overrides map[string]string) error {
+	content := ""
+	if len(overrides) > 0 {
+		content = php.Render("Set with `sld php ini set`. Changes are overwritten.", nil, overrides)
+	}
+	return d.Adapter.SyncPHPIni(runtime.Version, php.OverridesIni, content)
+}
@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/fpm"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/nginx"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/services"
+)
+
+// sitePools builds the FPM pool of each isolated PHP site from the state
+// snapshot s; versions maps site indexes to their PHP version. Nothing is written.
+func (d *Daemon) sitePools(s *state.State, sites []nginx.Site, versions map[int]string) map[int]fpm.Pool {
+	pools := map[int]fpm.Pool{}
+	for i, version := range versions {
+		site := sites[i]
+		pool := fpm.Pool{
+			Site:    site.Domain,
+			Owner:   d.poolOwner(site.Path),
+			Slowlog: filepath.Join(d.Paths.Logs, site.Domain+"-php-slow.log"),
+		}
+		if settings := s.SiteConfigs[site.Domain].FPM; settings != nil {
+			pool.Settings = *settings
+		}
+		if err := pool.CheckUser(); err != nil {
+			fmt.Printf("Warning: %s: %v\n", site.Domain, err)
+		}
+		pool.Socket = d.Adapter.PHPPoolSocket(version, pool.Name())
+		if pool.Socket == "" {
+			continue // Not supported on this OS
+		}
+		pools[i] = pool
+	}
+	return pools
+}
+
+// syncPools installs exactly the given pools for each PHP version,
+// removing those of sites that are gone. It returns the versions whose
+// pools couldn't be installed; their sites keep the shared socket.
+func (d *Daemon) syncPools(pools map[int]fpm.Pool, versions map[int]string) map[string]bool {
+	byVersion := map[string]map[string]string{}
+	// Versions without isolated sites are synced too, which removes stale pools
+	if installed, err := d.Adapter.ListPHPVersions(); err == nil {
+		for _, v := range installed {
+			byVersion[v] = map[string]string{}
+		}
+	}
+	for i, pool := range pools {
+		version := versions[i]
+		if byVersion[version] == nil {
+			byVersion[version] = map[string]string{}
+		}
+		byVersion[version][pool.Name()] = pool.Render()
+	}
+
+	failed := map[string]bool{}
+	order := make([]string, 0, len(byVersion))
+	for v := range byVersion {
+		order = append(order, v)
+	}
+	sort.Strings(order)
+	for _, version := range order {
+		if err := d.Adapter.SyncPHPPools(version, byVersion[version]); err != nil {
+			fmt.Printf("Warning: %v. PHP %s sites share its default pool until this is fixed.\n", err, version)
+			failed[version] = true
+		}
+	}
+	return failed
+}
+
+// poolOwner is the user a site's PHP runs as unless .sld.yaml says
+// otherwise: the project's owner, so files the app writes stay theirs
+func (d *Daemon) poolOwner(path string) string {
+	if owner := services.PathOwner(path); owner != "" {
+		return owner
+	}
+	if owner := os.Getenv("SUDO_USER"); owner != "" {
+		return owner
+	}
+	// FPM refuses to run pools as root
+	return "www-data"
+}
@@ -0,0 +1,452 @@
+package daemon
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+)
+
+// archiveFormatVersion is bumped when the export layout changes
+const archiveFormatVersion = 1
+
+// ExportOptions selects what goes into an environment archive
+type ExportOptions struct {
+	Snapshots    []string // Snapshot filenames to include
+	AllSnapshots bool     // Include every snapshot in DatabaseService.SnapDir
+}
+
+// ExportManifest describes the contents of an environment archive
+type ExportManifest struct {
+	Version    int               `json:"version"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Hostname   string            `json:"hostname"`
+	Home       string            `json:"home"` // Used to rewrite paths on import
+	Snapshots  []string          `json:"snapshots"`
+	Certs      []string          `json:"certs"`
+	EnvBackups map[string]string `json:"env_backups"` // Archive dir -> original project path
+}
+
+// ImportResult summarises what an import restored
+type ImportResult struct {
+	FromHome     string   `json:"from_home"`
+	ToHome       string   `json:"to_home"`
+	PathsChanged bool     `json:"paths_rewritten"`
+	Sites        int      `json:"sites"`
+	Snapshots    int      `json:"snapshots"`
+	Certs        int      `json:"certs"`
+	EnvBackups   int      `json:"env_backups"`
+	Warnings     []string `json:"warnings,omitempty"`
+}
+
+// Export writes a gzipped tar archive of the environment to w
+func (d *Daemon) Export(w io.Writer, opts ExportOptions) (*ExportManifest, error) {
+	hostname, _ := os.Hostname()
+	manifest := &ExportManifest{
+		Version:    archiveFormatVersion,
+		CreatedAt:  time.Now(),
+		Hostname:   hostname,
+		Home:       getRealUserHome(),
+		EnvBackups: make(map[string]string),
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	// 1. State
+	stateData, err := json.MarshalIndent(d.State.Snapshot(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTarFile(tw, "state.json", stateData); err != nil {
+		return nil, err
+	}
+
+	// 2. Certificates
+	certDir := d.Paths.Certs
+	if entries, err := os.ReadDir(certDir); err == nil {
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if err := copyIntoTar(tw, filepath.Join(certDir, e.Name()), "certs/"+e.Name()); err != nil {
+				return nil, fmt.Errorf("failed to add certificate %s: %w", e.Name(), err)
+			}
+			manifest.Certs = append(manifest.Certs, e.Name())
+		}
+	}
+
+	// 3. Database snapshots
+	snapshots := opts.Snapshots
+	if opts.AllSnapshots {
+		snapshots = nil
+		if entries, err := os.ReadDir(d.DatabaseService.SnapDir); err == nil {
+			for _, e := range entries {
+				if !e.IsDir() {
+					snapshots = append(snapshots, e.Name())
+				}
+			}
+		}
+	}
+	for _, name := range snapshots {
+		name = filepath.Base(name)
+		if err := copyIntoTar(tw, filepath.Join(d.DatabaseService.SnapDir, name), "snapshots/"+name); err != nil {
+			return nil, fmt.Errorf("failed to add snapshot %s: %w", name, err)
+		}
+		manifest.Snapshots = append(manifest.Snapshots, name)
+	}
+
+	// 4. Env backups of every known project
+	for i, projectPath := range d.projectPaths() {
+		backupDir := filepath.Join(projectPath, ".env-backups")
+		entries, err := os.ReadDir(backupDir)
+		if err != nil {
+			continue
+		}
+		archiveDir := fmt.Sprintf("env-backups/%d-%s", i, filepath.Base(projectPath))
+		added := false
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if err := copyIntoTar(tw, filepath.Join(backupDir, e.Name()), archiveDir+"/"+e.Name()); err != nil {
+				return nil, fmt.Errorf("failed to add env backup %s: %w", e.Name(), err)
+			}
+			added = true
+		}
+		if added {
+			manifest.EnvBackups[archiveDir] = projectPath
+		}
+	}
+
+	// 5. Manifest last, once we know what was included
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTarFile(tw, "manifest.json", manifestData); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Import restores an archive created by Export, rewriting paths when the
+// home directory differs from the exporting machine.
+func (d *Daemon) Import(r io.Reader) (*ImportResult, error) {
+	result, err := d.restoreArchive(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bring the running system in line with the new state
+	d.PluginManager.StartEnabled()
+	if err := d.SyncPluginPHP(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	d.Events.Publish(events.Event{Type: events.SitesUpdated})
+
+	if err := d.syncHosts(); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to sync hosts: %v", err))
+	}
+	if s := d.State.Snapshot(); s.Secure || len(s.Certificates) > 0 {
+		// Re-issue under this machine's CA; imported certs stay as a fallback
+		if err := d.reissueCerts(); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to re-issue certificates: %v", err))
+		}
+		return result, nil
+	}
+	if err := d.refreshNginxConfig(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// restoreArchive replaces the state and copies the certificates, snapshots
+// and env backups of an archive into place, without touching the running
+// system
+func (d *Daemon) restoreArchive(r io.Reader) (*ImportResult, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid sld archive: %w", err)
+	}
+	defer gz.Close()
+
+	// 1. Unpack into a staging dir so a broken archive changes nothing
+	staging, err := os.MkdirTemp("", "sld-import-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("archive contains unsafe path: %s", hdr.Name)
+		}
+		dest := filepath.Join(staging, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm())
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.Close()
+	}
+
+	var manifest ExportManifest
+	if err := readJSONFile(filepath.Join(staging, "manifest.json"), &manifest); err != nil {
+		return nil, fmt.Errorf("archive has no valid manifest: %w", err)
+	}
+	if manifest.Version > archiveFormatVersion {
+		return nil, fmt.Errorf("archive format %d is newer than supported format %d", manifest.Version, archiveFormatVersion)
+	}
+
+	stateData, err := os.ReadFile(filepath.Join(staging, "state.json"))
+	if err != nil {
+		return nil, fmt.Errorf("archive has no state: %w", err)
+	}
+	imported, err := state.Decode(stateData)
+	if err != nil {
+		return nil, fmt.Errorf("archive has no valid state: %w", err)
+	}
+
+	result := &ImportResult{FromHome: manifest.Home, ToHome: getRealUserHome()}
+	rewrite := func(p string) string { return p }
+	if manifest.Home != "" && manifest.Home != result.ToHome {
+		result.PathsChanged = true
+		rewrite = func(p string) string { return rewriteHome(p, manifest.Home, result.ToHome) }
+	}
+
+	// 2. State, with paths rewritten for this machine
+	for i, p := range imported.Paths {
+		imported.Paths[i] = rewrite(p)
+	}
+	for i, p := range imported.Ignored {
+		imported.Ignored[i] = rewrite(p)
+	}
+	for name, p := range imported.Links {
+		imported.Links[name] = rewrite(p)
+		if _, err := os.Stat(imported.Links[name]); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("linked project %s not found at %s", name, imported.Links[name]))
+		}
+	}
+	// Stashed workspaces come from the same machine
+	for name, ws := range imported.Workspaces {
+		for i, p := range ws.Paths {
+			ws.Paths[i] = rewrite(p)
+		}
+		for link, p := range ws.Links {
+			ws.Links[link] = rewrite(p)
+		}
+		imported.Workspaces[name] = ws
+	}
+	if err := d.State.Replace(imported); err != nil {
+		return nil, fmt.Errorf("failed to save state: %w", err)
+	}
+	result.Sites = len(imported.Links) + len(imported.Paths)
+
+	// 3. Certificates
+	certDir := d.Paths.Certs
+	for _, name := range manifest.Certs {
+		if err := copyFileFrom(filepath.Join(staging, "certs", filepath.Base(name)), filepath.Join(certDir, filepath.Base(name))); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("certificate %s: %v", name, err))
+			continue
+		}
+		result.Certs++
+	}
+
+	// 4. Snapshots
+	for _, name := range manifest.Snapshots {
+		if err := copyFileFrom(filepath.Join(staging, "snapshots", filepath.Base(name)), filepath.Join(d.DatabaseService.SnapDir, filepath.Base(name))); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("snapshot %s: %v", name, err))
+			continue
+		}
+		result.Snapshots++
+	}
+
+	// 5. Env backups go back next to their project. The manifest comes from
+	// the archive, so only projects the imported state serves are written to.
+	projects := map[string]bool{}
+	for _, p := range imported.Links {
+		projects[p] = true
+	}
+	parked := map[string]bool{}
+	for _, p := range imported.Paths {
+		parked[p] = true
+	}
+	for archiveDir, projectPath := range manifest.EnvBackups {
+		if path.Clean(archiveDir) != archiveDir || path.Dir(archiveDir) != "env-backups" || !filepath.IsLocal(archiveDir) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("skipped env backups from unsafe archive path %s", archiveDir))
+			continue
+		}
+		target := filepath.Clean(rewrite(projectPath))
+		if !projects[target] && !parked[filepath.Dir(target)] {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("skipped env backups for %s, which is not a linked or parked project", target))
+			continue
+		}
+		if _, err := os.Stat(target); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("skipped env backups for missing project %s", target))
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(staging, filepath.FromSlash(archiveDir)))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			src := filepath.Join(staging, filepath.FromSlash(archiveDir), e.Name())
+			if err := copyFileFrom(src, filepath.Join(target, ".env-backups", e.Name())); err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("env backup %s: %v", e.Name(), err))
+				continue
+			}
+			result.EnvBackups++
+		}
+	}
+	return result, nil
+}
+
+// projectPaths lists linked projects and projects inside parked directories
+func (d *Daemon) projectPaths() []string {
+	s := d.State.Snapshot()
+	seen := map[string]bool{}
+	var paths []string
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+
+	for _, p := range s.Links {
+		add(p)
+	}
+	for _, parked := range s.Paths {
+		entries, err := os.ReadDir(parked)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+				add(filepath.Join(parked, e.Name()))
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// rewriteHome moves p from oldHome to newHome if it lives under oldHome
+func rewriteHome(p, oldHome, newHome string) string {
+	oldHome = strings.TrimSuffix(oldHome, "/")
+	if p == oldHome {
+		return newHome
+	}
+	if strings.HasPrefix(p, oldHome+"/") {
+		return filepath.Join(newHome, strings.TrimPrefix(p, oldHome+"/"))
+	}
+	return p
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func copyIntoTar(tw *tar.Writer, src, name string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    int64(info.Mode().Perm()),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func copyFileFrom(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	// Keep the archived mode so private keys stay private
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func readJSONFile(file string, v interface{}) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
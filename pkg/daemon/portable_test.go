@@ -0,0 +1,232 @@
+package daemon
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/config"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/services"
+)
+
+// testDaemon returns a daemon with its SLD home in dir and nothing running
+func testDaemon(t *testing.T, dir string) *Daemon {
+	t.Helper()
+	paths := config.NewPaths(dir)
+	m, err := state.NewManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return &Daemon{
+		State:           m,
+		Paths:           paths,
+		DatabaseService: services.NewDatabaseService(paths.Snapshots),
+	}
+}
+
+// setHome makes getRealUserHome return home
+func setHome(t *testing.T, home string) {
+	t.Setenv("SUDO_USER", "")
+	t.Setenv("HOME", home)
+}
+
+func writeFile(t *testing.T, file, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	oldHome, newHome := filepath.Join(root, "old"), filepath.Join(root, "new")
+	outside := filepath.Join(root, "srv", "api")
+
+	// The exporting machine: a parked dir with one project, two links
+	writeFile(t, filepath.Join(oldHome, "Sites", "acme", ".env-backups", ".env.1"), "APP_ENV=local\n")
+	writeFile(t, filepath.Join(oldHome, "Code", "shop", "index.php"), "")
+	if err := os.MkdirAll(outside, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	src := testDaemon(t, filepath.Join(root, "src-sld"))
+	src.State.AddPath(filepath.Join(oldHome, "Sites"))
+	src.State.AddLink("shop", filepath.Join(oldHome, "Code", "shop"))
+	src.State.AddLink("api", outside)
+	src.State.Data.Ignored = []string{filepath.Join(oldHome, "Sites", "legacy")}
+	src.State.Data.Workspaces["client"] = state.Workspace{
+		Paths: []string{filepath.Join(oldHome, "Clients")},
+		Links: map[string]string{"portal": filepath.Join(oldHome, "Clients", "portal")},
+	}
+	writeFile(t, filepath.Join(src.Paths.Certs, "acme.test.crt"), "cert")
+	writeFile(t, filepath.Join(src.Paths.Snapshots, "acme-1.sql"), "dump")
+
+	setHome(t, oldHome)
+	var archive bytes.Buffer
+	manifest, err := src.Export(&archive, ExportOptions{AllSnapshots: true})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if manifest.Home != oldHome || len(manifest.EnvBackups) != 1 {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+
+	// The importing machine has acme checked out, but not shop
+	if err := os.MkdirAll(filepath.Join(newHome, "Sites", "acme"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	dst := testDaemon(t, filepath.Join(root, "dst-sld"))
+	setHome(t, newHome)
+	result, err := dst.restoreArchive(&archive)
+	if err != nil {
+		t.Fatalf("restoreArchive: %v", err)
+	}
+
+	if !result.PathsChanged || result.FromHome != oldHome || result.ToHome != newHome {
+		t.Errorf("unexpected homes in result: %+v", result)
+	}
+	data := dst.State.Data
+	if want := filepath.Join(newHome, "Sites"); len(data.Paths) != 1 || data.Paths[0] != want {
+		t.Errorf("Paths = %v, want [%s]", data.Paths, want)
+	}
+	if want := filepath.Join(newHome, "Sites", "legacy"); len(data.Ignored) != 1 || data.Ignored[0] != want {
+		t.Errorf("Ignored = %v, want [%s]", data.Ignored, want)
+	}
+	if want := filepath.Join(newHome, "Code", "shop"); data.Links["shop"] != want {
+		t.Errorf("shop link = %s, want %s", data.Links["shop"], want)
+	}
+	if data.Links["api"] != outside {
+		t.Errorf("api link outside the home was rewritten to %s", data.Links["api"])
+	}
+	client := data.Workspaces["client"]
+	if want := filepath.Join(newHome, "Clients"); len(client.Paths) != 1 || client.Paths[0] != want {
+		t.Errorf("client workspace Paths = %v, want [%s]", client.Paths, want)
+	}
+	if want := filepath.Join(newHome, "Clients", "portal"); client.Links["portal"] != want {
+		t.Errorf("client workspace portal link = %s, want %s", client.Links["portal"], want)
+	}
+
+	backup := filepath.Join(newHome, "Sites", "acme", ".env-backups", ".env.1")
+	if got, err := os.ReadFile(backup); err != nil || string(got) != "APP_ENV=local\n" {
+		t.Errorf("env backup not restored to %s: %q, %v", backup, got, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst.Paths.Certs, "acme.test.crt")); err != nil {
+		t.Errorf("certificate not restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst.Paths.Snapshots, "acme-1.sql")); err != nil {
+		t.Errorf("snapshot not restored: %v", err)
+	}
+	if result.EnvBackups != 1 || result.Certs != 1 || result.Snapshots != 1 {
+		t.Errorf("unexpected counts: %+v", result)
+	}
+
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "linked project shop not found") {
+		t.Errorf("Warnings = %v, want the missing shop link", result.Warnings)
+	}
+}
+
+// tarGz builds an archive holding files in order
+func tarGz(t *testing.T, files ...[2]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		if err := writeTarFile(tw, f[0], []byte(f[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestImportRejectsUnsafePaths(t *testing.T) {
+	for _, name := range []string{"..", "../escape", "certs/../../escape", "/etc/escape"} {
+		d := testDaemon(t, t.TempDir())
+		archive := tarGz(t, [2]string{name, "x"})
+		if _, err := d.restoreArchive(archive); err == nil || !strings.Contains(err.Error(), "unsafe path") {
+			t.Errorf("%s: err = %v, want an unsafe path error", name, err)
+		}
+	}
+}
+
+func TestImportRejectsNewerFormat(t *testing.T) {
+	d := testDaemon(t, t.TempDir())
+	d.State.AddLink("kept", "/srv/kept")
+
+	manifest, _ := json.Marshal(ExportManifest{Version: archiveFormatVersion + 1})
+	archive := tarGz(t, [2]string{"manifest.json", string(manifest)}, [2]string{"state.json", `{"links": {}}`})
+	_, err := d.restoreArchive(archive)
+	if err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Fatalf("err = %v, want a format version error", err)
+	}
+	if d.State.Data.Links["kept"] != "/srv/kept" {
+		t.Errorf("state was changed by a refused archive: %v", d.State.Data.Links)
+	}
+}
+
+func TestImportKeepsEnvBackupsInProjects(t *testing.T) {
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	setHome(t, home)
+	project := filepath.Join(home, "Sites", "acme")
+	elsewhere := filepath.Join(root, "etc")
+	for _, dir := range []string{project, elsewhere} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(root, "secret"), "outside the archive")
+
+	manifest, _ := json.Marshal(ExportManifest{
+		Version: archiveFormatVersion,
+		Home:    home,
+		EnvBackups: map[string]string{
+			"env-backups/0-acme":   project,
+			"env-backups/1-etc":    elsewhere,
+			"env-backups/../../..": project,
+		},
+	})
+	stateData, _ := json.Marshal(map[string]interface{}{"paths": []string{filepath.Join(home, "Sites")}})
+	archive := tarGz(t,
+		[2]string{"manifest.json", string(manifest)},
+		[2]string{"state.json", string(stateData)},
+		[2]string{"env-backups/0-acme/.env.1", "APP_ENV=local"},
+		[2]string{"env-backups/1-etc/passwd", "owned"},
+	)
+
+	d := testDaemon(t, filepath.Join(root, "sld"))
+	result, err := d.restoreArchive(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".env-backups", ".env.1")); err != nil {
+		t.Errorf("backup of the parked project not restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(elsewhere, ".env-backups")); err == nil {
+		t.Error("backups written into a directory that is not a project")
+	}
+	if _, err := os.Stat(filepath.Join(project, ".env-backups", "secret")); err == nil {
+		t.Error("a file outside the archive was copied")
+	}
+	if result.EnvBackups != 1 || len(result.Warnings) != 2 {
+		t.Errorf("EnvBackups = %d, Warnings = %v; want 1 and two skipped entries", result.EnvBackups, result.Warnings)
+	}
+}
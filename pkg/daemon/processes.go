@@ -0,0 +1,165 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/project"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/services"
+)
+
+// StartSupervisor turns on process supervision. Only the long-running daemon
+// calls it: processes started by a short-lived CLI would die with it.
+// Sites marked to be supervised are started now and whenever sites change.
+func (d *Daemon) StartSupervisor() {
+	d.supervising = true
+	d.Events.Subscribe(events.SitesUpdated, func(events.Event) {
+		go d.syncProcesses()
+	})
+	go d.syncProcesses()
+}
+
+// StartProcesses starts the workers declared in a site's .sld.yaml or
+// Procfile and keeps them running across daemon restarts
+func (d *Daemon) StartProcesses(name string) ([]services.ProcessStatus, error) {
+	site, err := d.findSite(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.startSiteProcesses(site); err != nil {
+		return nil, err
+	}
+	if err := d.setSupervise(site.Domain, true); err != nil {
+		return nil, err
+	}
+
+	d.Events.Publish(events.Event{Type: events.SitesUpdated})
+	return d.Supervisor.Status(site.Name), nil
+}
+
+// StopProcesses stops a site's workers and stops starting them with the daemon
+func (d *Daemon) StopProcesses(name string) error {
+	site, err := d.findSite(name)
+	if err != nil {
+		return err
+	}
+	if err := d.setSupervise(site.Domain, false); err != nil {
+		return err
+	}
+	if err := d.Supervisor.Stop(site.Name); err != nil {
+		return err
+	}
+
+	d.Events.Publish(events.Event{Type: events.SitesUpdated})
+	return nil
+}
+
+// RestartProcesses stops and starts all of a site's workers
+func (d *Daemon) RestartProcesses(name string) ([]services.ProcessStatus, error) {
+	site, err := d.findSite(name)
+	if err != nil {
+		return nil, err
+	}
+	d.Supervisor.Stop(site.Name) // Not running yet is fine
+	return d.StartProcesses(site.Name)
+}
+
+// ProcessStatus reports a site's workers
+func (d *Daemon) ProcessStatus(name string) ([]services.ProcessStatus, error) {
+	site, err := d.findSite(name)
+	if err != nil {
+		return nil, err
+	}
+	statuses := d.Supervisor.Status(site.Name)
+	if statuses == nil {
+		statuses = []services.ProcessStatus{}
+	}
+	return statuses, nil
+}
+
+// ProcessLogs returns the last n output lines of a site's workers
+func (d *Daemon) ProcessLogs(name string, n int) ([]services.LogEntryData, error) {
+	site, err := d.findSite(name)
+	if err != nil {
+		return nil, err
+	}
+	return d.Supervisor.Logs(site.Name, n), nil
+}
+
+// startSiteProcesses reads the site's current process list and hands it to the supervisor
+func (d *Daemon) startSiteProcesses(site Site) error {
+	conf, err := project.Detect(site.Path)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(conf.Processes))
+	for name := range conf.Processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	specs := make([]services.ProcessSpec, 0, len(names))
+	for _, name := range names {
+		specs = append(specs, services.ProcessSpec{Name: name, Command: conf.Processes[name]})
+	}
+	return d.Supervisor.Start(site.Name, site.Path, specs, conf.Env)
+}
+
+// syncProcesses starts supervised sites and removes the processes of sites
+// that were unlinked, forgotten or switched away from
+func (d *Daemon) syncProcesses() {
+	if !d.supervising {
+		return
+	}
+	d.syncMu.Lock()
+	defer d.syncMu.Unlock()
+
+	sites, err := d.GetSites()
+	if err != nil {
+		return
+	}
+
+	s := d.State.Snapshot()
+	live := make(map[string]bool, len(sites))
+	for _, site := range sites {
+		live[site.Name] = true
+		if !s.SiteConfigs[site.Domain].Supervise {
+			continue
+		}
+		if err := d.startSiteProcesses(site); err != nil {
+			fmt.Printf("Warning: failed to start processes for %s: %v\n", site.Name, err)
+		}
+	}
+
+	for _, name := range d.Supervisor.Sites() {
+		if !live[name] {
+			d.Supervisor.Remove(name)
+		}
+	}
+}
+
+// setSupervise records whether a site's workers start with the daemon
+func (d *Daemon) setSupervise(domain string, supervise bool) error {
+	config := d.State.Snapshot().SiteConfigs[domain]
+	if config.Supervise == supervise {
+		return nil
+	}
+	config.Supervise = supervise
+	return d.State.SetSiteConfig(domain, config)
+}
+
+// findSite looks a site up by name or domain
+func (d *Daemon) findSite(name string) (Site, error) {
+	sites, err := d.GetSites()
+	if err != nil {
+		return Site{}, err
+	}
+	for _, s := range sites {
+		if s.Name == name || s.Domain == name {
+			return s, nil
+		}
+	}
+	return Site{}, fmt.Errorf("site %s not found", name)
+}
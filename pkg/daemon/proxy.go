@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/project"
+)
+
+var siteName = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// Proxy serves <name>.<tld> by reverse-proxying to target, e.g. a Node or Go
+// dev server. Projects served from a directory set `proxy:` in their
+// .sld.yaml instead, since it is re-read on every refresh.
+func (d *Daemon) Proxy(name, target string, secure bool) (string, error) {
+	s := d.State.Snapshot()
+	domain := proxyDomain(s.TLD, name)
+	name = strings.TrimSuffix(domain, "."+s.TLD)
+	if !siteName.MatchString(name) {
+		return "", fmt.Errorf("invalid site name %q", name)
+	}
+	upstream, err := project.ParseProxy(target)
+	if err != nil {
+		return "", err
+	}
+	if site, err := d.findSite(name); err == nil && site.Type != "proxy" {
+		return "", fmt.Errorf("%s is a %s site at %s; set `proxy: %s` in its .sld.yaml instead", domain, site.Type, site.Path, upstream)
+	}
+
+	config := s.SiteConfigs[domain]
+	config.Proxy = upstream
+	config.Secure = config.Secure || secure
+	config.Standalone = true
+	if err := d.State.SetSiteConfig(domain, config); err != nil {
+		return "", fmt.Errorf("failed to save state: %w", err)
+	}
+
+	d.Events.Publish(events.Event{Type: events.SitesUpdated})
+	if secure {
+		if _, err := d.TrustCA(); err != nil {
+			return "", err
+		}
+	}
+	return domain, d.ensureCerts()
+}
+
+// Unproxy stops serving a proxy site registered with `sld proxy`
+func (d *Daemon) Unproxy(name string) error {
+	s := d.State.Snapshot()
+	domain := proxyDomain(s.TLD, name)
+	config, ok := s.SiteConfigs[domain]
+	if !ok || !config.Standalone {
+		return fmt.Errorf("%s is not a proxy site", domain)
+	}
+	if site, err := d.findSite(domain); err == nil && site.Type != "proxy" {
+		return fmt.Errorf("%s is a %s site; remove `proxy:` from its .sld.yaml instead", domain, site.Type)
+	}
+
+	if err := d.State.RemoveSiteConfig(domain); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	d.removeSiteCert(domain)
+
+	d.Events.Publish(events.Event{Type: events.SitesUpdated})
+	return d.refreshNginxConfig()
+}
+
+// proxyDomain accepts a bare name or a full domain
+func proxyDomain(tld, name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if strings.HasSuffix(name, "."+tld) {
+		return name
+	}
+	return name + "." + tld
+}
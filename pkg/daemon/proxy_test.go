@@ -0,0 +1,33 @@
+package daemon
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
+)
+
+func TestProjectConfigsDoNotOutliveTheirProject(t *testing.T) {
+	dir := t.TempDir()
+	d := testDaemon(t, filepath.Join(dir, "sld"))
+	d.State.AddPath(filepath.Join(dir, "Sites"))
+	d.State.SetSiteConfig("api.test", state.SiteConfig{Proxy: "http://127.0.0.1:3000", Standalone: true})
+	// From the .sld.yaml of a project that has since been deleted
+	d.State.SetSiteConfig("shop.test", state.SiteConfig{Proxy: "http://127.0.0.1:5000"})
+
+	sites, err := d.GetSites()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) != 1 || sites[0].Domain != "api.test" || sites[0].Type != "proxy" {
+		t.Errorf("GetSites = %+v, want only the standalone api.test proxy", sites)
+	}
+
+	d.pruneSiteConfigs()
+	if _, ok := d.State.Data.SiteConfigs["shop.test"]; ok {
+		t.Error("config of the deleted shop project was kept")
+	}
+	if _, ok := d.State.Data.SiteConfigs["api.test"]; !ok {
+		t.Error("standalone proxy was pruned")
+	}
+}
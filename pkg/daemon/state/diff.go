@@ -0,0 +1,143 @@
+package state
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// ChangeKind classifies a difference between two states
+type ChangeKind string
+
+const (
+	ChangeSiteAdded         ChangeKind = "site_added"
+	ChangeSiteRemoved       ChangeKind = "site_removed"
+	ChangePathParked        ChangeKind = "path_parked"
+	ChangePathForgotten     ChangeKind = "path_forgotten"
+	ChangeSiteConfigChanged ChangeKind = "site_config_changed"
+	ChangePHPVersion        ChangeKind = "php_version_changed"
+	ChangeSecure            ChangeKind = "secure_changed"
+	ChangePort              ChangeKind = "port_changed"
+	ChangePlugins           ChangeKind = "plugins_changed"
+	ChangeIgnored           ChangeKind = "ignored_changed"
+)
+
+// Change describes a single difference. Key is the site name, path or domain
+// the change applies to; Old and New carry the values on either side.
+type Change struct {
+	Kind ChangeKind  `json:"kind"`
+	Key  string      `json:"key,omitempty"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Diff lists the changes needed to go from old to new, in a stable order
+func Diff(old, new *State) []Change {
+	var changes []Change
+
+	// Linked sites
+	for _, name := range sortedKeys(old.Links) {
+		if _, ok := new.Links[name]; !ok {
+			changes = append(changes, Change{Kind: ChangeSiteRemoved, Key: name, Old: old.Links[name]})
+		}
+	}
+	for _, name := range sortedKeys(new.Links) {
+		oldPath, ok := old.Links[name]
+		if !ok {
+			changes = append(changes, Change{Kind: ChangeSiteAdded, Key: name, New: new.Links[name]})
+		} else if oldPath != new.Links[name] {
+			// Re-pointed link: treat as remove + add so listeners rebuild the site
+			changes = append(changes,
+				Change{Kind: ChangeSiteRemoved, Key: name, Old: oldPath},
+				Change{Kind: ChangeSiteAdded, Key: name, New: new.Links[name]})
+		}
+	}
+
+	// Parked paths
+	for _, p := range missing(old.Paths, new.Paths) {
+		changes = append(changes, Change{Kind: ChangePathForgotten, Key: p})
+	}
+	for _, p := range missing(new.Paths, old.Paths) {
+		changes = append(changes, Change{Kind: ChangePathParked, Key: p})
+	}
+
+	// Per-site configuration
+	domains := map[string]bool{}
+	for d := range old.SiteConfigs {
+		domains[d] = true
+	}
+	for d := range new.SiteConfigs {
+		domains[d] = true
+	}
+	for _, domain := range sortedKeys(domains) {
+		oldConf, hadOld := old.SiteConfigs[domain]
+		newConf, hasNew := new.SiteConfigs[domain]
+		if hadOld == hasNew && reflect.DeepEqual(oldConf, newConf) {
+			continue
+		}
+		c := Change{Kind: ChangeSiteConfigChanged, Key: domain}
+		if hadOld {
+			c.Old = oldConf
+		}
+		if hasNew {
+			c.New = newConf
+		}
+		changes = append(changes, c)
+	}
+
+	// Scalars and lists
+	if old.PHPVersion != new.PHPVersion {
+		changes = append(changes, Change{Kind: ChangePHPVersion, Old: old.PHPVersion, New: new.PHPVersion})
+	}
+	if old.Secure != new.Secure {
+		changes = append(changes, Change{Kind: ChangeSecure, Old: old.Secure, New: new.Secure})
+	}
+	if old.Port != new.Port {
+		changes = append(changes, Change{Kind: ChangePort, Old: old.Port, New: new.Port})
+	}
+	if !sameSet(old.EnabledPlugins, new.EnabledPlugins) {
+		changes = append(changes, Change{Kind: ChangePlugins, Old: old.EnabledPlugins, New: new.EnabledPlugins})
+	}
+	if !sameSet(old.Ignored, new.Ignored) {
+		changes = append(changes, Change{Kind: ChangeIgnored, Old: old.Ignored, New: new.Ignored})
+	}
+
+	return changes
+}
+
+// clone returns a deep copy of the state
+func (s *State) clone() *State {
+	data, _ := json.Marshal(s)
+	c := &State{}
+	json.Unmarshal(data, c)
+	c.normalize()
+	return c
+}
+
+// missing returns the items of a that are not in b
+func missing(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, v := range b {
+		in[v] = true
+	}
+	var out []string
+	for _, v := range a {
+		if !in[v] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func sameSet(a, b []string) bool {
+	return len(missing(a, b)) == 0 && len(missing(b, a)) == 0
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,276 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// OpUndo marks journal entries written by Undo
+const OpUndo = "undo"
+
+// JournalEntry records one state mutation: who made it, when, and what changed
+type JournalEntry struct {
+	ID       string        `json:"id"`
+	Time     time.Time     `json:"time"`
+	User     string        `json:"user"`
+	Source   string        `json:"source"` // cli, daemon, ...
+	Op       string        `json:"op"`
+	Summary  string        `json:"summary"`
+	Changes  []FieldChange `json:"changes"`
+	Reverts  []string      `json:"reverts,omitempty"`  // IDs undone by an undo entry
+	Reverted bool          `json:"reverted,omitempty"` // Filled in by History
+}
+
+// FieldChange holds the before/after JSON of one state field.
+// Path is a top-level field ("paths") or a map entry ("links.blog").
+// A nil side means the entry did not exist.
+type FieldChange struct {
+	Path   string          `json:"path"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// keyedFields are diffed per key so undo only touches the affected entry
+var keyedFields = map[string]bool{"links": true, "site_configs": true, "services": true, "workspaces": true}
+
+// currentUser names the person behind a change, looking through sudo
+func currentUser() string {
+	if u := os.Getenv("SUDO_USER"); u != "" {
+		return u
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// fieldChanges compares two states field by field
+func fieldChanges(before, after *State) ([]FieldChange, error) {
+	b, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FieldChange
+	for _, field := range sortedKeys(unionKeys(b, a)) {
+		if field == "schema_version" {
+			continue
+		}
+		if !keyedFields[field] {
+			if string(b[field]) != string(a[field]) {
+				changes = append(changes, FieldChange{Path: field, Before: b[field], After: a[field]})
+			}
+			continue
+		}
+
+		var bm, am map[string]json.RawMessage
+		json.Unmarshal(b[field], &bm)
+		json.Unmarshal(a[field], &am)
+		for _, key := range sortedKeys(unionKeys(bm, am)) {
+			if string(bm[key]) != string(am[key]) {
+				changes = append(changes, FieldChange{Path: field + "." + key, Before: bm[key], After: am[key]})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// revert applies the Before side of changes to s, newest change last
+func revert(s *State, changes []FieldChange) error {
+	fields, err := toFields(s)
+	if err != nil {
+		return err
+	}
+
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		field, key, keyed := strings.Cut(c.Path, ".")
+		if !keyed {
+			if c.Before == nil {
+				delete(fields, field)
+			} else {
+				fields[field] = c.Before
+			}
+			continue
+		}
+
+		entries := map[string]json.RawMessage{}
+		json.Unmarshal(fields[field], &entries)
+		if c.Before == nil {
+			delete(entries, key)
+		} else {
+			entries[key] = c.Before
+		}
+		raw, err := json.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		fields[field] = raw
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	reverted := &State{}
+	if err := json.Unmarshal(data, reverted); err != nil {
+		return err
+	}
+	reverted.normalize()
+	*s = *reverted
+	return nil
+}
+
+func toFields(s *State) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+func unionKeys(a, b map[string]json.RawMessage) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
+
+// appendJournal writes one entry. Callers must hold the file lock.
+func (m *Manager) appendJournal(entry JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Share the state file's permissions so every process that may edit it can journal
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(m.filePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	f, err := os.OpenFile(m.journalPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, mode)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// readJournal returns all entries, oldest first
+func (m *Manager) readJournal() ([]JournalEntry, error) {
+	f, err := os.Open(m.journalPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // Skip a torn trailing line
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// History returns up to limit journal entries, newest first (limit <= 0 means all)
+func (m *Manager) History(limit int) ([]JournalEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries, err := m.readJournal()
+	if err != nil {
+		return nil, err
+	}
+	markReverted(entries)
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// Undo reverts the last n operations that have not been undone yet and
+// returns them, newest first. The undo itself is journaled.
+func (m *Manager) Undo(n int) ([]JournalEntry, error) {
+	if n < 1 {
+		n = 1
+	}
+
+	var undone []JournalEntry
+	err := m.mutate(OpUndo, func(s *State) (string, error) {
+		entries, err := m.readJournal()
+		if err != nil {
+			return "", err
+		}
+		markReverted(entries)
+
+		for i := len(entries) - 1; i >= 0 && len(undone) < n; i-- {
+			e := entries[i]
+			if e.Op == OpUndo || e.Reverted {
+				continue
+			}
+			if err := revert(s, e.Changes); err != nil {
+				return "", fmt.Errorf("failed to revert %s: %w", e.Summary, err)
+			}
+			undone = append(undone, e)
+		}
+		if len(undone) == 0 {
+			return "", fmt.Errorf("nothing to undo")
+		}
+
+		summaries := make([]string, len(undone))
+		for i, e := range undone {
+			summaries[i] = e.Summary
+		}
+		return "undo " + strings.Join(summaries, ", "), nil
+	}, func(entry *JournalEntry) {
+		for _, e := range undone {
+			entry.Reverts = append(entry.Reverts, e.ID)
+		}
+	})
+	return undone, err
+}
+
+// markReverted flags entries that a later undo reverted
+func markReverted(entries []JournalEntry) {
+	reverted := map[string]bool{}
+	for _, e := range entries {
+		for _, id := range e.Reverts {
+			reverted[id] = true
+		}
+	}
+	for i := range entries {
+		entries[i].Reverted = reverted[entries[i].ID]
+	}
+}
@@ -2,25 +2,35 @@ package state
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/fpm"
 )
 
-// State represents the persistent configuration of the SLD environment.
 // State represents the persistent configuration of the SLD environment.
 type State struct {
+	SchemaVersion  int                   `json:"schema_version"` // On-disk format version, see migrations.go
 	TLD            string                `json:"tld"`
 	Paths          []string              `json:"paths"`           // Parked paths
 	Links          map[string]string     `json:"links"`           // Linked projects (siteName -> path)
 	Services       map[string]string     `json:"services"`        // Service status/config
-	Certificates   []string              `json:"certificates"`    // Secured domains
+	Certificates   []string              `json:"certificates"`    // Domains with their own certificate in the certs dir
 	PHPVersion     string                `json:"php_version"`     // Default PHP version
 	Secure         bool                  `json:"secure"`          // Is global HTTPS enabled?
 	Port           string                `json:"port"`            // Main HTTP Port (default 80)
 	Ignored        []string              `json:"ignored"`         // Ignored project paths
 	EnabledPlugins []string              `json:"enabled_plugins"` // Plugins to auto-start
 	SiteConfigs    map[string]SiteConfig `json:"site_configs"`    // Site-specific configurations
+
+	// Paths, Links and SiteConfigs above are the live set of ActiveWorkspace.
+	// Other workspaces are stashed here until switched to.
+	Workspaces      map[string]Workspace `json:"workspaces"`
+	ActiveWorkspace string               `json:"active_workspace"`
 }
 
 // SiteConfig represents isolated configuration for a specific site
@@ -30,203 +40,539 @@ type SiteConfig struct {
 	NodeVersion string   `json:"node_version,omitempty"` // Node Version
 	Tags        []string `json:"tags,omitempty"`
 	Category    string   `json:"category,omitempty"`
+
+	// Serving options from .sld.yaml
+	Driver            string            `json:"driver,omitempty"`               // Framework driver (see pkg/drivers)
+	Aliases           []string          `json:"aliases,omitempty"`              // Extra domains for this site
+	Wildcard          bool              `json:"wildcard,omitempty"`             // Also serve *.<domain>
+	Plugins           []string          `json:"plugins,omitempty"`              // Plugins the site needs running
+	Env               map[string]string `json:"env,omitempty"`                  // Extra fastcgi_param values
+	Nginx             string            `json:"nginx,omitempty"`                // Raw nginx snippet for the server block
+	ClientMaxBodySize string            `json:"client_max_body_size,omitempty"` // e.g. "100M"
+	Index             string            `json:"index,omitempty"`                // Front controller / index file
+
+	Processes map[string]string `json:"processes,omitempty"` // Supervised workers (.sld.yaml or Procfile)
+	Supervise bool              `json:"supervise,omitempty"` // Start the workers with the daemon
+
+	Secure bool   `json:"secure,omitempty"` // Serve over HTTPS with the site's own certificate
+	Proxy  string `json:"proxy,omitempty"`  // Upstream URL to reverse-proxy to instead of PHP
+
+	// Standalone sites were registered with `sld proxy` and have no project
+	// directory; other configs come from a project and go away with it
+	Standalone bool `json:"standalone,omitempty"`
+
+	FPM *fpm.Settings `json:"fpm,omitempty"` // Options of the site's PHP-FPM pool from .sld.yaml
 }
 
-type Manager struct {
-	mu       sync.RWMutex
-	filePath string
-	Data     *State
+// NeedsServerBlock reports whether the site can't be served by the shared
+// wildcard block and needs its own isolated server block
+func (c SiteConfig) NeedsServerBlock() bool {
+	return c.PHPVersion != "" || c.Driver != "" || len(c.Aliases) > 0 || len(c.Env) > 0 ||
+		c.Nginx != "" || c.ClientMaxBodySize != "" || c.Index != "" || c.Secure || c.Proxy != "" || c.Wildcard || c.FPM != nil
 }
 
-// NewManager creates a new State Manager pointing to the global config path.
-func NewManager() (*Manager, error) {
-	// Global path for multi-user support
-	configDir := "/var/lib/sld"
+// Manager owns the state file. Every mutation re-reads the file under an
+// exclusive advisory lock shared by all SLD processes (CLI and daemon), applies
+// the change and atomically replaces the file, so concurrent writers never
+// drop each other's changes.
+type Manager struct {
+	mu          sync.RWMutex
+	filePath    string
+	lockPath    string
+	journalPath string
+	Data        *State // Replaced by setters and the watcher; concurrent readers use Snapshot
+
+	// Source tags journal entries with the process making changes (cli, daemon)
+	Source string
 
+	onChange func(changes []Change) // Set by Watch
+	pending  []Change               // External changes merged by a setter, not yet reported
+}
+
+// NewManager creates a new State Manager for the state.json inside configDir
+// (the SLD home).
+func NewManager(configDir string) (*Manager, error) {
 	// Ensure directory exists (usually created by installer, but good safety)
-	if err := os.MkdirAll(configDir, 0777); err != nil {
+	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return nil, err
 	}
 
+	filePath := filepath.Join(configDir, "state.json")
 	return &Manager{
-		filePath: filepath.Join(configDir, "state.json"),
-		Data: &State{
-			TLD:            "test",
-			Paths:          []string{},
-			Links:          make(map[string]string),
-			Services:       make(map[string]string),
-			Port:           "80", // Default port
-			Ignored:        []string{},
-			EnabledPlugins: []string{},
-			SiteConfigs:    make(map[string]SiteConfig),
-		},
+		filePath:    filePath,
+		lockPath:    filePath + ".lock",
+		journalPath: filepath.Join(configDir, "journal.log"),
+		Data:        defaultState(),
+		Source:      "cli",
 	}, nil
 }
 
+// defaultState returns the state used when no file exists yet
+func defaultState() *State {
+	return &State{
+		SchemaVersion:  CurrentSchemaVersion,
+		TLD:            "test",
+		Paths:          []string{},
+		Links:          make(map[string]string),
+		Services:       make(map[string]string),
+		Certificates:   []string{},
+		Port:           "80", // Default port
+		Ignored:        []string{},
+		EnabledPlugins: []string{},
+		SiteConfigs:    make(map[string]SiteConfig),
+		Workspaces: map[string]Workspace{
+			DefaultWorkspace: {},
+		},
+		ActiveWorkspace: DefaultWorkspace,
+	}
+}
+
+// normalize makes sure collections are never nil after decoding
+func (s *State) normalize() {
+	if s.Port == "" {
+		s.Port = "80"
+	}
+	if s.Paths == nil {
+		s.Paths = []string{}
+	}
+	if s.Links == nil {
+		s.Links = make(map[string]string)
+	}
+	if s.Services == nil {
+		s.Services = make(map[string]string)
+	}
+	if s.Certificates == nil {
+		s.Certificates = []string{}
+	}
+	if s.Ignored == nil {
+		s.Ignored = []string{}
+	}
+	if s.EnabledPlugins == nil {
+		s.EnabledPlugins = []string{}
+	}
+	if s.SiteConfigs == nil {
+		s.SiteConfigs = make(map[string]SiteConfig)
+	}
+	if s.ActiveWorkspace == "" {
+		s.ActiveWorkspace = DefaultWorkspace
+	}
+	if s.Workspaces == nil {
+		s.Workspaces = make(map[string]Workspace)
+	}
+	if _, ok := s.Workspaces[s.ActiveWorkspace]; !ok {
+		s.Workspaces[s.ActiveWorkspace] = Workspace{}
+	}
+}
+
+// FilePath returns the location of the state file on disk
+func (m *Manager) FilePath() string {
+	return m.filePath
+}
+
+// Snapshot returns a copy of the state taken under the lock. Code that can
+// run while the watcher reloads the file reads this instead of Data.
+func (m *Manager) Snapshot() *State {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.Data.clone()
+}
+
 // Load reads the state from disk.
 func (m *Manager) Load() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	data, err := os.ReadFile(m.filePath)
-	if os.IsNotExist(err) {
-		return m.Save() // Initialize new file
+	unlock, err := m.acquire()
+	if err != nil {
+		return err
 	}
+	defer unlock()
+
+	return m.load()
+}
+
+// Save writes the current in-memory state to disk.
+// Prefer the setters, which merge with whatever other processes wrote.
+func (m *Manager) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	unlock, err := m.acquire()
 	if err != nil {
 		return err
 	}
+	defer unlock()
+
+	return m.write()
+}
+
+// update runs a read-modify-write cycle under both the in-process and the
+// file lock, journaling the change as op with a human readable summary.
+func (m *Manager) update(op, summary string, fn func(s *State)) error {
+	return m.mutate(op, func(s *State) (string, error) {
+		fn(s)
+		return summary, nil
+	}, nil)
+}
+
+// mutate is update for callers that can fail or only know their summary
+// once they have seen the current state.
+func (m *Manager) mutate(op string, fn func(s *State) (string, error), decorate func(entry *JournalEntry)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	if err := json.Unmarshal(data, m.Data); err != nil {
+	unlock, err := m.acquire()
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	// Ensure default port if missing (e.g. old state file)
-	if m.Data.Port == "" {
-		m.Data.Port = "80"
+	// 1. Pick up changes made by other processes
+	external := m.Data.clone()
+	if err := m.load(); err != nil {
+		return err
+	}
+	if m.onChange != nil {
+		// Keep them for the watcher, which would otherwise see no difference
+		m.pending = append(m.pending, Diff(external, m.Data)...)
 	}
 
-	// Ensure Initialized slices
-	if m.Data.Paths == nil {
-		m.Data.Paths = []string{}
+	// 2. Apply on a copy so a failed mutation leaves the state untouched
+	before := m.Data.clone()
+	next := m.Data.clone()
+	summary, err := fn(next)
+	if err != nil {
+		return err
 	}
-	if m.Data.Ignored == nil {
-		m.Data.Ignored = []string{}
+
+	changes, err := fieldChanges(before, next)
+	if err != nil {
+		return err
 	}
-	if m.Data.EnabledPlugins == nil {
-		m.Data.EnabledPlugins = []string{}
+	if len(changes) == 0 && op != OpUndo {
+		return nil // Nothing to persist or journal
 	}
-	if m.Data.SiteConfigs == nil {
-		m.Data.SiteConfigs = make(map[string]SiteConfig)
+
+	// 3. Persist, then record who did what
+	*m.Data = *next
+	if err := m.write(); err != nil {
+		return err
 	}
 
+	entry := JournalEntry{
+		ID:      fmt.Sprintf("%d", time.Now().UnixNano()),
+		Time:    time.Now(),
+		User:    currentUser(),
+		Source:  m.Source,
+		Op:      op,
+		Summary: summary,
+		Changes: changes,
+	}
+	if decorate != nil {
+		decorate(&entry)
+	}
+	if err := m.appendJournal(entry); err != nil {
+		// The change itself is saved; a missing history line must not fail it
+		log.Printf("Warning: %v", err)
+	}
 	return nil
 }
 
-// Save writes the current state to disk.
-func (m *Manager) Save() error {
-	// Lock is assumed to be held by caller or we lock here if this is public
-	// but usually we want atomic operations. For simplicity, we lock.
-	// NOTE: In a real concurrent daemon, we might need finer grained locking.
+// acquire takes the cross-process lock. The lock lives in a sidecar file so
+// that it survives the state file being replaced by rename.
+func (m *Manager) acquire() (func(), error) {
+	f, err := os.OpenFile(m.lockPath, os.O_RDWR|os.O_CREATE, 0664)
+	if err != nil {
+		// Unprivileged users may only be able to read an existing lock file
+		f, err = os.Open(m.lockPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open state lock: %w", err)
+		}
+	}
 
-	// For now, let's just write.
-	data, err := json.MarshalIndent(m.Data, "", "  ")
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock state: %w", err)
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// load replaces m.Data with the file contents, migrating old schemas.
+// Callers must hold both locks.
+func (m *Manager) load() error {
+	data, err := os.ReadFile(m.filePath)
+	if os.IsNotExist(err) {
+		*m.Data = *defaultState()
+		return m.write() // Initialize new file
+	}
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(m.filePath, data, 0644)
+	// Decode into a fresh value so keys removed on disk don't linger in maps
+	fresh, migrated, err := decode(data)
+	if err != nil {
+		return fmt.Errorf("state file %s is corrupt: %w", m.filePath, err)
+	}
+	*m.Data = *fresh
+
+	if migrated {
+		return m.write()
+	}
+	return nil
 }
 
-func (m *Manager) AddPath(path string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// Decode parses a state document of any supported schema version,
+// e.g. one taken from an export archive.
+func Decode(data []byte) (*State, error) {
+	s, _, err := decode(data)
+	return s, err
+}
 
-	for _, p := range m.Data.Paths {
-		if p == path {
-			return
-		}
+func decode(data []byte) (*State, bool, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, false, err
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
 	}
-	m.Data.Paths = append(m.Data.Paths, path)
-	m.Save()
-}
 
-func (m *Manager) RemovePath(path string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	migrated, err := migrate(doc)
+	if err != nil {
+		return nil, false, err
+	}
 
-	newPaths := []string{}
-	for _, p := range m.Data.Paths {
-		if p != path {
-			newPaths = append(newPaths, p)
-		}
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s := &State{}
+	if err := json.Unmarshal(upgraded, s); err != nil {
+		return nil, false, err
 	}
-	m.Data.Paths = newPaths
-	m.Save()
+	s.normalize()
+	return s, migrated, nil
 }
 
-func (m *Manager) AddLink(name, path string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// write atomically replaces the state file with m.Data.
+// Callers must hold both locks.
+func (m *Manager) write() error {
+	m.Data.SchemaVersion = CurrentSchemaVersion
 
-	if m.Data.Links == nil {
-		m.Data.Links = make(map[string]string)
+	data, err := json.MarshalIndent(m.Data, "", "  ")
+	if err != nil {
+		return err
 	}
-	m.Data.Links[name] = path
-	m.Save()
+
+	// Keep whatever permissions the installer gave the file
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(m.filePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(m.filePath)
+	tmp, err := os.CreateTemp(dir, ".state-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp state file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, m.filePath); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+
+	// Persist the rename itself (best effort, not supported everywhere)
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
 }
 
-func (m *Manager) RemoveLink(name string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *Manager) AddPath(path string) error {
+	return m.update("park", "park "+path, func(s *State) {
+		for _, p := range s.Paths {
+			if p == path {
+				return
+			}
+		}
+		s.Paths = append(s.Paths, path)
+	})
+}
+
+func (m *Manager) RemovePath(path string) error {
+	return m.update("forget", "forget "+path, func(s *State) {
+		newPaths := []string{}
+		for _, p := range s.Paths {
+			if p != path {
+				newPaths = append(newPaths, p)
+			}
+		}
+		s.Paths = newPaths
+	})
+}
 
-	delete(m.Data.Links, name)
-	m.Save()
+func (m *Manager) AddLink(name, path string) error {
+	return m.update("link", fmt.Sprintf("link %s -> %s", name, path), func(s *State) {
+		s.Links[name] = path
+	})
 }
 
-func (m *Manager) AddIgnore(path string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *Manager) RemoveLink(name string) error {
+	return m.update("unlink", "unlink "+name, func(s *State) {
+		delete(s.Links, name)
+	})
+}
 
-	for _, p := range m.Data.Ignored {
-		if p == path {
-			return
+func (m *Manager) AddIgnore(path string) error {
+	return m.update("ignore", "ignore "+path, func(s *State) {
+		for _, p := range s.Ignored {
+			if p == path {
+				return
+			}
+		}
+		s.Ignored = append(s.Ignored, path)
+	})
+}
+
+func (m *Manager) RemoveIgnore(path string) error {
+	return m.update("unignore", "unignore "+path, func(s *State) {
+		newPaths := []string{}
+		for _, p := range s.Ignored {
+			if p != path {
+				newPaths = append(newPaths, p)
+			}
 		}
+		s.Ignored = newPaths
+	})
+}
+
+// Replace swaps the whole state, e.g. when importing an environment
+func (m *Manager) Replace(next *State) error {
+	return m.update("replace", "replace entire state", func(s *State) {
+		*s = *next.clone()
+	})
+}
+
+// SetPHPVersion changes the default PHP version
+func (m *Manager) SetPHPVersion(version string) error {
+	return m.update("php", "set default PHP to "+version, func(s *State) {
+		s.PHPVersion = version
+	})
+}
+
+// SetSecure toggles global HTTPS
+func (m *Manager) SetSecure(secure bool) error {
+	op := "unsecure"
+	if secure {
+		op = "secure"
 	}
-	m.Data.Ignored = append(m.Data.Ignored, path)
-	m.Save()
+	return m.update(op, op+" all sites", func(s *State) {
+		s.Secure = secure
+	})
 }
 
-func (m *Manager) RemoveIgnore(path string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// AddCertificate records that domain has its own certificate
+func (m *Manager) AddCertificate(domain string) error {
+	return m.update("cert.add", "issue certificate for "+domain, func(s *State) {
+		for _, d := range s.Certificates {
+			if d == domain {
+				return
+			}
+		}
+		s.Certificates = append(s.Certificates, domain)
+	})
+}
 
-	newPaths := []string{}
-	for _, p := range m.Data.Ignored {
-		if p != path {
-			newPaths = append(newPaths, p)
+// RemoveCertificate forgets the certificate of domain
+func (m *Manager) RemoveCertificate(domain string) error {
+	return m.update("cert.remove", "remove certificate for "+domain, func(s *State) {
+		kept := []string{}
+		for _, d := range s.Certificates {
+			if d != domain {
+				kept = append(kept, d)
+			}
+		}
+		s.Certificates = kept
+	})
+}
+
+// HasCertificate reports whether domain has its own certificate
+func (m *Manager) HasCertificate(domain string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, d := range m.Data.Certificates {
+		if d == domain {
+			return true
 		}
 	}
-	m.Data.Ignored = newPaths
-	m.Save()
+	return false
 }
 
 // Plugin Management
 
-func (m *Manager) SetPluginEnabled(id string, enabled bool) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
+func (m *Manager) SetPluginEnabled(id string, enabled bool) error {
+	op := "plugin.disable"
 	if enabled {
-		// Add if not already present
-		for _, p := range m.Data.EnabledPlugins {
-			if p == id {
-				return
+		op = "plugin.enable"
+	}
+	return m.update(op, op+" "+id, func(s *State) {
+		if enabled {
+			// Add if not already present
+			for _, p := range s.EnabledPlugins {
+				if p == id {
+					return
+				}
 			}
-		}
-		m.Data.EnabledPlugins = append(m.Data.EnabledPlugins, id)
-	} else {
-		// Remove from list
-		newPlugins := []string{}
-		for _, p := range m.Data.EnabledPlugins {
-			if p != id {
-				newPlugins = append(newPlugins, p)
+			s.EnabledPlugins = append(s.EnabledPlugins, id)
+		} else {
+			// Remove from list
+			newPlugins := []string{}
+			for _, p := range s.EnabledPlugins {
+				if p != id {
+					newPlugins = append(newPlugins, p)
+				}
 			}
+			s.EnabledPlugins = newPlugins
 		}
-		m.Data.EnabledPlugins = newPlugins
-	}
-	m.Save()
+	})
 }
 
 // SetSiteConfig updates configuration for a specific site
-func (m *Manager) SetSiteConfig(domain string, config SiteConfig) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *Manager) SetSiteConfig(domain string, config SiteConfig) error {
+	return m.update("site.config", "update site config for "+domain, func(s *State) {
+		s.SiteConfigs[domain] = config
+	})
+}
 
-	if m.Data.SiteConfigs == nil {
-		m.Data.SiteConfigs = make(map[string]SiteConfig)
-	}
-	m.Data.SiteConfigs[domain] = config
-	m.Save()
+// RemoveSiteConfig drops the isolated configuration for a site
+func (m *Manager) RemoveSiteConfig(domain string) error {
+	return m.update("site.config.remove", "remove site config for "+domain, func(s *State) {
+		delete(s.SiteConfigs, domain)
+	})
 }
 
 func (m *Manager) IsPluginEnabled(id string) bool {
@@ -0,0 +1,304 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentWritersKeepAllChanges(t *testing.T) {
+	dir := t.TempDir()
+
+	// Two managers model the CLI and the daemon holding the same file
+	cli, err := NewManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon, err := NewManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			cli.AddLink(fmt.Sprintf("cli-%d", i), "/tmp/cli")
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			daemon.AddPath(fmt.Sprintf("/srv/park-%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	check, _ := NewManager(dir)
+	if err := check.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(check.Data.Links) != 20 {
+		t.Errorf("expected 20 links, got %d", len(check.Data.Links))
+	}
+	if len(check.Data.Paths) != 20 {
+		t.Errorf("expected 20 paths, got %d", len(check.Data.Paths))
+	}
+
+	// No temp files should be left behind
+	leftovers, _ := filepath.Glob(filepath.Join(dir, ".state-*.tmp"))
+	if len(leftovers) != 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+}
+
+func TestLoadMigratesLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+	legacy := `{"services":{},"certificates":[],"php_version":"8.2","secure":false,"tld":"test","paths":["/srv"],"links":{"blog":"/srv/blog"}}`
+	if err := os.WriteFile(filepath.Join(dir, "state.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ := NewManager(dir)
+	if err := m.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Data.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("expected schema %d, got %d", CurrentSchemaVersion, m.Data.SchemaVersion)
+	}
+	if m.Data.Port != "80" || m.Data.SiteConfigs == nil || m.Data.Links["blog"] != "/srv/blog" {
+		t.Errorf("legacy state not upgraded correctly: %+v", m.Data)
+	}
+
+	// The upgrade is persisted
+	raw, _ := os.ReadFile(filepath.Join(dir, "state.json"))
+	var doc map[string]interface{}
+	json.Unmarshal(raw, &doc)
+	if schemaVersionOf(doc) != CurrentSchemaVersion {
+		t.Errorf("migrated schema was not written back")
+	}
+}
+
+func TestMigrateV3MarksStandaloneProxies(t *testing.T) {
+	dir := t.TempDir()
+	parked := filepath.Join(dir, "Sites")
+	if err := os.MkdirAll(filepath.Join(parked, "shop"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := fmt.Sprintf(`{"schema_version": 2, "tld": "test", "paths": [%q], "links": {"blog": "/srv/blog"},
+		"site_configs": {
+			"api.test": {"proxy": "http://127.0.0.1:3000"},
+			"blog.test": {"proxy": "http://127.0.0.1:4000"},
+			"shop.test": {"proxy": "http://127.0.0.1:5000"},
+			"docs.test": {"php_version": "8.2"}
+		}}`, parked)
+	if err := os.WriteFile(filepath.Join(dir, "state.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ := NewManager(dir)
+	if err := m.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for domain, want := range map[string]bool{"api.test": true, "blog.test": false, "shop.test": false, "docs.test": false} {
+		if got := m.Data.SiteConfigs[domain].Standalone; got != want {
+			t.Errorf("%s: Standalone = %v, want %v", domain, got, want)
+		}
+	}
+}
+
+func TestLoadRejectsNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	future := fmt.Sprintf(`{"schema_version": %d}`, CurrentSchemaVersion+1)
+	os.WriteFile(filepath.Join(dir, "state.json"), []byte(future), 0644)
+
+	m, _ := NewManager(dir)
+	if err := m.Load(); err == nil {
+		t.Fatal("expected an error for a newer schema")
+	}
+}
+
+func TestLoadDropsKeysRemovedOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	a, _ := NewManager(dir)
+	b, _ := NewManager(dir)
+	a.Load()
+	b.Load()
+
+	a.AddLink("blog", "/srv/blog")
+	b.Load()
+	a.RemoveLink("blog")
+	b.Load()
+
+	if _, ok := b.Data.Links["blog"]; ok {
+		t.Error("removed link survived a reload")
+	}
+}
+
+func TestWatchReportsExternalChanges(t *testing.T) {
+	dir := t.TempDir()
+	daemon, _ := NewManager(dir)
+	cli, _ := NewManager(dir)
+	daemon.Load()
+	cli.Load()
+
+	got := make(chan []Change, 4)
+	stop, err := daemon.Watch(func(changes []Change) { got <- changes })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	// Readers take snapshots while the watcher reloads (run with -race)
+	done := make(chan struct{})
+	reads := make(chan int)
+	go func() {
+		tick := time.NewTicker(time.Millisecond)
+		defer tick.Stop()
+		n := 0
+		for {
+			select {
+			case <-done:
+				reads <- n
+				return
+			case <-tick.C:
+				n += len(daemon.Snapshot().Links)
+			}
+		}
+	}()
+	defer func() {
+		close(done)
+		<-reads
+	}()
+
+	cli.AddLink("blog", "/srv/blog")
+	cli.SetPHPVersion("8.3")
+
+	seen := map[ChangeKind]bool{}
+	timeout := time.After(3 * time.Second)
+	for !(seen[ChangeSiteAdded] && seen[ChangePHPVersion]) {
+		select {
+		case changes := <-got:
+			for _, c := range changes {
+				seen[c.Kind] = true
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for changes, saw %v", seen)
+		}
+	}
+
+	snapshot := daemon.Snapshot()
+	if snapshot.Links["blog"] != "/srv/blog" {
+		t.Error("watcher did not reload the new link")
+	}
+	snapshot.Links["blog"] = "/srv/changed"
+	if daemon.Data.Links["blog"] != "/srv/blog" {
+		t.Error("changing a snapshot changed the state")
+	}
+}
+
+func TestUndoRevertsLastOperations(t *testing.T) {
+	dir := t.TempDir()
+	m, _ := NewManager(dir)
+	m.Load()
+
+	m.AddPath("/srv/work")
+	m.AddLink("blog", "/srv/blog")
+	m.SetSiteConfig("blog.test", SiteConfig{PHPVersion: "8.1"})
+	m.RemovePath("/srv/work") // The accidental forget
+
+	undone, err := m.Undo(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(undone) != 1 || undone[0].Op != "forget" {
+		t.Fatalf("expected to undo the forget, got %+v", undone)
+	}
+	if len(m.Data.Paths) != 1 || m.Data.Paths[0] != "/srv/work" {
+		t.Errorf("parked path not restored: %v", m.Data.Paths)
+	}
+
+	// The next undo skips the already reverted forget
+	if _, err := m.Undo(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Data.Links["blog"]; ok {
+		t.Error("link should have been undone")
+	}
+	if _, ok := m.Data.SiteConfigs["blog.test"]; ok {
+		t.Error("site config should have been undone")
+	}
+	if len(m.Data.Paths) != 1 {
+		t.Errorf("undo touched unrelated fields: %v", m.Data.Paths)
+	}
+
+	history, _ := m.History(0)
+	if history[0].Op != OpUndo || len(history[0].Reverts) != 2 {
+		t.Errorf("undo was not journaled: %+v", history[0])
+	}
+}
+
+func TestWorkspacesSwitchLiveSites(t *testing.T) {
+	m, _ := NewManager(t.TempDir())
+	m.Load()
+
+	m.AddLink("internal", "/srv/internal")
+	if err := m.CreateWorkspace("client", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UseWorkspace("client"); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Data.Links) != 0 || m.Data.ActiveWorkspace != "client" {
+		t.Fatalf("expected an empty client workspace, got %+v", m.Data.Links)
+	}
+
+	m.AddLink("acme", "/srv/acme")
+	if err := m.UseWorkspace(DefaultWorkspace); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Data.Links["internal"]; !ok || len(m.Data.Links) != 1 {
+		t.Errorf("default workspace not restored: %v", m.Data.Links)
+	}
+	if m.Data.Workspaces["client"].Links["acme"] != "/srv/acme" {
+		t.Errorf("client links were not stashed: %+v", m.Data.Workspaces["client"])
+	}
+
+	if err := m.DeleteWorkspace(DefaultWorkspace); err == nil {
+		t.Error("deleting the active workspace should fail")
+	}
+	if err := m.UseWorkspace("missing"); err == nil {
+		t.Error("switching to an unknown workspace should fail")
+	}
+}
+
+func TestCertificatesAreTrackedPerDomain(t *testing.T) {
+	m, _ := NewManager(t.TempDir())
+	m.Load()
+
+	m.AddCertificate("shop.test")
+	m.AddCertificate("shop.test")
+	m.AddCertificate("blog.test")
+	if len(m.Data.Certificates) != 2 || !m.HasCertificate("shop.test") {
+		t.Fatalf("unexpected certificates %v", m.Data.Certificates)
+	}
+
+	m.RemoveCertificate("shop.test")
+	if m.HasCertificate("shop.test") || !m.HasCertificate("blog.test") {
+		t.Errorf("unexpected certificates %v", m.Data.Certificates)
+	}
+
+	if !(SiteConfig{Secure: true}).NeedsServerBlock() {
+		t.Error("a secured site needs its own server block for its certificate")
+	}
+}
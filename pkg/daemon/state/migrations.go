@@ -0,0 +1,138 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CurrentSchemaVersion is the state file schema written by this build.
+// Bump it and append a migration whenever State or SiteConfig change shape.
+const CurrentSchemaVersion = 3
+
+// migration upgrades a raw state document from version-1 to version
+type migration struct {
+	version     int
+	description string
+	apply       func(doc map[string]interface{}) error
+}
+
+// migrations run in order against any file older than CurrentSchemaVersion
+var migrations = []migration{
+	{version: 1, description: "introduce schema_version and fill legacy defaults", apply: migrateV1},
+	{version: 2, description: "move existing sites into the default workspace", apply: migrateV2},
+	{version: 3, description: "mark proxy sites registered with sld proxy as standalone", apply: migrateV3},
+}
+
+// migrate brings doc up to CurrentSchemaVersion in place.
+// It reports whether anything was changed so the caller can persist the result.
+func migrate(doc map[string]interface{}) (bool, error) {
+	version := schemaVersionOf(doc)
+	if version > CurrentSchemaVersion {
+		return false, fmt.Errorf("state file schema %d is newer than supported schema %d, please upgrade sld", version, CurrentSchemaVersion)
+	}
+
+	changed := false
+	for _, mig := range migrations {
+		if mig.version <= version {
+			continue
+		}
+		if err := mig.apply(doc); err != nil {
+			return changed, fmt.Errorf("state migration to v%d (%s) failed: %w", mig.version, mig.description, err)
+		}
+		doc["schema_version"] = mig.version
+		version = mig.version
+		changed = true
+	}
+	return changed, nil
+}
+
+// schemaVersionOf reads schema_version from a raw document, treating a missing field as 0
+func schemaVersionOf(doc map[string]interface{}) int {
+	switch v := doc["schema_version"].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// migrateV1 handles files written before versioning existed
+func migrateV1(doc map[string]interface{}) error {
+	if s, _ := doc["tld"].(string); s == "" {
+		doc["tld"] = "test"
+	}
+	if s, _ := doc["port"].(string); s == "" {
+		doc["port"] = "80"
+	}
+	for _, key := range []string{"paths", "certificates", "ignored", "enabled_plugins"} {
+		if _, ok := doc[key].([]interface{}); !ok {
+			doc[key] = []interface{}{}
+		}
+	}
+	for _, key := range []string{"links", "services", "site_configs"} {
+		if _, ok := doc[key].(map[string]interface{}); !ok {
+			doc[key] = map[string]interface{}{}
+		}
+	}
+	return nil
+}
+
+// migrateV2 introduces workspaces; the existing sites become the default one
+func migrateV2(doc map[string]interface{}) error {
+	if _, ok := doc["workspaces"].(map[string]interface{}); !ok {
+		doc["workspaces"] = map[string]interface{}{DefaultWorkspace: map[string]interface{}{}}
+	}
+	if s, _ := doc["active_workspace"].(string); s == "" {
+		doc["active_workspace"] = DefaultWorkspace
+	}
+	return nil
+}
+
+// migrateV3 flags the proxy sites `sld proxy` registered, which used to be
+// told apart from project configs only by having no project directory
+func migrateV3(doc map[string]interface{}) error {
+	tld, _ := doc["tld"].(string)
+	markStandalone(doc, tld)
+	if workspaces, ok := doc["workspaces"].(map[string]interface{}); ok {
+		for _, ws := range workspaces {
+			if ws, ok := ws.(map[string]interface{}); ok {
+				markStandalone(ws, tld)
+			}
+		}
+	}
+	return nil
+}
+
+// markStandalone flags the proxy configs in set (the live state or a
+// workspace) that no link or parked directory backs
+func markStandalone(set map[string]interface{}, tld string) {
+	configs, _ := set["site_configs"].(map[string]interface{})
+	links, _ := set["links"].(map[string]interface{})
+	paths, _ := set["paths"].([]interface{})
+
+	for domain, conf := range configs {
+		conf, ok := conf.(map[string]interface{})
+		if proxy, _ := conf["proxy"].(string); !ok || proxy == "" {
+			continue
+		}
+		name := strings.TrimSuffix(domain, "."+tld)
+		if _, linked := links[name]; linked {
+			continue
+		}
+		parked := false
+		for _, p := range paths {
+			if p, ok := p.(string); ok {
+				if _, err := os.Stat(filepath.Join(p, name)); err == nil {
+					parked = true
+					break
+				}
+			}
+		}
+		if !parked {
+			conf["standalone"] = true
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package state
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce coalesces the burst of events a single atomic save produces
+const reloadDebounce = 150 * time.Millisecond
+
+// Watch reloads the state whenever the file changes on disk and calls
+// onChange with the differences. Changes made through this Manager's own
+// setters produce no notification, but external changes that a setter picked
+// up while merging are still reported. Call the returned function to stop.
+func (m *Manager) Watch(onChange func(changes []Change)) (func(), error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// Watch the directory: saves replace the file, which would drop a file watch
+	if err := w.Add(filepath.Dir(m.filePath)); err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.onChange = onChange
+	m.mu.Unlock()
+
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) != m.filePath {
+					continue
+				}
+				if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Rename) {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDebounce, m.reload)
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("State watcher error: %v", err)
+			}
+		}
+	}()
+
+	return func() {
+		w.Close()
+		m.mu.Lock()
+		m.onChange = nil
+		m.pending = nil
+		m.mu.Unlock()
+	}, nil
+}
+
+// reload re-reads the file and notifies the watcher callback of any changes
+func (m *Manager) reload() {
+	m.mu.Lock()
+	old := m.Data.clone()
+
+	unlock, err := m.acquire()
+	if err == nil {
+		err = m.load()
+		unlock()
+	}
+
+	changes := append(m.pending, Diff(old, m.Data)...)
+	m.pending = nil
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	if err != nil {
+		log.Printf("State reload failed: %v", err)
+		return
+	}
+	if len(changes) > 0 && onChange != nil {
+		onChange(changes)
+	}
+}
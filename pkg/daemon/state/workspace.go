@@ -0,0 +1,119 @@
+package state
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// DefaultWorkspace holds the sites that existed before workspaces
+const DefaultWorkspace = "default"
+
+var workspaceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// Workspace is a named set of parked paths, links and site configs.
+// Only one workspace is live at a time.
+type Workspace struct {
+	Paths       []string              `json:"paths"`
+	Links       map[string]string     `json:"links"`
+	SiteConfigs map[string]SiteConfig `json:"site_configs"`
+}
+
+// WorkspaceInfo summarises a workspace for listings
+type WorkspaceInfo struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+	Paths  int    `json:"paths"`
+	Links  int    `json:"links"`
+}
+
+// CreateWorkspace adds an empty workspace, or a copy of the active one
+func (m *Manager) CreateWorkspace(name string, copyActive bool) error {
+	if !workspaceNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid workspace name %q (use letters, digits, - and _)", name)
+	}
+
+	return m.mutate("workspace.create", func(s *State) (string, error) {
+		if _, exists := s.Workspaces[name]; exists {
+			return "", fmt.Errorf("workspace %s already exists", name)
+		}
+
+		ws := Workspace{}
+		if copyActive {
+			ws = s.liveWorkspace()
+		}
+		s.Workspaces[name] = ws
+		return "create workspace " + name, nil
+	}, nil)
+}
+
+// UseWorkspace stashes the live sites and makes name the active workspace
+func (m *Manager) UseWorkspace(name string) error {
+	return m.mutate("workspace.use", func(s *State) (string, error) {
+		target, exists := s.Workspaces[name]
+		if !exists {
+			return "", fmt.Errorf("workspace %s does not exist", name)
+		}
+		if s.ActiveWorkspace == name {
+			return "", nil
+		}
+
+		previous := s.ActiveWorkspace
+		s.Workspaces[previous] = s.liveWorkspace()
+
+		s.Paths = target.Paths
+		s.Links = target.Links
+		s.SiteConfigs = target.SiteConfigs
+		s.ActiveWorkspace = name
+		s.normalize()
+
+		// The live fields are authoritative for the active workspace
+		s.Workspaces[name] = Workspace{}
+		return fmt.Sprintf("switch workspace %s -> %s", previous, name), nil
+	}, nil)
+}
+
+// DeleteWorkspace removes an inactive workspace
+func (m *Manager) DeleteWorkspace(name string) error {
+	return m.mutate("workspace.delete", func(s *State) (string, error) {
+		if _, exists := s.Workspaces[name]; !exists {
+			return "", fmt.Errorf("workspace %s does not exist", name)
+		}
+		if s.ActiveWorkspace == name {
+			return "", fmt.Errorf("cannot delete the active workspace, switch to another one first")
+		}
+		delete(s.Workspaces, name)
+		return "delete workspace " + name, nil
+	}, nil)
+}
+
+// ListWorkspaces returns all workspaces sorted by name
+func (m *Manager) ListWorkspaces() []WorkspaceInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var list []WorkspaceInfo
+	for name, ws := range m.Data.Workspaces {
+		if name == m.Data.ActiveWorkspace {
+			ws = m.Data.liveWorkspace()
+		}
+		list = append(list, WorkspaceInfo{
+			Name:   name,
+			Active: name == m.Data.ActiveWorkspace,
+			Paths:  len(ws.Paths),
+			Links:  len(ws.Links),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// liveWorkspace copies the active sites out of the top-level fields
+func (s *State) liveWorkspace() Workspace {
+	c := s.clone()
+	return Workspace{
+		Paths:       c.Paths,
+		Links:       c.Links,
+		SiteConfigs: c.SiteConfigs,
+	}
+}
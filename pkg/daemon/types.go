@@ -1,5 +1,7 @@
 package daemon
 
+import "github.com/supreme-majesty/supreme-local-dev/pkg/services"
+
 // Site represents a project/site served by SLD
 type Site struct {
 	Name       string   `json:"name"`
@@ -7,8 +9,32 @@ type Site struct {
 	Domain     string   `json:"domain"`
 	PHPVersion string   `json:"phpVersion,omitempty"`
 	Secure     bool     `json:"secure"`
-	Type       string   `json:"type"`     // "parked" or "linked"
+	Type       string   `json:"type"`     // "parked", "linked" or "proxy"
 	Creating   bool     `json:"creating"` // true if project is still being created
 	Tags       []string `json:"tags,omitempty"`
 	Category   string   `json:"category,omitempty"`
+	Driver     string   `json:"driver,omitempty"` // Framework driver, e.g. "laravel"
+	Proxy      string   `json:"proxy,omitempty"`  // Upstream the site is reverse-proxied to
+
+	Processes []services.ProcessStatus `json:"processes,omitempty"` // Supervised workers, see `sld proc`
+}
+
+// RuntimeResolution explains which PHP or Node.js version a site resolved to
+type RuntimeResolution struct {
+	Runtime    string   `json:"runtime"` // php or node
+	Constraint string   `json:"constraint"`
+	Source     string   `json:"source,omitempty"` // File the constraint came from
+	Installed  []string `json:"installed"`
+	Satisfying []string `json:"satisfying"`
+	Chosen     string   `json:"chosen"`   // Version the site runs on
+	Isolated   bool     `json:"isolated"` // PHP only: differs from the global version
+	Reason     string   `json:"reason"`
+}
+
+// SiteInfo describes how a site is served
+type SiteInfo struct {
+	Site
+	WebRoot string            `json:"web_root"`
+	PHP     RuntimeResolution `json:"php"`
+	Node    RuntimeResolution `json:"node"`
 }
@@ -0,0 +1,24 @@
+package daemon
+
+import (
+	"github.com/supreme-majesty/supreme-local-dev/pkg/project"
+)
+
+// ValidateProject checks a project's .sld.yaml against the schema and
+// against what is installed on this machine
+func (d *Daemon) ValidateProject(path string) (*project.ValidationResult, error) {
+	opts := project.ValidateOptions{}
+	if versions, err := d.Adapter.ListPHPVersions(); err == nil {
+		opts.PHPVersions = versions
+	}
+	if versions, err := d.Adapter.ListNodeVersions(); err == nil {
+		opts.NodeVersions = versions
+	}
+	if d.PluginManager != nil {
+		opts.Plugins = []string{}
+		for _, p := range d.PluginManager.GetAll() {
+			opts.Plugins = append(opts.Plugins, p.ID())
+		}
+	}
+	return project.Validate(path, opts)
+}
@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/project"
+)
+
+// ResolvePHP picks the PHP version for a project's constraint. The global
+// version wins when it satisfies the constraint, since the site then needs
+// no isolated server block; otherwise the newest satisfying version is used.
+func (d *Daemon) ResolvePHP(req project.Requirement) RuntimeResolution {
+	global := d.State.Snapshot().PHPVersion
+	res := RuntimeResolution{Runtime: project.RuntimePHP, Constraint: req.Constraint, Source: req.Source, Chosen: global}
+
+	if req.Constraint == "" {
+		res.Reason = fmt.Sprintf("no PHP constraint, using the global PHP %s", global)
+		return res
+	}
+
+	installed, err := d.Adapter.ListPHPVersions()
+	if err != nil {
+		res.Reason = fmt.Sprintf("could not list installed PHP versions (%v), using the global PHP %s", err, global)
+		return res
+	}
+	res.Installed = installed
+
+	res.Satisfying, err = req.Match(installed)
+	if err != nil {
+		res.Reason = fmt.Sprintf("could not parse %q (%v), using the global PHP %s", req.Constraint, err, global)
+		return res
+	}
+
+	switch {
+	case contains(res.Satisfying, global):
+		res.Reason = fmt.Sprintf("the global PHP %s satisfies %s", global, req.Constraint)
+	case len(res.Satisfying) > 0:
+		res.Chosen = res.Satisfying[0]
+		res.Isolated = true
+		res.Reason = fmt.Sprintf("PHP %s is the newest installed version satisfying %s (global is %s)", res.Chosen, req.Constraint, global)
+	default:
+		res.Reason = fmt.Sprintf("no installed PHP satisfies %s, falling back to the global PHP %s", req.Constraint, global)
+	}
+	return res
+}
+
+// ResolveNode picks the newest installed Node.js release matching the
+// project's version. Aliases such as lts/iron are left to fnm.
+func (d *Daemon) ResolveNode(req project.Requirement) RuntimeResolution {
+	res := RuntimeResolution{Runtime: project.RuntimeNode, Constraint: req.Constraint, Source: req.Source}
+
+	if req.Constraint == "" {
+		res.Reason = "no Node.js version requested, using the system default"
+		return res
+	}
+	if req.IsAlias() {
+		res.Chosen = req.Constraint
+		res.Reason = fmt.Sprintf("%s is a release alias, resolved by fnm", req.Constraint)
+		return res
+	}
+
+	installed, err := d.Adapter.ListNodeVersions()
+	if err != nil {
+		res.Reason = fmt.Sprintf("could not list installed Node.js versions: %v", err)
+		return res
+	}
+	res.Installed = installed
+
+	res.Satisfying, err = req.Match(installed)
+	if err != nil {
+		res.Reason = fmt.Sprintf("could not parse %q: %v", req.Constraint, err)
+		return res
+	}
+
+	if len(res.Satisfying) > 0 {
+		res.Chosen = res.Satisfying[0]
+		res.Reason = fmt.Sprintf("Node.js %s is the newest installed version satisfying %s", res.Chosen, req.Constraint)
+	} else {
+		res.Reason = fmt.Sprintf("no installed Node.js satisfies %s, sld will install %s", req.Constraint, nodeInstallTarget(req.Constraint))
+	}
+	return res
+}
+
+var firstVersion = regexp.MustCompile(`\d+(\.\d+){0,2}`)
+
+// nodeInstallTarget turns a range into something fnm can install: the
+// lowest version line it names ("^18.17" -> "18", ">=20 <22" -> "20").
+// Exact versions are kept as-is.
+func nodeInstallTarget(constraint string) string {
+	c := strings.TrimPrefix(strings.TrimSpace(constraint), "v")
+	if firstVersion.FindString(c) == c {
+		return c
+	}
+	major, _, _ := strings.Cut(firstVersion.FindString(c), ".")
+	return major
+}
+
+// resolvePHPVersion returns the version to isolate a site on, or "" to use the global one
+func (d *Daemon) resolvePHPVersion(req project.Requirement) string {
+	res := d.ResolvePHP(req)
+	if !res.Isolated {
+		if req.Constraint != "" && len(res.Satisfying) == 0 {
+			fmt.Printf("Warning: %s\n", res.Reason)
+		}
+		return ""
+	}
+	return res.Chosen
+}
+
+// SiteInfo explains how a site is served, including why its PHP and Node versions were chosen
+func (d *Daemon) SiteInfo(name string) (*SiteInfo, error) {
+	s, err := d.findSite(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &SiteInfo{Site: s}
+	conf, err := project.Detect(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	info.WebRoot = filepath.Join(s.Path, conf.Public)
+	if info.Driver == "" {
+		info.Driver = conf.Driver
+	}
+	info.PHP = d.ResolvePHP(conf.PHPRequirement())
+	info.Node = d.ResolveNode(conf.NodeRequirement())
+	return info, nil
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
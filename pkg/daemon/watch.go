@@ -0,0 +1,64 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+)
+
+// WatchState keeps the daemon in sync with changes other SLD processes make
+// to the state file (e.g. `sld park` or `sld link` from a terminal).
+func (d *Daemon) WatchState() error {
+	stop, err := d.State.Watch(d.applyStateChanges)
+	if err != nil {
+		return fmt.Errorf("failed to watch state: %w", err)
+	}
+	d.stopWatch = stop
+	return nil
+}
+
+// StopWatchingState stops following the state file
+func (d *Daemon) StopWatchingState() {
+	if d.stopWatch != nil {
+		d.stopWatch()
+		d.stopWatch = nil
+	}
+}
+
+// applyStateChanges publishes fine-grained events and rebuilds nginx
+func (d *Daemon) applyStateChanges(changes []state.Change) {
+	needsNginx := false
+
+	for _, c := range changes {
+		switch c.Kind {
+		case state.ChangeSiteAdded:
+			d.Events.Publish(events.Event{Type: events.SiteAdded, Payload: c})
+		case state.ChangeSiteRemoved:
+			d.Events.Publish(events.Event{Type: events.SiteRemoved, Payload: c})
+		case state.ChangePathParked:
+			d.Events.Publish(events.Event{Type: events.ProjectParked, Payload: c})
+		case state.ChangePathForgotten:
+			d.Events.Publish(events.Event{Type: events.Projectforgotten, Payload: c})
+		case state.ChangeSiteConfigChanged:
+			d.Events.Publish(events.Event{Type: events.SiteConfigChanged, Payload: c})
+		case state.ChangePHPVersion:
+			d.Events.Publish(events.Event{Type: events.PHPVersionChanged, Payload: c})
+		}
+
+		// Ignored paths only affect the site listing
+		if c.Kind != state.ChangeIgnored {
+			needsNginx = true
+		}
+	}
+
+	d.Events.Publish(events.Event{Type: events.StateChanged, Payload: changes})
+	d.Events.Publish(events.Event{Type: events.SitesUpdated})
+
+	if needsNginx {
+		fmt.Printf("State changed on disk (%d changes), regenerating nginx...\n", len(changes))
+		if err := d.refreshNginxConfig(); err != nil {
+			fmt.Printf("Warning: Failed to regenerate nginx after state change: %v\n", err)
+		}
+	}
+}
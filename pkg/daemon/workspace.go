@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+)
+
+// ListWorkspaces returns all workspaces and marks the active one
+func (d *Daemon) ListWorkspaces() []state.WorkspaceInfo {
+	return d.State.ListWorkspaces()
+}
+
+// CreateWorkspace adds a workspace, optionally seeded with the active sites
+func (d *Daemon) CreateWorkspace(name string, copyActive bool) error {
+	return d.State.CreateWorkspace(name, copyActive)
+}
+
+// DeleteWorkspace removes an inactive workspace
+func (d *Daemon) DeleteWorkspace(name string) error {
+	return d.State.DeleteWorkspace(name)
+}
+
+// UseWorkspace makes name the live set of sites and regenerates nginx,
+// hosts and certificates for just that set.
+func (d *Daemon) UseWorkspace(name string) error {
+	if err := d.State.UseWorkspace(name); err != nil {
+		return err
+	}
+
+	d.Events.Publish(events.Event{Type: events.SitesUpdated})
+
+	if err := d.syncHosts(); err != nil {
+		fmt.Printf("Warning: Failed to sync hosts: %v\n", err)
+	}
+
+	return d.ensureCerts()
+}
@@ -0,0 +1,113 @@
+package dns
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// resolvConfs lists where upstream servers are read from, most specific
+// first. systemd-resolved keeps the real upstreams out of /etc/resolv.conf,
+// which only names its stub.
+var resolvConfs = []string{"/run/systemd/resolve/resolv.conf", "/etc/resolv.conf"}
+
+// stubs are systemd-resolved listeners. Forwarding to them would loop back
+// here when resolved routes the TLD to this server.
+var stubs = []string{"127.0.0.53", "127.0.0.54"}
+
+// SystemUpstreams returns the nameservers the system uses, skipping
+// systemd-resolved's stub and self (the server's own address)
+func SystemUpstreams(self string) []string {
+	for _, path := range resolvConfs {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if servers := parseResolvConf(string(data), self); len(servers) > 0 {
+			return servers
+		}
+	}
+	return nil
+}
+
+// parseResolvConf returns the nameserver lines as host:port
+func parseResolvConf(data, self string) []string {
+	var servers []string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		ip := fields[1]
+		if contains(stubs, ip) {
+			continue
+		}
+		addr := net.JoinHostPort(ip, "53")
+		if addr == self {
+			continue
+		}
+		servers = append(servers, addr)
+	}
+	return servers
+}
+
+// Lookup asks the server at addr for the A or AAAA records of name
+func Lookup(addr, name string, qtype dnsmessage.Type, timeout time.Duration) ([]net.IP, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+	id := uint16(rand.Uint32())
+	query, err := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := exchange("udp", addr, query, timeout)
+	if err != nil {
+		return nil, err
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(resp); err != nil {
+		return nil, err
+	}
+	if msg.ID != id {
+		return nil, fmt.Errorf("mismatched response from %s", addr)
+	}
+	if msg.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("%s answered %s for %s", addr, msg.RCode, strings.TrimSuffix(name, "."))
+	}
+
+	var ips []net.IP
+	for _, a := range msg.Answers {
+		switch r := a.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, net.IP(r.A[:]))
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, net.IP(r.AAAA[:]))
+		}
+	}
+	return ips, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
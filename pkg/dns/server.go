@@ -0,0 +1,275 @@
+// Package dns is the resolver behind site domains: every name under the TLD
+// resolves to loopback, so wildcard and tenant subdomains work without
+// touching /etc/hosts, and every other query is forwarded upstream.
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ttl keeps answers short-lived so a TLD change is picked up quickly
+const ttl = 60
+
+// Server answers DNS over UDP and TCP
+type Server struct {
+	TLD       string        // e.g. "test"; the apex and all subdomains are local
+	Upstreams []string      // host:port, tried in order for everything else
+	Timeout   time.Duration // Per upstream, defaults to 2s
+
+	mu  sync.Mutex
+	udp net.PacketConn
+	tcp net.Listener
+}
+
+// ListenAndServe binds addr on UDP and TCP and serves in the background
+func (s *Server) ListenAndServe(addr string) error {
+	udp, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	// A port of 0 picks one for UDP; TCP shares it
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		udp.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	s.udp, s.tcp = udp, tcp
+	s.mu.Unlock()
+
+	go s.serveUDP(udp)
+	go s.serveTCP(tcp)
+	return nil
+}
+
+// Addr is the bound address, empty before ListenAndServe
+func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.udp == nil {
+		return ""
+	}
+	return s.udp.LocalAddr().String()
+}
+
+// Close stops both listeners
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	if s.udp != nil {
+		errs = append(errs, s.udp.Close())
+	}
+	if s.tcp != nil {
+		errs = append(errs, s.tcp.Close())
+	}
+	s.udp, s.tcp = nil, nil
+	return errors.Join(errs...)
+}
+
+func (s *Server) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, 65535)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		query := append([]byte(nil), buf[:n]...)
+		go func() {
+			if resp, err := s.Handle(query, "udp"); err == nil {
+				conn.WriteTo(resp, from)
+			}
+		}()
+	}
+}
+
+func (s *Server) serveTCP(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn answers length-prefixed messages until the client goes quiet
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	for {
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		query, err := readTCP(conn)
+		if err != nil {
+			return
+		}
+		resp, err := s.Handle(query, "tcp")
+		if err != nil || writeTCP(conn, resp) != nil {
+			return
+		}
+	}
+}
+
+// Handle answers one query. Names under the TLD are answered locally, the
+// rest go upstream over the network the query came in on. Unparseable
+// queries return an error and are dropped.
+func (s *Server) Handle(query []byte, network string) ([]byte, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	q, err := p.Question()
+	if err != nil {
+		return reply(h, nil, dnsmessage.RCodeFormatError)
+	}
+	if s.IsLocal(q.Name.String()) {
+		return s.answer(h, q)
+	}
+	if resp, err := s.forward(query, network); err == nil {
+		return resp, nil
+	}
+	return reply(h, &q, dnsmessage.RCodeServerFailure)
+}
+
+// IsLocal reports whether name is the TLD or under it
+func (s *Server) IsLocal(name string) bool {
+	tld := strings.Trim(strings.ToLower(s.TLD), ".")
+	if tld == "" {
+		return false
+	}
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	return name == tld || strings.HasSuffix(name, "."+tld)
+}
+
+// answer resolves a local name to loopback. Other record types get an
+// empty answer so clients don't fall back to a search domain.
+func (s *Server) answer(h dnsmessage.Header, q dnsmessage.Question) ([]byte, error) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 h.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   h.RecursionDesired,
+		RecursionAvailable: true,
+	})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(q); err != nil {
+		return nil, err
+	}
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+
+	rh := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: ttl}
+	if q.Type == dnsmessage.TypeA || q.Type == dnsmessage.TypeALL {
+		if err := b.AResource(rh, dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}}); err != nil {
+			return nil, err
+		}
+	}
+	if q.Type == dnsmessage.TypeAAAA || q.Type == dnsmessage.TypeALL {
+		if err := b.AAAAResource(rh, dnsmessage.AAAAResource{AAAA: [16]byte(net.IPv6loopback)}); err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+// forward relays the raw query to the first upstream that answers
+func (s *Server) forward(query []byte, network string) ([]byte, error) {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+
+	err := errors.New("no upstream DNS servers")
+	for _, upstream := range s.Upstreams {
+		var resp []byte
+		if resp, err = exchange(network, upstream, query, timeout); err == nil {
+			return resp, nil
+		}
+	}
+	return nil, err
+}
+
+// exchange sends one message and waits for the reply
+func exchange(network, addr string, msg []byte, timeout time.Duration) ([]byte, error) {
+	conn, err := net.DialTimeout(network, addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if network == "tcp" {
+		if err := writeTCP(conn, msg); err != nil {
+			return nil, err
+		}
+		return readTCP(conn)
+	}
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// reply is a response without answers, e.g. SERVFAIL
+func reply(h dnsmessage.Header, q *dnsmessage.Question, rcode dnsmessage.RCode) ([]byte, error) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 h.ID,
+		Response:           true,
+		RecursionDesired:   h.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              rcode,
+	})
+	if q != nil {
+		if err := b.StartQuestions(); err != nil {
+			return nil, err
+		}
+		if err := b.Question(*q); err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+func readTCP(r io.Reader) ([]byte, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func writeTCP(w io.Writer, msg []byte) error {
+	buf := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	copy(buf[2:], msg)
+	_, err := w.Write(buf)
+	return err
+}
@@ -0,0 +1,107 @@
+package dns
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func startServer(t *testing.T, s *Server) string {
+	t.Helper()
+	if err := s.ListenAndServe("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s.Addr()
+}
+
+func TestAnswersSiteDomainsWithLoopback(t *testing.T) {
+	addr := startServer(t, &Server{TLD: "test"})
+
+	tests := []struct {
+		name  string
+		qtype dnsmessage.Type
+		want  string
+	}{
+		{"app.test", dnsmessage.TypeA, "127.0.0.1"},
+		{"acme.app.test", dnsmessage.TypeA, "127.0.0.1"},
+		{"ACME.App.Test", dnsmessage.TypeAAAA, "::1"},
+		{"test", dnsmessage.TypeA, "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		ips, err := Lookup(addr, tt.name, tt.qtype, time.Second)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(ips) != 1 || !ips[0].Equal(net.ParseIP(tt.want)) {
+			t.Errorf("%s %s = %v, want %s", tt.name, tt.qtype, ips, tt.want)
+		}
+	}
+
+	// Other record types exist but are empty
+	if ips, err := Lookup(addr, "app.test", dnsmessage.TypeMX, time.Second); err != nil || len(ips) != 0 {
+		t.Errorf("MX app.test = %v, %v; want an empty answer", ips, err)
+	}
+}
+
+func TestForwardsOtherNames(t *testing.T) {
+	// The upstream claims .com, so its answers are recognisable
+	upstream := startServer(t, &Server{TLD: "com"})
+	addr := startServer(t, &Server{TLD: "test", Upstreams: []string{"127.0.0.1:1", upstream}, Timeout: 200 * time.Millisecond})
+
+	ips, err := Lookup(addr, "example.com", dnsmessage.TypeA, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 1 || !ips[0].Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("example.com = %v, want the upstream's answer", ips)
+	}
+}
+
+func TestServerFailureWithoutUpstream(t *testing.T) {
+	addr := startServer(t, &Server{TLD: "test"})
+	if _, err := Lookup(addr, "example.com", dnsmessage.TypeA, time.Second); err == nil {
+		t.Error("expected SERVFAIL without upstreams")
+	}
+}
+
+func TestForwardsOverTCP(t *testing.T) {
+	upstream := startServer(t, &Server{TLD: "com"})
+	s := &Server{TLD: "test", Upstreams: []string{upstream}}
+
+	name, _ := dnsmessage.NewName("example.com.")
+	query, _ := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: 7},
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	resp, err := s.Handle(query, "tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(resp); err != nil {
+		t.Fatal(err)
+	}
+	if msg.ID != 7 || len(msg.Answers) != 1 {
+		t.Errorf("got %+v, want one forwarded answer", msg)
+	}
+}
+
+func TestParseResolvConf(t *testing.T) {
+	conf := `# Generated by resolvconf
+nameserver 127.0.0.53
+nameserver 192.168.1.1
+nameserver 2001:db8::1
+nameserver 127.0.0.1
+options edns0
+search lan
+`
+	got := parseResolvConf(conf, "127.0.0.1:53")
+	want := []string{"192.168.1.1:53", "[2001:db8::1]:53"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
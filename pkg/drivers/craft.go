@@ -0,0 +1,22 @@
+package drivers
+
+// Craft CMS serves web/index.php
+type Craft struct{}
+
+func (d *Craft) Name() string { return "craft" }
+
+func (d *Craft) Detect(path string) bool {
+	return exists(path, "craft") && exists(path, "web", "index.php")
+}
+
+func (d *Craft) WebRoot(path string) string { return "web" }
+
+func (d *Craft) FrontController() string { return "index.php" }
+
+func (d *Craft) Locations(path, frontController string) string {
+	// Craft reads the route from the "p" query parameter
+	return `    location / {
+        try_files $uri $uri/ /` + frontController + `?p=$uri&$args;
+    }
+`
+}
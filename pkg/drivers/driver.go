@@ -0,0 +1,111 @@
+// Package drivers teaches SLD how individual frameworks are served:
+// where the web root is, which front controller handles requests and
+// which nginx location rules route to it.
+package drivers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Driver detects a framework from the project tree and describes how to serve it
+type Driver interface {
+	// Name is the identifier stored in site configs and accepted in .sld.yaml
+	Name() string
+
+	// Detect reports whether the project at path uses this framework
+	Detect(path string) bool
+
+	// WebRoot returns the document root relative to path ("" for the project root)
+	WebRoot(path string) string
+
+	// FrontController is the file unmatched requests fall back to
+	FrontController() string
+
+	// Locations returns the nginx location rules, excluding the PHP handler.
+	// frontController may differ from FrontController when .sld.yaml sets index.
+	Locations(path, frontController string) string
+}
+
+var (
+	mu       sync.RWMutex
+	registry []Driver
+)
+
+func init() {
+	// Most specific first; Static matches almost anything with an index.html
+	Register(&Laravel{})
+	Register(&Symfony{})
+	Register(&Craft{})
+	Register(&Drupal{})
+	Register(&WordPress{})
+	Register(&SPA{})
+	Register(&Static{})
+}
+
+// Register adds a driver after the existing ones
+func Register(d Driver) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry = append(registry, d)
+}
+
+// Detect returns the first driver that recognises the project, or nil
+func Detect(path string) Driver {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, d := range registry {
+		if d.Detect(path) {
+			return d
+		}
+	}
+	return nil
+}
+
+// Get looks a driver up by name
+func Get(name string) (Driver, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, d := range registry {
+		if d.Name() == name {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// Names lists the registered drivers in detection order
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, len(registry))
+	for i, d := range registry {
+		names[i] = d.Name()
+	}
+	return names
+}
+
+// DefaultLocations is the generic PHP front-controller rule used when no driver matches
+func DefaultLocations(frontController string) string {
+	return `    location / {
+        try_files $uri $uri/ /` + frontController + `?$query_string;
+    }
+`
+}
+
+func exists(path ...string) bool {
+	_, err := os.Stat(filepath.Join(path...))
+	return err == nil
+}
+
+func isDir(path ...string) bool {
+	info, err := os.Stat(filepath.Join(path...))
+	return err == nil && info.IsDir()
+}
+
+func fileContains(path, substr string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && strings.Contains(string(data), substr)
+}
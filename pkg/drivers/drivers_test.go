@@ -0,0 +1,141 @@
+package drivers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// project builds a temporary project tree from relative file paths
+func project(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		driver   string
+		webRoot  string
+		location string
+	}{
+		{
+			name:     "laravel",
+			files:    map[string]string{"artisan": "", "public/index.php": ""},
+			driver:   "laravel",
+			webRoot:  "public",
+			location: "try_files $uri $uri/ /index.php?$query_string;",
+		},
+		{
+			name:     "symfony",
+			files:    map[string]string{"bin/console": "", "public/index.php": "", "symfony.lock": ""},
+			driver:   "symfony",
+			webRoot:  "public",
+			location: "try_files $uri /index.php$is_args$args;",
+		},
+		{
+			name:     "craft",
+			files:    map[string]string{"craft": "", "web/index.php": ""},
+			driver:   "craft",
+			webRoot:  "web",
+			location: "/index.php?p=$uri&$args;",
+		},
+		{
+			name:     "drupal",
+			files:    map[string]string{"index.php": "", "core/lib/Drupal.php": ""},
+			driver:   "drupal",
+			webRoot:  "",
+			location: "location ~ ^/sites/.*/private/",
+		},
+		{
+			name:     "drupal composer",
+			files:    map[string]string{"composer.json": "{}", "web/index.php": "", "web/core/lib/Drupal.php": ""},
+			driver:   "drupal",
+			webRoot:  "web",
+			location: "try_files $uri /index.php?$query_string;",
+		},
+		{
+			name:     "wordpress",
+			files:    map[string]string{"index.php": "", "wp-config.php": "<?php"},
+			driver:   "wordpress",
+			webRoot:  "",
+			location: "try_files $uri $uri/ /index.php?$args;",
+		},
+		{
+			name:     "spa",
+			files:    map[string]string{"package.json": "{}", "dist/index.html": ""},
+			driver:   "spa",
+			webRoot:  "dist",
+			location: "try_files $uri $uri/ /index.html;",
+		},
+		{
+			name:     "static",
+			files:    map[string]string{"index.html": ""},
+			driver:   "static",
+			webRoot:  "",
+			location: "try_files $uri $uri/ =404;",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := project(t, tt.files)
+
+			d := Detect(root)
+			if d == nil {
+				t.Fatalf("no driver detected, want %s", tt.driver)
+			}
+			if d.Name() != tt.driver {
+				t.Fatalf("detected %s, want %s", d.Name(), tt.driver)
+			}
+			if got := d.WebRoot(root); got != tt.webRoot {
+				t.Errorf("web root %q, want %q", got, tt.webRoot)
+			}
+			if got := d.Locations(root, d.FrontController()); !strings.Contains(got, tt.location) {
+				t.Errorf("locations missing %q:\n%s", tt.location, got)
+			}
+		})
+	}
+}
+
+func TestDetectUnknownProject(t *testing.T) {
+	root := project(t, map[string]string{"README.md": ""})
+	if d := Detect(root); d != nil {
+		t.Errorf("expected no driver, got %s", d.Name())
+	}
+}
+
+func TestWordPressMultisite(t *testing.T) {
+	subdir := project(t, map[string]string{
+		"wp-config.php": "<?php define('MULTISITE', true); define('SUBDOMAIN_INSTALL', false);",
+	})
+	if got := (&WordPress{}).Locations(subdir, "index.php"); !strings.Contains(got, "rewrite ^(/[^/]+)?(/wp-.*) $2 last;") {
+		t.Errorf("subdirectory multisite rewrites missing:\n%s", got)
+	}
+
+	subdomain := project(t, map[string]string{
+		"wp-config.php": "<?php define('MULTISITE', true); define('SUBDOMAIN_INSTALL', true);",
+	})
+	if got := (&WordPress{}).Locations(subdomain, "index.php"); strings.Contains(got, "rewrite") {
+		t.Errorf("subdomain multisite should not rewrite paths:\n%s", got)
+	}
+}
+
+func TestCustomFrontController(t *testing.T) {
+	root := project(t, map[string]string{"artisan": "", "public/index.php": ""})
+	if got := (&Laravel{}).Locations(root, "app.php"); !strings.Contains(got, "/app.php?$query_string") {
+		t.Errorf("front controller override ignored:\n%s", got)
+	}
+}
@@ -0,0 +1,40 @@
+package drivers
+
+// Drupal 8+ lives in the project root or, for composer installs, in web/
+type Drupal struct{}
+
+func (d *Drupal) Name() string { return "drupal" }
+
+func (d *Drupal) Detect(path string) bool {
+	return exists(path, "core", "lib", "Drupal.php") || exists(path, "web", "core", "lib", "Drupal.php")
+}
+
+func (d *Drupal) WebRoot(path string) string {
+	if exists(path, "web", "core", "lib", "Drupal.php") {
+		return "web"
+	}
+	return ""
+}
+
+func (d *Drupal) FrontController() string { return "index.php" }
+
+func (d *Drupal) Locations(path, frontController string) string {
+	return `    location / {
+        try_files $uri /` + frontController + `?$query_string;
+    }
+
+    # Keep private files, dotfiles and PHP outside the front controller unreachable
+    location ~ ^/sites/.*/private/ {
+        return 403;
+    }
+    location ~ (^|/)\. {
+        return 403;
+    }
+    location ~ ^/sites/[^/]+/files/styles/ {
+        try_files $uri @drupal;
+    }
+    location @drupal {
+        rewrite ^ /` + frontController + `;
+    }
+`
+}
@@ -0,0 +1,21 @@
+package drivers
+
+// Laravel serves public/index.php
+type Laravel struct{}
+
+func (d *Laravel) Name() string { return "laravel" }
+
+func (d *Laravel) Detect(path string) bool {
+	return exists(path, "artisan") && exists(path, "public", "index.php")
+}
+
+func (d *Laravel) WebRoot(path string) string { return "public" }
+
+func (d *Laravel) FrontController() string { return "index.php" }
+
+func (d *Laravel) Locations(path, frontController string) string {
+	return DefaultLocations(frontController) + `
+    location = /favicon.ico { access_log off; log_not_found off; }
+    location = /robots.txt  { access_log off; log_not_found off; }
+`
+}
@@ -0,0 +1,32 @@
+package drivers
+
+// spaOutputDirs are the build directories of common bundlers, in lookup order
+var spaOutputDirs = []string{"dist", "build", "out"}
+
+// SPA serves a built single-page app and sends unknown paths to index.html
+// so client-side routing works on reload
+type SPA struct{}
+
+func (d *SPA) Name() string { return "spa" }
+
+func (d *SPA) Detect(path string) bool {
+	return exists(path, "package.json") && d.WebRoot(path) != ""
+}
+
+func (d *SPA) WebRoot(path string) string {
+	for _, dir := range spaOutputDirs {
+		if isDir(path, dir) && exists(path, dir, "index.html") {
+			return dir
+		}
+	}
+	return ""
+}
+
+func (d *SPA) FrontController() string { return "index.html" }
+
+func (d *SPA) Locations(path, frontController string) string {
+	return `    location / {
+        try_files $uri $uri/ /` + frontController + `;
+    }
+`
+}
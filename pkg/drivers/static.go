@@ -0,0 +1,29 @@
+package drivers
+
+// Static serves plain HTML without a front controller
+type Static struct{}
+
+func (d *Static) Name() string { return "static" }
+
+func (d *Static) Detect(path string) bool {
+	if exists(path, "index.php") || exists(path, "public", "index.php") {
+		return false
+	}
+	return exists(path, "index.html") || exists(path, "public", "index.html")
+}
+
+func (d *Static) WebRoot(path string) string {
+	if !exists(path, "index.html") && exists(path, "public", "index.html") {
+		return "public"
+	}
+	return ""
+}
+
+func (d *Static) FrontController() string { return "index.html" }
+
+func (d *Static) Locations(path, frontController string) string {
+	return `    location / {
+        try_files $uri $uri/ =404;
+    }
+`
+}
@@ -0,0 +1,23 @@
+package drivers
+
+// Symfony serves public/index.php and passes the original path through
+type Symfony struct{}
+
+func (d *Symfony) Name() string { return "symfony" }
+
+func (d *Symfony) Detect(path string) bool {
+	return exists(path, "bin", "console") && exists(path, "public", "index.php") &&
+		(exists(path, "symfony.lock") || exists(path, "config", "bundles.php"))
+}
+
+func (d *Symfony) WebRoot(path string) string { return "public" }
+
+func (d *Symfony) FrontController() string { return "index.php" }
+
+func (d *Symfony) Locations(path, frontController string) string {
+	// Symfony routes on PATH_INFO, so the query string is kept as-is
+	return `    location / {
+        try_files $uri /` + frontController + `$is_args$args;
+    }
+`
+}
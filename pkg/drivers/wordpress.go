@@ -0,0 +1,40 @@
+package drivers
+
+import "path/filepath"
+
+// WordPress serves index.php from the project root, including multisite networks
+type WordPress struct{}
+
+func (d *WordPress) Name() string { return "wordpress" }
+
+func (d *WordPress) Detect(path string) bool {
+	return exists(path, "wp-config.php") || exists(path, "wp-load.php")
+}
+
+func (d *WordPress) WebRoot(path string) string { return "" }
+
+func (d *WordPress) FrontController() string { return "index.php" }
+
+func (d *WordPress) Locations(path, frontController string) string {
+	rules := `    location / {
+        try_files $uri $uri/ /` + frontController + `?$args;
+    }
+`
+	// Subdirectory multisite needs the network paths mapped back to the core files
+	config := filepath.Join(path, "wp-config.php")
+	if fileContains(config, "MULTISITE") && !subdomainInstall(config) {
+		rules += `
+    if (!-e $request_filename) {
+        rewrite /wp-admin$ $scheme://$host$uri/ permanent;
+        rewrite ^(/[^/]+)?(/wp-.*) $2 last;
+        rewrite ^(/[^/]+)?(/.*\.php) $2 last;
+    }
+`
+	}
+	return rules
+}
+
+// subdomainInstall reports whether a multisite network uses subdomains
+func subdomainInstall(config string) bool {
+	return fileContains(config, "'SUBDOMAIN_INSTALL', true") || fileContains(config, "\"SUBDOMAIN_INSTALL\", true")
+}
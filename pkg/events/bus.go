@@ -17,8 +17,15 @@ const (
 	LogEntry            EventType = "log:entry"
 	ArtisanOutput       EventType = "artisan:output"
 	ArtisanDone         EventType = "artisan:done"
+	HookOutput          EventType = "hook:output"
+	HookFinished        EventType = "hook:finished"
 	HealerIssueDetected EventType = "healer:issue_detected"
 	HealerIssueResolved EventType = "healer:issue_resolved"
+	SiteAdded           EventType = "site:added"
+	SiteRemoved         EventType = "site:removed"
+	SiteConfigChanged   EventType = "site:config_changed"
+	PHPVersionChanged   EventType = "php:version_changed"
+	StateChanged        EventType = "state:changed"
 )
 
 type Event struct {
@@ -0,0 +1,199 @@
+// Package fpm renders the dedicated PHP-FPM pool SLD runs for each isolated
+// PHP site, so sites get their own workers, user and php.ini overrides.
+package fpm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Pool defaults, tuned for a development machine running many sites:
+// workers are started on demand and exit when idle
+const (
+	DefaultPM             = "ondemand"
+	DefaultMaxChildren    = 5
+	DefaultMaxRequests    = 500
+	DefaultSlowlogTimeout = "5s"
+	idleTimeout           = "10s"
+)
+
+// Settings are a site's pool options, from the fpm: block of its .sld.yaml
+type Settings struct {
+	User            string            `yaml:"user" json:"user,omitempty"` // Must be the project's owner, see Pool.CheckUser
+	PM              string            `yaml:"pm" json:"pm,omitempty"`     // static, dynamic or ondemand
+	MaxChildren     int               `yaml:"max_children" json:"max_children,omitempty"`
+	StartServers    int               `yaml:"start_servers" json:"start_servers,omitempty"`
+	MinSpareServers int               `yaml:"min_spare_servers" json:"min_spare_servers,omitempty"`
+	MaxSpareServers int               `yaml:"max_spare_servers" json:"max_spare_servers,omitempty"`
+	MaxRequests     int               `yaml:"max_requests" json:"max_requests,omitempty"`
+	SlowlogTimeout  string            `yaml:"slowlog_timeout" json:"slowlog_timeout,omitempty"` // e.g. "5s"; "0" disables the slowlog
+	AdminValues     map[string]string `yaml:"php_admin_value" json:"php_admin_value,omitempty"` // php.ini overrides the site can't change
+}
+
+var (
+	iniKey   = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	duration = regexp.MustCompile(`^[0-9]+[smhd]?$`)
+	userName = regexp.MustCompile(`^[a-z_][a-z0-9_-]*\$?$`)
+)
+
+// Validate rejects settings php-fpm would refuse, which would take every
+// pool of that PHP version down with it
+func (s Settings) Validate() error {
+	switch s.PM {
+	case "", "static", "dynamic", "ondemand":
+	default:
+		return fmt.Errorf("pm must be static, dynamic or ondemand, not %q", s.PM)
+	}
+	if s.User != "" && !userName.MatchString(s.User) {
+		return fmt.Errorf("invalid user %q", s.User)
+	}
+	for name, n := range map[string]int{
+		"max_children": s.MaxChildren, "start_servers": s.StartServers, "min_spare_servers": s.MinSpareServers,
+		"max_spare_servers": s.MaxSpareServers, "max_requests": s.MaxRequests,
+	} {
+		if n < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	if s.SlowlogTimeout != "" && !duration.MatchString(s.SlowlogTimeout) {
+		return fmt.Errorf("slowlog_timeout must be a duration like 5s, not %q", s.SlowlogTimeout)
+	}
+
+	p := Pool{Settings: s}
+	if p.pm() == "dynamic" {
+		children, start, min, max := p.dynamic()
+		if min > max || start < min || start > max || max > children {
+			return fmt.Errorf("dynamic pm needs min_spare_servers (%d) <= start_servers (%d) <= max_spare_servers (%d) <= max_children (%d)",
+				min, start, max, children)
+		}
+	}
+
+	for key, value := range s.AdminValues {
+		if !iniKey.MatchString(key) {
+			return fmt.Errorf("invalid php_admin_value name %q", key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("php_admin_value %s must be a single line", key)
+		}
+	}
+	return nil
+}
+
+// Pool is the FPM pool of one site
+type Pool struct {
+	Site    string // Domain the pool serves
+	Owner   string // Run-as user
+	Socket  string
+	Slowlog string // Slowlog file; empty disables it
+	Settings
+}
+
+// Name is the pool's section name, which is also its file name
+func (p Pool) Name() string {
+	return "sld-" + p.Site
+}
+
+// Render returns the pool's php-fpm.conf section
+func (p Pool) Render() string {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format+"\n", args...)
+	}
+
+	line("; Generated by SLD for %s. Changes are overwritten: use fpm: in its .sld.yaml.", p.Site)
+	line("[%s]", p.Name())
+	if user := p.user(); user != "" {
+		line("user = %s", user)
+	}
+	line("listen = %s", p.Socket)
+	// The socket only accepts local connections and nginx may run as any user
+	line("listen.mode = 0666")
+	line("")
+
+	pm := p.pm()
+	line("pm = %s", pm)
+	children, start, min, max := p.dynamic()
+	line("pm.max_children = %d", children)
+	switch pm {
+	case "dynamic":
+		line("pm.start_servers = %d", start)
+		line("pm.min_spare_servers = %d", min)
+		line("pm.max_spare_servers = %d", max)
+	case "ondemand":
+		line("pm.process_idle_timeout = %s", idleTimeout)
+	}
+	line("pm.max_requests = %d", or(p.MaxRequests, DefaultMaxRequests))
+
+	if timeout := p.slowlogTimeout(); p.Slowlog != "" && timeout != "0" {
+		line("")
+		line("slowlog = %s", p.Slowlog)
+		line("request_slowlog_timeout = %s", timeout)
+	}
+
+	line("")
+	line("catch_workers_output = yes")
+
+	if len(p.AdminValues) > 0 {
+		line("")
+		keys := make([]string, 0, len(p.AdminValues))
+		for k := range p.AdminValues {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			line("php_admin_value[%s] = %s", k, p.AdminValues[k])
+		}
+	}
+	return b.String()
+}
+
+// CheckUser rejects a user: other than the project's owner. .sld.yaml
+// comes with the project, so a cloned repository must not get its PHP run
+// as another account.
+func (p Pool) CheckUser() error {
+	if p.User != "" && p.User != p.Owner {
+		return fmt.Errorf("fpm user %q is not the project's owner %q; the pool runs as %q", p.User, p.Owner, p.Owner)
+	}
+	return nil
+}
+
+// user is the pool's run-as user: always the project's owner
+func (p Pool) user() string {
+	return p.Owner
+}
+
+func (p Pool) pm() string {
+	if p.PM == "" {
+		return DefaultPM
+	}
+	return p.PM
+}
+
+func (p Pool) slowlogTimeout() string {
+	if p.SlowlogTimeout == "" {
+		return DefaultSlowlogTimeout
+	}
+	return p.SlowlogTimeout
+}
+
+// dynamic fills in the worker counts php-fpm requires, keeping
+// min <= start <= max <= children
+func (p Pool) dynamic() (children, start, min, max int) {
+	children = or(p.MaxChildren, DefaultMaxChildren)
+	min = or(p.MinSpareServers, 1)
+	max = or(p.MaxSpareServers, (children+1)/2)
+	if max < min {
+		max = min
+	}
+	start = or(p.StartServers, min)
+	return children, start, min, max
+}
+
+func or(n, fallback int) int {
+	if n > 0 {
+		return n
+	}
+	return fallback
+}
@@ -0,0 +1,91 @@
+package fpm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderDefaults(t *testing.T) {
+	p := Pool{Site: "blog.test", Owner: "alice", Socket: "/run/php/sld-blog.test.sock", Slowlog: "/var/log/sld/blog.test-fpm-slow.log"}
+	want := `; Generated by SLD for blog.test. Changes are overwritten: use fpm: in its .sld.yaml.
+[sld-blog.test]
+user = alice
+listen = /run/php/sld-blog.test.sock
+listen.mode = 0666
+
+pm = ondemand
+pm.max_children = 5
+pm.process_idle_timeout = 10s
+pm.max_requests = 500
+
+slowlog = /var/log/sld/blog.test-fpm-slow.log
+request_slowlog_timeout = 5s
+
+catch_workers_output = yes
+`
+	if got := p.Render(); got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderSettings(t *testing.T) {
+	p := Pool{Site: "shop.test", Owner: "alice", Socket: "/run/php/sld-shop.test.sock", Slowlog: "/tmp/slow.log", Settings: Settings{
+		User:           "www-data",
+		PM:             "dynamic",
+		MaxChildren:    8,
+		SlowlogTimeout: "0",
+		AdminValues:    map[string]string{"memory_limit": "512M", "display_errors": "on"},
+	}}
+	got := p.Render()
+	for _, line := range []string{
+		"user = alice\n", // Only the project's owner
+		"pm = dynamic\npm.max_children = 8\npm.start_servers = 1\npm.min_spare_servers = 1\npm.max_spare_servers = 4\n",
+		"php_admin_value[display_errors] = on\nphp_admin_value[memory_limit] = 512M\n",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("missing %q in\n%s", line, got)
+		}
+	}
+	if strings.Contains(got, "slowlog") {
+		t.Errorf("slowlog_timeout 0 should disable the slowlog:\n%s", got)
+	}
+}
+
+func TestCheckUser(t *testing.T) {
+	p := Pool{Site: "shop.test", Owner: "alice"}
+	if err := p.CheckUser(); err != nil {
+		t.Errorf("no user: %v", err)
+	}
+	p.User = "alice"
+	if err := p.CheckUser(); err != nil {
+		t.Errorf("owner as user: %v", err)
+	}
+	p.User = "postgres"
+	if err := p.CheckUser(); err == nil {
+		t.Error("a .sld.yaml must not run PHP as another account")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    Settings
+		ok   bool
+	}{
+		{"empty", Settings{}, true},
+		{"dynamic defaults", Settings{PM: "dynamic"}, true},
+		{"unknown pm", Settings{PM: "lazy"}, false},
+		{"spares above children", Settings{PM: "dynamic", MaxChildren: 2, MaxSpareServers: 3}, false},
+		{"start below min", Settings{PM: "dynamic", MinSpareServers: 2, StartServers: 1, MaxSpareServers: 3}, false},
+		{"negative", Settings{MaxRequests: -1}, false},
+		{"timeout", Settings{SlowlogTimeout: "five"}, false},
+		{"user", Settings{User: "alice; rm"}, false},
+		{"ini key", Settings{AdminValues: map[string]string{"memory_limit]": "1G"}}, false},
+		{"multi-line value", Settings{AdminValues: map[string]string{"memory_limit": "1G\nuser = root"}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.s.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package nginx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Installer applies a generated config transactionally: the candidate is
+// written in place with the previous file kept beside it, `nginx -t` checks
+// it, and nginx only reloads when the check passes. A rejected candidate is
+// replaced by the previous file again.
+type Installer struct {
+	Path   string                               // Live config file
+	Write  func(path string, data []byte) error // Defaults to os.WriteFile
+	Test   func() ([]byte, error)               // Runs `nginx -t`, returning its output
+	Reload func() error
+}
+
+// BackupPath is where the last accepted config is kept
+func (i *Installer) BackupPath() string {
+	return i.Path + ".bak"
+}
+
+// Apply installs config, returning a *ConfigError when nginx rejects it
+func (i *Installer) Apply(config string) error {
+	write := i.Write
+	if write == nil {
+		write = func(path string, data []byte) error {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			return os.WriteFile(path, data, 0644)
+		}
+	}
+
+	// A rollback writes previous back, so it must be the live file's content
+	previous, readErr := os.ReadFile(i.Path)
+	if readErr != nil && !errors.Is(readErr, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read the current nginx config: %w", readErr)
+	}
+	if readErr == nil {
+		if err := write(i.BackupPath(), previous); err != nil {
+			return fmt.Errorf("failed to back up nginx config: %w", err)
+		}
+	}
+	if err := write(i.Path, []byte(config)); err != nil {
+		return err
+	}
+
+	if i.Test != nil {
+		if out, err := i.Test(); err != nil {
+			// Without a previous file an empty one keeps nginx's includes valid
+			if restoreErr := write(i.Path, previous); restoreErr != nil {
+				return fmt.Errorf("nginx rejected the config and the previous one could not be restored: %v", restoreErr)
+			}
+			return NewConfigError(string(out), i.Path, config)
+		}
+	}
+
+	if i.Reload == nil {
+		return nil
+	}
+	return i.Reload()
+}
+
+// ConfigError is a config nginx refused, located in the generated file
+type ConfigError struct {
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Text    string `json:"text,omitempty"`   // The offending line
+	Source  string `json:"source,omitempty"` // "sld", "plugin" or "site"
+	Name    string `json:"name,omitempty"`   // Plugin block or site domain
+	Output  string `json:"output"`           // Full `nginx -t` output
+}
+
+func (e *ConfigError) Error() string {
+	where := ""
+	switch {
+	case e.Source == "plugin" || e.Source == "site":
+		where = fmt.Sprintf(" at line %d (%s %s)", e.Line, e.Source, e.Name)
+	case e.Line > 0:
+		where = fmt.Sprintf(" at %s:%d", e.File, e.Line)
+	}
+	return fmt.Sprintf("nginx rejected the config%s: %s; the previous config was kept", where, e.Message)
+}
+
+// testFailure matches the first error `nginx -t` reports, e.g.
+// nginx: [emerg] unknown directive "foo" in /etc/nginx/sites-enabled/sld.conf:42
+var testFailure = regexp.MustCompile(`\[(?:emerg|alert|crit|error)\] (.+?)(?: in (\S+):(\d+))?\s*$`)
+
+// NewConfigError parses `nginx -t` output. When the error points into the
+// generated file (by name, since nginx may report a symlink), the line is
+// traced back to the plugin block or site it came from.
+func NewConfigError(output, path, config string) *ConfigError {
+	e := &ConfigError{Message: strings.TrimSpace(output), Output: output}
+	for _, line := range strings.Split(output, "\n") {
+		m := testFailure.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		e.Message, e.File = m[1], m[2]
+		e.Line, _ = strconv.Atoi(m[3])
+		break
+	}
+	if e.Message == "" {
+		e.Message = "nginx -t failed"
+	}
+
+	if e.Line > 0 && filepath.Base(e.File) == filepath.Base(path) {
+		e.Text, e.Source, e.Name = Locate(config, e.Line)
+	}
+	return e
+}
+
+// Locate returns the text of a line (1-based) in a rendered config and the
+// block it belongs to: "plugin" with the plugin's block header, "site" with
+// the site's primary domain, or "sld" for the shared servers.
+func Locate(config string, line int) (text, source, name string) {
+	lines := strings.Split(config, "\n")
+	if line < 1 || line > len(lines) {
+		return "", "", ""
+	}
+	text = strings.TrimSpace(lines[line-1])
+
+	source = "sld"
+	serverStart := -1
+	for i := 0; i < line; i++ {
+		l := lines[i]
+		switch {
+		case l == "# --- Plugin Blocks ---":
+			source = "plugin"
+		case l == "# --- Isolated Sites ---":
+			source, name = "site", ""
+		case source == "plugin" && strings.HasPrefix(l, "# --- Plugin: "):
+			name = strings.TrimSuffix(strings.TrimPrefix(l, "# --- Plugin: "), " ---")
+		case source == "site" && l == "server {":
+			serverStart = i
+		}
+	}
+
+	if source == "site" {
+		if serverStart < 0 {
+			return text, "sld", ""
+		}
+		for _, l := range lines[serverStart:] {
+			if names, ok := strings.CutPrefix(strings.TrimSpace(l), "server_name "); ok {
+				if fields := strings.Fields(strings.TrimSuffix(names, ";")); len(fields) > 0 {
+					name = fields[0]
+				}
+				break
+			}
+		}
+	}
+	return text, source, name
+}
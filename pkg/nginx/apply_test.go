@@ -0,0 +1,129 @@
+package nginx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lineOf returns the 1-based line of the first occurrence of substr
+func lineOf(t *testing.T, config, substr string) int {
+	t.Helper()
+	for i, l := range strings.Split(config, "\n") {
+		if strings.Contains(l, substr) {
+			return i + 1
+		}
+	}
+	t.Fatalf("%q not found in config", substr)
+	return 0
+}
+
+func TestInstallerRollsBackRejectedConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sld.conf")
+	os.WriteFile(path, []byte("# previous\n"), 0644)
+
+	bad := PluginBlock{Plugin: "Example Proxy", Name: "api-proxy", Config: "location /api/ {\n    proxy_pass http://localhost:3000;\n}\n"}
+	config, err := Build(testOptions(), []Site{shop}, []PluginBlock{bad}).Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := lineOf(t, config, "location /api/")
+
+	reloaded := false
+	installer := Installer{
+		Path: path,
+		Test: func() ([]byte, error) {
+			out := fmt.Sprintf("nginx: [emerg] \"location\" directive is not allowed here in /etc/nginx/sites-enabled/sld.conf:%d\nnginx: configuration file /etc/nginx/nginx.conf test failed\n", line)
+			return []byte(out), errors.New("exit status 1")
+		},
+		Reload: func() error { reloaded = true; return nil },
+	}
+
+	err = installer.Apply(config)
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("expected a ConfigError, got %v", err)
+	}
+	if configErr.Source != "plugin" || configErr.Name != "Example Proxy (api-proxy)" || configErr.Line != line {
+		t.Errorf("wrong location: %+v", configErr)
+	}
+	if configErr.Text != "location /api/ {" || configErr.Message != `"location" directive is not allowed here` {
+		t.Errorf("wrong details: %+v", configErr)
+	}
+	if reloaded {
+		t.Error("nginx was reloaded with a rejected config")
+	}
+	if got, _ := os.ReadFile(path); string(got) != "# previous\n" {
+		t.Errorf("previous config not restored, got %q", got)
+	}
+}
+
+func TestInstallerKeepsBackupAndReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sld.conf")
+	os.WriteFile(path, []byte("# previous\n"), 0644)
+
+	reloaded := false
+	installer := Installer{
+		Path:   path,
+		Test:   func() ([]byte, error) { return []byte("syntax is ok"), nil },
+		Reload: func() error { reloaded = true; return nil },
+	}
+	if err := installer.Apply("# next\n"); err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded {
+		t.Error("nginx was not reloaded")
+	}
+	if got, _ := os.ReadFile(path); string(got) != "# next\n" {
+		t.Errorf("config not installed, got %q", got)
+	}
+	if got, _ := os.ReadFile(installer.BackupPath()); string(got) != "# previous\n" {
+		t.Errorf("backup not kept, got %q", got)
+	}
+}
+
+func TestLocateSite(t *testing.T) {
+	config, err := Build(testOptions(), []Site{shop, legacy}, nil).Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, source, name := Locate(config, lineOf(t, config, "client_max_body_size 256M"))
+	if text != "client_max_body_size 256M;" || source != "site" || name != "legacy.test" {
+		t.Errorf("got %q %q %q", text, source, name)
+	}
+	if _, source, _ := Locate(config, lineOf(t, config, "stub_status")); source != "sld" {
+		t.Errorf("shared servers should belong to sld, got %q", source)
+	}
+}
+
+func TestConfigErrorOutsideGeneratedFile(t *testing.T) {
+	e := NewConfigError("nginx: [emerg] unexpected \"}\" in /etc/nginx/nginx.conf:12\n", "/etc/nginx/sites-available/sld.conf", "")
+	if e.Source != "" || e.File != "/etc/nginx/nginx.conf" || e.Line != 12 {
+		t.Errorf("unexpected %+v", e)
+	}
+	if !strings.Contains(e.Error(), "/etc/nginx/nginx.conf:12") {
+		t.Errorf("error should point at the file: %s", e.Error())
+	}
+}
+
+func TestInstallerKeepsUnreadableConfig(t *testing.T) {
+	// A directory can't be read as a file, like a config we lack permission for
+	path := t.TempDir()
+
+	var written []string
+	installer := Installer{
+		Path:  path,
+		Write: func(p string, data []byte) error { written = append(written, p); return nil },
+		Test:  func() ([]byte, error) { return nil, errors.New("exit status 1") },
+	}
+	if err := installer.Apply("# candidate\n"); err == nil {
+		t.Fatal("expected an error for an unreadable config")
+	}
+	if len(written) != 0 {
+		t.Errorf("wrote %v although the live config couldn't be read", written)
+	}
+}
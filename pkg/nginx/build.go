@@ -0,0 +1,304 @@
+package nginx
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/drivers"
+)
+
+// DefaultSocket is used when no PHP version is known
+const DefaultSocket = "/run/php/php-fpm.sock"
+
+// phpValue keeps deprecations out of rendered pages
+const phpValue = "error_reporting=E_ALL & ~E_DEPRECATED"
+
+// Options are the global settings every server block depends on
+type Options struct {
+	TLD        string
+	HTTPPort   int
+	HTTPSPort  int
+	Secure     bool   // Serve HTTPS and redirect plain HTTP to it
+	RuntimeDir string // Holds router.php
+	LogDir     string
+	CertsDir   string // Holds the shared dev.pem / dev-key.pem
+	APIAddr    string // Dashboard and API backend
+	PHPSocket  string // Global PHP-FPM socket or host:port
+}
+
+// Site is a site that needs its own server block
+type Site struct {
+	Domain            string
+	ServerNames       []string // Domain first, then aliases
+	Path              string   // Project directory
+	WebRoot           string   // Relative to Path
+	Driver            string
+	Index             string // Front controller override from .sld.yaml
+	Socket            string // PHP-FPM socket or host:port
+	ClientMaxBodySize string
+	Nginx             string // Raw directives from .sld.yaml
+	Env               map[string]string
+	Proxy             string // Upstream URL; when set, requests go there instead of PHP
+
+	// TLS serves the site over HTTPS with its own certificate, even when
+	// HTTPS isn't enabled globally. Without it, secure mode uses the shared one.
+	TLS *TLS
+}
+
+// Build assembles the full config: the wildcard and dashboard servers,
+// plugin blocks, and one server per isolated site (two when it's served
+// over HTTPS)
+func Build(opts Options, sites []Site, plugins []PluginBlock) *Config {
+	cfg := &Config{
+		Servers: []Server{opts.wildcardHTTP(), opts.dashboardHTTP()},
+		Plugins: append([]PluginBlock(nil), plugins...),
+	}
+	if opts.Secure {
+		cfg.Servers = append(cfg.Servers, opts.wildcardHTTPS(), opts.dashboardHTTPS())
+	}
+	sort.SliceStable(cfg.Plugins, func(i, j int) bool {
+		if cfg.Plugins[i].Plugin != cfg.Plugins[j].Plugin {
+			return cfg.Plugins[i].Plugin < cfg.Plugins[j].Plugin
+		}
+		return cfg.Plugins[i].Name < cfg.Plugins[j].Name
+	})
+
+	sorted := append([]Site(nil), sites...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Domain < sorted[j].Domain })
+	for _, site := range sorted {
+		tls := site.TLS
+		if tls == nil && opts.Secure {
+			tls = opts.tls()
+		}
+		if tls == nil {
+			cfg.Sites = append(cfg.Sites, opts.siteServer(site, Listen{Port: opts.HTTPPort}, nil))
+			continue
+		}
+		cfg.Sites = append(cfg.Sites, Server{
+			Listen:      []Listen{{Port: opts.HTTPPort}},
+			ServerNames: site.names(),
+			Return:      "301 " + opts.httpsRedirect(),
+		})
+		cfg.Sites = append(cfg.Sites, opts.siteServer(site, Listen{Port: opts.HTTPSPort, SSL: true}, tls))
+	}
+	return cfg
+}
+
+// httpsRedirect is the redirect target for plain HTTP requests on secured sites
+func (o Options) httpsRedirect() string {
+	if o.HTTPSPort == 443 {
+		return "https://$host$request_uri"
+	}
+	return fmt.Sprintf("https://$host:%d$request_uri", o.HTTPSPort)
+}
+
+func (o Options) wildcard() string  { return "*." + o.TLD }
+func (o Options) dashboard() string { return "sld." + o.TLD }
+
+func (o Options) tls() *TLS {
+	return &TLS{
+		Certificate: filepath.Join(o.CertsDir, "dev.pem"),
+		Key:         filepath.Join(o.CertsDir, "dev-key.pem"),
+	}
+}
+
+func (o Options) logs() ([]AccessLog, string) {
+	return []AccessLog{
+			{Path: filepath.Join(o.LogDir, "sld-access.log")},
+			{Path: filepath.Join(o.LogDir, "sld-xray.log"), Format: "sld_xray_json"},
+		},
+		filepath.Join(o.LogDir, "sld-error.log")
+}
+
+// router dispatches every request on the wildcard server to router.php
+func (o Options) router(https bool) Location {
+	params := []Param{{"HTTP_HOST", "$host"}}
+	if https {
+		params = append(params, Param{"HTTPS", "on"})
+	}
+	params = append(params, Param{"PHP_VALUE", phpValue})
+	return Location{
+		Match:      "/",
+		Directives: []string{"try_files /router.php =404"},
+		FastCGI: &FastCGI{
+			Pass:           fastcgiPass(o.PHPSocket),
+			Index:          "router.php",
+			ScriptFilename: "$document_root/router.php",
+			Params:         params,
+		},
+	}
+}
+
+// status exposes stub_status for the dashboard metrics. It stays reachable
+// over plain HTTP in secure mode because metrics fetch it from 127.0.0.1.
+func statusLocation() Location {
+	return Location{
+		Match:      "/sld-nginx-status",
+		Directives: []string{"stub_status", "allow 127.0.0.1", "deny all", "access_log off"},
+	}
+}
+
+func (o Options) wildcardHTTP() Server {
+	access, errorLog := o.logs()
+	s := Server{
+		Listen:      []Listen{{Port: o.HTTPPort}},
+		ServerNames: []string{o.wildcard()},
+		Root:        o.RuntimeDir,
+		AccessLogs:  access,
+		ErrorLog:    errorLog,
+	}
+	if o.Secure {
+		s.Locations = []Location{{Match: "/", Directives: []string{"return 301 " + o.httpsRedirect()}}}
+	} else {
+		s.Locations = []Location{o.router(false)}
+	}
+	s.Locations = append(s.Locations, statusLocation())
+	return s
+}
+
+func (o Options) wildcardHTTPS() Server {
+	access, errorLog := o.logs()
+	return Server{
+		Listen:      []Listen{{Port: o.HTTPSPort, SSL: true}},
+		ServerNames: []string{o.wildcard()},
+		Root:        o.RuntimeDir,
+		TLS:         o.tls(),
+		AccessLogs:  access,
+		ErrorLog:    errorLog,
+		Locations:   []Location{o.router(true)},
+	}
+}
+
+func (o Options) dashboardHTTP() Server {
+	s := Server{
+		Listen:      []Listen{{Port: o.HTTPPort}},
+		ServerNames: []string{o.dashboard()},
+	}
+	if o.Secure {
+		s.Return = "301 " + o.httpsRedirect()
+	} else {
+		s.Locations = []Location{{Match: "/", Proxy: &Proxy{Pass: "http://" + o.APIAddr}}}
+	}
+	return s
+}
+
+func (o Options) dashboardHTTPS() Server {
+	return Server{
+		Listen:      []Listen{{Port: o.HTTPSPort, SSL: true}},
+		ServerNames: []string{o.dashboard()},
+		TLS:         o.tls(),
+		Locations:   []Location{{Match: "/", Proxy: &Proxy{Pass: "http://" + o.APIAddr}}},
+	}
+}
+
+// siteServer is the full server block of an isolated site. HTTP and HTTPS
+// variants differ only in listen and TLS.
+func (o Options) siteServer(site Site, listen Listen, tls *TLS) Server {
+	access, errorLog := o.logs()
+	s := Server{
+		Listen:      []Listen{listen},
+		ServerNames: site.names(),
+		TLS:         tls,
+		AccessLogs:  access,
+		ErrorLog:    errorLog,
+	}
+	if site.ClientMaxBodySize != "" {
+		s.Directives = append(s.Directives, "client_max_body_size "+site.ClientMaxBodySize)
+	}
+	if site.Proxy != "" {
+		return site.proxyServer(s)
+	}
+
+	frontController, index := site.index()
+	s.Root = filepath.Join(site.Path, site.WebRoot)
+	s.Index = index
+	s.ForwardedHeaders = true
+
+	locations := drivers.DefaultLocations(frontController)
+	driverComment := ""
+	if d, ok := drivers.Get(site.Driver); ok {
+		locations = d.Locations(site.Path, frontController)
+		driverComment = "Driver: " + d.Name()
+	}
+	s.Snippets = append(s.Snippets, Snippet{Comment: driverComment, Text: locations})
+	if strings.TrimSpace(site.Nginx) != "" {
+		s.Snippets = append(s.Snippets, Snippet{Comment: "From .sld.yaml", Text: site.Nginx})
+	}
+
+	params := []Param{
+		{"HTTP_HOST", "$proxy_host"},
+		{"SERVER_NAME", "$proxy_host"},
+		{"HTTPS", "$proxy_https"},
+		{"PHP_VALUE", phpValue},
+	}
+	keys := make([]string, 0, len(site.Env))
+	for k := range site.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		params = append(params, Param{k, site.Env[k]})
+	}
+
+	s.Locations = append(s.Locations, Location{
+		Match: `~ \.php$`,
+		FastCGI: &FastCGI{
+			Pass:           fastcgiPass(site.Socket),
+			Index:          frontController,
+			ScriptFilename: "$realpath_root$fastcgi_script_name",
+			Params:         params,
+		},
+	})
+	return s
+}
+
+// proxyServer passes every request to the site's upstream. The .sld.yaml
+// snippet still applies, e.g. to serve a build directory directly.
+func (s Site) proxyServer(srv Server) Server {
+	if strings.TrimSpace(s.Nginx) != "" {
+		srv.Snippets = append(srv.Snippets, Snippet{Comment: "From .sld.yaml", Text: s.Nginx})
+	}
+	srv.Locations = append(srv.Locations, Location{Match: "/", Proxy: &Proxy{Pass: s.Proxy}})
+	return srv
+}
+
+// names falls back to the domain when no server names were given
+func (s Site) names() []string {
+	if len(s.ServerNames) == 0 {
+		return []string{s.Domain}
+	}
+	return s.ServerNames
+}
+
+// index returns the file requests fall back to and the index list.
+// An index from .sld.yaml wins over the driver's front controller.
+func (s Site) index() (string, []string) {
+	frontController := "index.php"
+	if d, ok := drivers.Get(s.Driver); ok {
+		frontController = d.FrontController()
+	}
+	if s.Index != "" {
+		frontController = strings.TrimPrefix(s.Index, "/")
+	}
+
+	files := []string{frontController}
+	for _, f := range []string{"index.html", "index.htm", "index.php"} {
+		if f != frontController {
+			files = append(files, f)
+		}
+	}
+	return frontController, files
+}
+
+// fastcgiPass prefixes Unix socket paths; TCP addresses (Windows, macOS) pass through
+func fastcgiPass(socket string) string {
+	if socket == "" {
+		socket = DefaultSocket
+	}
+	if strings.HasPrefix(socket, "/") {
+		return "unix:" + socket
+	}
+	return socket
+}
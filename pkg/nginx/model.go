@@ -0,0 +1,90 @@
+// Package nginx generates the SLD nginx configuration. Build turns the
+// daemon's view of sites and plugins into a typed model of servers and
+// locations, and Render prints it through a single text/template so HTTP
+// and HTTPS blocks can't drift apart.
+package nginx
+
+// Config is a complete generated config file
+type Config struct {
+	Servers []Server      // Wildcard and dashboard servers
+	Plugins []PluginBlock // Raw blocks from plugin NginxHooks
+	Sites   []Server      // Isolated per-site servers
+}
+
+// Listen is one listen address; it is rendered for IPv4 and IPv6
+type Listen struct {
+	Port int
+	SSL  bool
+}
+
+// TLS holds the certificate a server presents
+type TLS struct {
+	Certificate string
+	Key         string
+}
+
+// AccessLog is an access_log target with an optional log_format name
+type AccessLog struct {
+	Path   string
+	Format string
+}
+
+// Snippet is raw nginx text from a driver or .sld.yaml, re-indented on render
+type Snippet struct {
+	Comment string
+	Text    string
+}
+
+// Server is one server block
+type Server struct {
+	Listen      []Listen
+	ServerNames []string
+	Return      string // When set, the server only redirects (e.g. "301 https://$host$request_uri")
+	Root        string
+	Index       []string
+	TLS         *TLS
+	AccessLogs  []AccessLog
+	ErrorLog    string
+	Directives  []string // Simple directives without the trailing semicolon
+
+	// ForwardedHeaders derives $proxy_host and $proxy_https from
+	// X-Forwarded-* so apps generate public URLs behind a tunnel
+	ForwardedHeaders bool
+
+	Snippets  []Snippet
+	Locations []Location
+}
+
+// Location is one location block. At most one of FastCGI and Proxy is set.
+type Location struct {
+	Match      string   // e.g. "/", "~ \.php$"
+	Directives []string // Rendered before the handler
+	FastCGI    *FastCGI
+	Proxy      *Proxy
+}
+
+// FastCGI passes requests to PHP-FPM
+type FastCGI struct {
+	Pass           string // unix:/path.sock or host:port
+	Index          string
+	ScriptFilename string
+	Params         []Param
+}
+
+// Param is a fastcgi_param; values are quoted and escaped on render
+type Param struct {
+	Name  string
+	Value string
+}
+
+// Proxy passes requests to an HTTP upstream, WebSockets included
+type Proxy struct {
+	Pass string
+}
+
+// PluginBlock is a named config block contributed by a plugin
+type PluginBlock struct {
+	Plugin string // Display name
+	Name   string // Block identifier from the plugin
+	Config string
+}
@@ -0,0 +1,154 @@
+package nginx
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func testOptions() Options {
+	return Options{
+		TLD:        "test",
+		HTTPPort:   80,
+		HTTPSPort:  443,
+		RuntimeDir: "/var/lib/sld/runtime",
+		LogDir:     "/var/lib/sld/logs",
+		CertsDir:   "/var/lib/sld/certs",
+		APIAddr:    "127.0.0.1:8081",
+		PHPSocket:  "/run/php/php8.3-fpm.sock",
+	}
+}
+
+var shop = Site{
+	Domain:      "shop.test",
+	ServerNames: []string{"shop.test", "api.shop.test"},
+	Path:        "/srv/www/shop",
+	WebRoot:     "public",
+	Driver:      "laravel",
+	Socket:      "/run/php/php8.1-fpm.sock",
+}
+
+var legacy = Site{
+	Domain:            "legacy.test",
+	Path:              "/srv/www/legacy app",
+	Index:             "/app.php",
+	Socket:            "127.0.0.1:9074",
+	ClientMaxBodySize: "256M",
+	Nginx:             "gzip on;\nlocation /uploads {\n  expires 7d;\n}\n",
+	Env:               map[string]string{"APP_ENV": "local", "GREETING": `say "hi"`},
+}
+
+var storefront = Site{
+	Domain:            "storefront.test",
+	Proxy:             "http://127.0.0.1:3000",
+	ClientMaxBodySize: "20M",
+	TLS:               &TLS{Certificate: "/var/lib/sld/certs/storefront.test.pem", Key: "/var/lib/sld/certs/storefront.test-key.pem"},
+}
+
+func TestRenderGolden(t *testing.T) {
+	securedShop := shop
+	securedShop.TLS = &TLS{Certificate: "/var/lib/sld/certs/shop.test.pem", Key: "/var/lib/sld/certs/shop.test-key.pem"}
+
+	secure := testOptions()
+	secure.Secure = true
+
+	customPort := testOptions()
+	customPort.Secure = true
+	customPort.TLD = "localhost"
+	customPort.HTTPPort = 8080
+	customPort.HTTPSPort = 8443
+	local := legacy
+	local.Domain = "legacy.localhost"
+
+	wildcard := Site{
+		Domain:      "acme.test",
+		ServerNames: []string{"acme.test", "*.acme.test"},
+		Path:        "/srv/www/acme",
+		WebRoot:     "public",
+		Socket:      "/run/php/php8.3-fpm.sock",
+		TLS:         &TLS{Certificate: "/var/lib/sld/certs/acme.test.pem", Key: "/var/lib/sld/certs/acme.test-key.pem"},
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		sites   []Site
+		plugins []PluginBlock
+	}{
+		{name: "parked", opts: testOptions()},
+		{name: "linked", opts: testOptions(), sites: []Site{shop}},
+		{name: "isolated", opts: testOptions(), sites: []Site{shop, legacy}},
+		{name: "secure", opts: secure, sites: []Site{shop}},
+		{name: "site-secure", opts: testOptions(), sites: []Site{securedShop, legacy}},
+		{name: "proxy", opts: testOptions(), sites: []Site{shop, storefront}},
+		{name: "custom-port", opts: customPort, sites: []Site{local}},
+		{name: "wildcard", opts: testOptions(), sites: []Site{wildcard}},
+		{
+			name: "plugin-hook",
+			opts: testOptions(),
+			plugins: []PluginBlock{
+				{Plugin: "Example Proxy", Name: "api-proxy", Config: "\nlocation /api/ {\n    proxy_pass http://localhost:3000;\n}\n"},
+				{Plugin: "Adminer", Name: "adminer", Config: "# adminer\n"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Build(tt.opts, tt.sites, tt.plugins).Render()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("%s differs from golden file:\n%s", tt.name, got)
+			}
+		})
+	}
+}
+
+// The HTTP and HTTPS variants of a site must carry the same PHP settings
+func TestSecureSiteKeepsPHPSettings(t *testing.T) {
+	opts := testOptions()
+	opts.Secure = true
+	cfg := Build(opts, []Site{legacy}, nil)
+
+	if len(cfg.Sites) != 2 || cfg.Sites[0].Return == "" {
+		t.Fatalf("expected a redirect and an HTTPS server, got %+v", cfg.Sites)
+	}
+	php := cfg.Sites[1].Locations[len(cfg.Sites[1].Locations)-1].FastCGI
+	var names []string
+	for _, p := range php.Params {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "HTTP_HOST,SERVER_NAME,HTTPS,PHP_VALUE,APP_ENV,GREETING" {
+		t.Errorf("unexpected fastcgi params %s", got)
+	}
+}
+
+func TestFastCGIPass(t *testing.T) {
+	tests := map[string]string{
+		"":                         "unix:" + DefaultSocket,
+		"/run/php/php8.2-fpm.sock": "unix:/run/php/php8.2-fpm.sock",
+		"127.0.0.1:9082":           "127.0.0.1:9082",
+	}
+	for socket, want := range tests {
+		if got := fastcgiPass(socket); got != want {
+			t.Errorf("fastcgiPass(%q) = %q, want %q", socket, got, want)
+		}
+	}
+}
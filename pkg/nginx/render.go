@@ -0,0 +1,65 @@
+package nginx
+
+import (
+	"bytes"
+	"embed"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/sld.conf.tmpl
+var templates embed.FS
+
+var tmpl = template.Must(template.New("sld.conf.tmpl").Funcs(template.FuncMap{
+	"join":   strings.Join,
+	"arg":    arg,
+	"indent": indent,
+}).ParseFS(templates, "templates/sld.conf.tmpl"))
+
+// blankRuns collapses the blank lines template branches leave behind
+var blankRuns = regexp.MustCompile(`\n{3,}`)
+
+// Render prints the config in nginx syntax
+func (c *Config) Render() (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, c); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(blankRuns.ReplaceAllString(buf.String(), "\n\n")) + "\n", nil
+}
+
+// arg quotes a directive argument when nginx would otherwise split or
+// misread it; variables still expand inside double quotes
+func arg(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\"';{}#") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// indent re-indents raw text to n spaces, keeping its relative indentation
+func indent(n int, text string) string {
+	lines := strings.Split(strings.Trim(text, "\n"), "\n")
+
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if common < 0 || width < common {
+			common = width
+		}
+	}
+
+	prefix := strings.Repeat(" ", n)
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+			continue
+		}
+		lines[i] = prefix + strings.TrimRight(line[common:], " \t")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,182 @@
+// Package php inspects installed PHP runtimes, renders the ini files SLD
+// adds to their conf.d directories and maps extensions to the packages
+// that provide them.
+package php
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SLD's own conf.d files. The prefixes sort them after the distribution's
+// extension files, and the user's overrides after the plugins'.
+const (
+	PluginsIni   = "90-sld-plugins.ini"   // What enabled plugins need
+	OverridesIni = "99-sld-overrides.ini" // Set with `sld php ini set`
+)
+
+// zendExtensions are loaded with zend_extension= instead of extension=
+var zendExtensions = map[string]bool{"opcache": true, "xdebug": true}
+
+var (
+	extensionLine = regexp.MustCompile(`^\s*(zend_)?extension\s*=\s*["']?([^"'\s;]+)`)
+	valueLine     = regexp.MustCompile(`^\s*([A-Za-z0-9_.-]+)\s*=\s*(.*?)\s*$`)
+	priorityLine  = regexp.MustCompile(`^;\s*priority\s*=\s*([0-9]+)`)
+	directiveName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// ExtensionName normalizes an extension as written in php -m or an
+// extension= line: "Zend OPcache", "redis.so" and "php_redis.dll" become
+// "opcache" and "redis"
+func ExtensionName(name string) string {
+	name = strings.ToLower(filepath.Base(name))
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".so"), ".dll")
+	name = strings.TrimPrefix(name, "php_")
+	if name == "zend opcache" {
+		return "opcache"
+	}
+	return name
+}
+
+// ParseExtensions returns the extensions an ini file loads
+func ParseExtensions(content string) []string {
+	var names []string
+	for _, line := range strings.Split(content, "\n") {
+		if m := extensionLine.FindStringSubmatch(line); m != nil {
+			names = append(names, ExtensionName(m[2]))
+		}
+	}
+	return names
+}
+
+// parseIniList extracts the file names from php --ini output
+func parseIniList(out string) []string {
+	var files []string
+	inAdditional := false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Loaded Configuration File:"):
+			if file := strings.TrimSpace(strings.TrimPrefix(line, "Loaded Configuration File:")); file != "(none)" {
+				files = append(files, file)
+			}
+			inAdditional = false
+		case strings.HasPrefix(line, "Additional .ini files parsed:"):
+			line = strings.TrimPrefix(line, "Additional .ini files parsed:")
+			inAdditional = true
+			fallthrough
+		case inAdditional:
+			for _, file := range strings.Split(line, ",") {
+				if file = strings.TrimSpace(file); file != "" && file != "(none)" {
+					files = append(files, file)
+				}
+			}
+		}
+	}
+	return files
+}
+
+// parseInfo extracts the directives and their local values from php -i
+// output, whose settings tables read "name => local value => master value"
+func parseInfo(out string) map[string]string {
+	values := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.Split(line, " => ")
+		if len(parts) != 3 || parts[0] == "Directive" {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		if value == "no value" {
+			value = ""
+		}
+		values[strings.TrimSpace(parts[0])] = value
+	}
+	return values
+}
+
+// ParseValues returns the directives an ini file sets, other than
+// extensions, with quotes removed
+func ParseValues(content string) map[string]string {
+	values := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		m := valueLine.FindStringSubmatch(line)
+		if m == nil || m[1] == "extension" || m[1] == "zend_extension" {
+			continue
+		}
+		value := m[2]
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+		}
+		values[m[1]] = value
+	}
+	return values
+}
+
+// Priority is the conf.d ordering Debian's mods-available files declare
+// ("; priority=20"), defaulting to 20 like phpenmod
+func Priority(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if m := priorityLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			return m[1]
+		}
+	}
+	return "20"
+}
+
+// ValidateDirective rejects a php.ini setting that would break the file
+func ValidateDirective(name, value string) error {
+	if !directiveName.MatchString(name) {
+		return fmt.Errorf("invalid php.ini directive %q", name)
+	}
+	if name == "extension" || name == "zend_extension" {
+		return fmt.Errorf("use `sld php ext enable` to load extensions")
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("the value of %s must be a single line", name)
+	}
+	return nil
+}
+
+// Render returns an SLD-owned ini file loading extensions and setting
+// values. header is written as a comment on top.
+func Render(header string, extensions []string, values map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "; %s\n", header)
+
+	if len(extensions) > 0 {
+		b.WriteString("\n")
+		sorted := append([]string(nil), extensions...)
+		sort.Strings(sorted)
+		for _, name := range sorted {
+			directive := "extension"
+			if zendExtensions[name] {
+				directive = "zend_extension"
+			}
+			fmt.Fprintf(&b, "%s=%s\n", directive, name)
+		}
+	}
+
+	if len(values) > 0 {
+		b.WriteString("\n")
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s = %s\n", k, quote(values[k]))
+		}
+	}
+	return b.String()
+}
+
+// quote wraps values PHP's ini parser would otherwise split or interpret
+func quote(value string) string {
+	if value == "" || strings.ContainsAny(value, " ;=&|^~!(){}[]\"'$") {
+		return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+	}
+	return value
+}
@@ -0,0 +1,105 @@
+package php
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExtensions(t *testing.T) {
+	ini := `; priority=20
+extension=redis.so
+ zend_extension = "/usr/lib/php/20220829/xdebug.so"
+;extension=imagick
+extension=php_pdo_pgsql.dll ; Windows
+memory_limit = 128M
+`
+	got := ParseExtensions(ini)
+	want := []string{"redis", "xdebug", "pdo_pgsql"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseExtensions = %v, want %v", got, want)
+	}
+}
+
+func TestParseIniList(t *testing.T) {
+	out := `Configuration File (php.ini) Path: /etc/php/8.2/cli
+Loaded Configuration File:         /etc/php/8.2/cli/php.ini
+Scan for additional .ini files in: /etc/php/8.2/cli/conf.d
+Additional .ini files parsed:      /etc/php/8.2/cli/conf.d/10-opcache.ini,
+/etc/php/8.2/cli/conf.d/20-redis.ini,
+/etc/php/8.2/cli/conf.d/90-sld-plugins.ini
+`
+	got := parseIniList(out)
+	want := []string{
+		"/etc/php/8.2/cli/php.ini",
+		"/etc/php/8.2/cli/conf.d/10-opcache.ini",
+		"/etc/php/8.2/cli/conf.d/20-redis.ini",
+		"/etc/php/8.2/cli/conf.d/90-sld-plugins.ini",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseIniList = %v, want %v", got, want)
+	}
+
+	if got := parseIniList("Loaded Configuration File: (none)\nAdditional .ini files parsed: (none)\n"); len(got) != 0 {
+		t.Errorf("parseIniList without files = %v", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	got := Render("Generated by SLD", []string{"xdebug", "redis"}, map[string]string{
+		"sendmail_path": "/usr/local/bin/MailHog sendmail --smtp-addr=127.0.0.1:1025",
+		"memory_limit":  "512M",
+	})
+	want := `; Generated by SLD
+
+extension=redis
+zend_extension=xdebug
+
+memory_limit = 512M
+sendmail_path = "/usr/local/bin/MailHog sendmail --smtp-addr=127.0.0.1:1025"
+`
+	if got != want {
+		t.Errorf("Render =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	out := `PHP Version => 8.2.12
+
+Core
+
+Directive => Local Value => Master Value
+memory_limit => 256M => 128M
+sendmail_path => /usr/sbin/sendmail -t -i => /usr/sbin/sendmail -t -i
+error_log => no value => no value
+`
+	got := parseInfo(out)
+	want := map[string]string{
+		"memory_limit":  "256M",
+		"sendmail_path": "/usr/sbin/sendmail -t -i",
+		"error_log":     "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseInfo = %v, want %v", got, want)
+	}
+}
+
+func TestParseValuesRoundTrip(t *testing.T) {
+	values := map[string]string{
+		"memory_limit":  "512M",
+		"sendmail_path": `/usr/bin/env catchmail -f "dev@example.test"`,
+		"error_log":     "",
+	}
+	content := Render("Generated by SLD", []string{"redis"}, values)
+	if got := ParseValues(content); !reflect.DeepEqual(got, values) {
+		t.Errorf("ParseValues(Render()) = %v, want %v", got, values)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	if got := Priority("; configuration for php redis module\n; priority=25\nextension=redis.so\n"); got != "25" {
+		t.Errorf("Priority = %q, want 25", got)
+	}
+	if got := Priority("extension=gd.so\n"); got != "20" {
+		t.Errorf("Priority without a declaration = %q, want 20", got)
+	}
+}
@@ -0,0 +1,42 @@
+package php
+
+// debianPackages maps extensions shipped together to the suffix of their
+// Debian/Ubuntu package (php8.2-<suffix>); any other extension has a
+// package of its own name
+var debianPackages = map[string]string{
+	"mysqli": "mysql", "mysqlnd": "mysql", "pdo_mysql": "mysql",
+	"pgsql": "pgsql", "pdo_pgsql": "pgsql",
+	"sqlite3": "sqlite3", "pdo_sqlite": "sqlite3",
+	"odbc": "odbc", "pdo_odbc": "odbc",
+	"dom": "xml", "simplexml": "xml", "xml": "xml", "xmlreader": "xml", "xmlwriter": "xml", "xsl": "xml",
+	"calendar": "common", "ctype": "common", "exif": "common", "ffi": "common", "fileinfo": "common",
+	"ftp": "common", "gettext": "common", "iconv": "common", "pdo": "common", "phar": "common",
+	"posix": "common", "shmop": "common", "sockets": "common", "sysvmsg": "common", "sysvsem": "common",
+	"sysvshm": "common", "tokenizer": "common",
+}
+
+// peclExtensions are the common extensions Homebrew's PHP doesn't bundle,
+// installed with pecl instead
+var peclExtensions = map[string]bool{
+	"apcu": true, "igbinary": true, "imagick": true, "memcached": true, "mongodb": true,
+	"msgpack": true, "pcov": true, "redis": true, "swoole": true, "xdebug": true, "yaml": true,
+}
+
+// DebianPackage is the package providing an extension for a PHP version on
+// Debian and Ubuntu (including the ondrej/php builds), e.g. php8.2-redis
+func DebianPackage(version, name string) string {
+	suffix, ok := debianPackages[name]
+	if !ok {
+		suffix = name
+	}
+	return "php" + version + "-" + suffix
+}
+
+// PECLPackage is the PECL package of an extension Homebrew's PHP doesn't
+// bundle, or empty when it is built in
+func PECLPackage(name string) string {
+	if !peclExtensions[name] {
+		return ""
+	}
+	return "pecl/" + name
+}
@@ -0,0 +1,17 @@
+package php
+
+import "testing"
+
+func TestDebianPackage(t *testing.T) {
+	cases := map[string]string{
+		"redis":     "php8.2-redis",
+		"pdo_mysql": "php8.2-mysql",
+		"simplexml": "php8.2-xml",
+		"ctype":     "php8.2-common",
+	}
+	for name, want := range cases {
+		if got := DebianPackage("8.2", name); got != want {
+			t.Errorf("DebianPackage(8.2, %s) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package php
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Runtime is one installed PHP version and the configuration its FPM
+// service runs with
+type Runtime struct {
+	Version string
+	Binary  string // CLI binary
+	Config  string // php.ini FPM loads, when it differs from the CLI's
+	ScanDir string // conf.d directory FPM scans; SLD's own ini files go here
+}
+
+// Extension is a PHP extension of a runtime, see Runtime.Extensions
+type Extension struct {
+	Name    string `json:"name"`
+	Loaded  bool   `json:"loaded"`
+	Builtin bool   `json:"builtin"`            // Compiled in, can't be disabled
+	Shared  bool   `json:"shared"`             // Its library is installed in extension_dir
+	IniFile string `json:"ini_file,omitempty"` // Configuration file that loads it
+	Package string `json:"package,omitempty"`  // Package that provides it
+}
+
+// command runs the binary with the FPM configuration
+func (r Runtime) command(args ...string) *exec.Cmd {
+	if r.Config != "" {
+		args = append([]string{"-c", r.Config}, args...)
+	}
+	cmd := exec.Command(r.Binary, args...)
+	if r.ScanDir != "" {
+		cmd.Env = append(os.Environ(), "PHP_INI_SCAN_DIR="+r.ScanDir)
+	}
+	return cmd
+}
+
+// Modules lists the extensions the runtime loads, lowercased. Without ini
+// files only the compiled-in ones are listed.
+func (r Runtime) Modules(withIni bool) (map[string]bool, error) {
+	cmd := r.command("-m")
+	if !withIni {
+		cmd = exec.Command(r.Binary, "-n", "-m")
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PHP %s modules: %w", r.Version, err)
+	}
+	modules := map[string]bool{}
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "[") {
+			continue
+		}
+		modules[ExtensionName(line)] = true
+	}
+	return modules, nil
+}
+
+// IniFiles lists the php.ini and conf.d files the runtime parses
+func (r Runtime) IniFiles() ([]string, error) {
+	out, err := r.command("--ini").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PHP %s ini files: %w", r.Version, err)
+	}
+	return parseIniList(string(out)), nil
+}
+
+// ExtensionDir is where the runtime looks for shared extensions
+func (r Runtime) ExtensionDir() (string, error) {
+	out, err := r.command("-r", `echo ini_get("extension_dir");`).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the PHP %s extension_dir: %w", r.Version, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Ini returns the effective value of every directive, from php -i
+func (r Runtime) Ini() (map[string]string, error) {
+	out, err := r.command("-i").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PHP %s settings: %w", r.Version, err)
+	}
+	return parseInfo(string(out)), nil
+}
+
+// enabledBy maps each extension loaded from an ini file to that file,
+// ignoring the file named skip
+func (r Runtime) enabledBy(skip string) (map[string]string, error) {
+	files, err := r.IniFiles()
+	if err != nil {
+		return nil, err
+	}
+	enabled := map[string]string{}
+	for _, file := range files {
+		if filepath.Base(file) == skip {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, name := range ParseExtensions(string(data)) {
+			if _, ok := enabled[name]; !ok {
+				enabled[name] = file
+			}
+		}
+	}
+	return enabled, nil
+}
+
+// Loaded reports which extensions are loaded without SLD's help: compiled
+// in, or enabled by an ini file other than skip
+func (r Runtime) Loaded(skip string) (map[string]bool, error) {
+	loaded, err := r.Modules(false)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := r.enabledBy(skip)
+	if err != nil {
+		return nil, err
+	}
+	for name := range enabled {
+		loaded[name] = true
+	}
+	return loaded, nil
+}
+
+// Extensions lists every extension the runtime loads or could load from
+// its extension_dir, sorted by name
+func (r Runtime) Extensions() ([]Extension, error) {
+	loaded, err := r.Modules(true)
+	if err != nil {
+		return nil, err
+	}
+	builtin, err := r.Modules(false)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := r.enabledBy("")
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for name := range loaded {
+		names[name] = true
+	}
+	shared := map[string]bool{}
+	if dir, err := r.ExtensionDir(); err == nil && dir != "" {
+		for _, pattern := range []string{"*.so", "php_*.dll"} {
+			files, _ := filepath.Glob(filepath.Join(dir, pattern))
+			for _, file := range files {
+				name := ExtensionName(file)
+				shared[name], names[name] = true, true
+			}
+		}
+	}
+
+	list := make([]Extension, 0, len(names))
+	for name := range names {
+		list = append(list, Extension{
+			Name:    name,
+			Loaded:  loaded[name],
+			Builtin: builtin[name],
+			Shared:  shared[name],
+			IniFile: enabled[name],
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// HasExtension reports whether the shared library of an extension is
+// installed in dir
+func HasExtension(dir, name string) bool {
+	for _, file := range []string{name + ".so", "php_" + name + ".dll"} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			return true
+		}
+	}
+	return false
+}
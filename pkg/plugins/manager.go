@@ -1,7 +1,9 @@
 package plugins
 
 import (
+	"fmt"
 	"log"
+	"strings"
 	"sync"
 
 	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
@@ -12,6 +14,7 @@ type Manager struct {
 	mu           sync.RWMutex
 	DataDir      string
 	StateManager *state.Manager
+	OnChange     func() // Called after a plugin is enabled or disabled
 }
 
 func NewManager(dataDir string, stateManager *state.Manager) *Manager {
@@ -67,6 +70,9 @@ func (m *Manager) SetEnabled(id string, enabled bool) error {
 	if err == nil && m.StateManager != nil {
 		m.StateManager.SetPluginEnabled(id, enabled)
 	}
+	if err == nil && m.OnChange != nil {
+		m.OnChange()
+	}
 
 	return err
 }
@@ -92,3 +98,34 @@ func (m *Manager) StartEnabled() {
 		}
 	}
 }
+
+// EnsureRequired installs and enables the plugins a project asks for.
+// Unknown IDs are reported together after the known ones are handled.
+func (m *Manager) EnsureRequired(ids []string) error {
+	var unknown []string
+	for _, id := range ids {
+		p, ok := m.Get(id)
+		if !ok {
+			unknown = append(unknown, id)
+			continue
+		}
+
+		if !p.IsInstalled() {
+			log.Printf("Installing required plugin: %s", id)
+			if err := p.Install(); err != nil {
+				return fmt.Errorf("failed to install required plugin %s: %w", id, err)
+			}
+		}
+
+		if m.StateManager == nil || !m.StateManager.IsPluginEnabled(id) || p.Status() != StatusRunning {
+			if err := m.SetEnabled(id, true); err != nil {
+				return fmt.Errorf("failed to start required plugin %s: %w", id, err)
+			}
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown plugins: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
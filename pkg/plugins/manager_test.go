@@ -0,0 +1,108 @@
+package plugins
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/daemon/state"
+)
+
+// fakePlugin records what the manager asks it to do
+type fakePlugin struct {
+	Plugin
+	id         string
+	installed  bool
+	running    bool
+	installErr error
+	calls      []string
+}
+
+func (p *fakePlugin) ID() string        { return p.id }
+func (p *fakePlugin) IsInstalled() bool { return p.installed }
+
+func (p *fakePlugin) Status() Status {
+	if p.running {
+		return StatusRunning
+	}
+	return StatusStopped
+}
+
+func (p *fakePlugin) Install() error {
+	p.calls = append(p.calls, "install")
+	if p.installErr != nil {
+		return p.installErr
+	}
+	p.installed = true
+	return nil
+}
+
+func (p *fakePlugin) Start() error {
+	p.calls = append(p.calls, "start")
+	p.running = true
+	return nil
+}
+
+func (p *fakePlugin) Stop() error {
+	p.calls = append(p.calls, "stop")
+	p.running = false
+	return nil
+}
+
+func newTestManager(t *testing.T, plugins ...*fakePlugin) *Manager {
+	t.Helper()
+	dir := t.TempDir()
+	sm, err := state.NewManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Load(); err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(dir, sm)
+	for _, p := range plugins {
+		m.Register(p)
+	}
+	return m
+}
+
+func TestEnsureRequired(t *testing.T) {
+	missing := &fakePlugin{id: "redis"}
+	disabled := &fakePlugin{id: "mailpit", installed: true}
+	running := &fakePlugin{id: "postgres", installed: true, running: true}
+	m := newTestManager(t, missing, disabled, running)
+	m.StateManager.SetPluginEnabled("postgres", true)
+
+	err := m.EnsureRequired([]string{"redis", "ghost", "mailpit", "postgres"})
+	if err == nil || !strings.Contains(err.Error(), "unknown plugins: ghost") {
+		t.Errorf("err = %v, want the unknown plugin reported", err)
+	}
+
+	if got := strings.Join(missing.calls, ","); got != "install,start" {
+		t.Errorf("missing plugin calls = %s, want install,start", got)
+	}
+	if got := strings.Join(disabled.calls, ","); got != "start" {
+		t.Errorf("disabled plugin calls = %s, want start", got)
+	}
+	if len(running.calls) != 0 {
+		t.Errorf("running plugin was touched: %v", running.calls)
+	}
+	for _, id := range []string{"redis", "mailpit", "postgres"} {
+		if !m.StateManager.IsPluginEnabled(id) {
+			t.Errorf("%s not enabled in state", id)
+		}
+	}
+}
+
+func TestEnsureRequiredInstallFailure(t *testing.T) {
+	broken := &fakePlugin{id: "redis", installErr: errors.New("no network")}
+	m := newTestManager(t, broken)
+
+	err := m.EnsureRequired([]string{"redis"})
+	if err == nil || !strings.Contains(err.Error(), "no network") {
+		t.Fatalf("err = %v, want the install error", err)
+	}
+	if m.StateManager.IsPluginEnabled("redis") {
+		t.Error("a plugin that failed to install was enabled")
+	}
+}
@@ -1,12 +1,12 @@
 package project
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
+	"github.com/supreme-majesty/supreme-local-dev/pkg/drivers"
+	"github.com/supreme-majesty/supreme-local-dev/pkg/fpm"
 	"gopkg.in/yaml.v3"
 )
 
@@ -15,11 +15,46 @@ type Config struct {
 	PHP    string `yaml:"php"`    // PHP version (e.g., "8.1")
 	Node   string `yaml:"node"`   // Node version
 	Public string `yaml:"public"` // Web root (e.g., "public")
+	Driver string `yaml:"driver"` // Framework driver (e.g., "laravel", "spa"), detected when empty
+
+	PHPSource  string `yaml:"-"` // File the PHP constraint came from
+	NodeSource string `yaml:"-"` // File the Node version came from
+
+	Aliases           []string            `yaml:"aliases"`              // Extra domains (e.g., "api.myapp" -> api.myapp.test)
+	Wildcard          bool                `yaml:"wildcard"`             // Also serve every subdomain, e.g. tenant.myapp.test
+	Plugins           []string            `yaml:"plugins"`              // Required plugins (e.g., redis, mailhog, postgres)
+	Env               map[string]string   `yaml:"env"`                  // Extra fastcgi environment variables
+	Nginx             string              `yaml:"nginx"`                // Raw nginx location snippets
+	ClientMaxBodySize string              `yaml:"client_max_body_size"` // Upload limit (e.g., "100M")
+	Index             string              `yaml:"index"`                // Custom index file (e.g., "app.php")
+	Processes         map[string]string   `yaml:"processes"`            // Supervised workers, falls back to a Procfile
+	Hooks             map[string]Commands `yaml:"hooks"`                // Lifecycle hooks, e.g. post-link: [composer install]
+	Proxy             string              `yaml:"proxy"`                // Upstream to reverse-proxy to instead of PHP, e.g. http://127.0.0.1:3000
+	FPM               *fpm.Settings       `yaml:"fpm"`                  // The site's own PHP-FPM pool: user, pm, php_admin_value, ...
 }
 
-// ComposerJSON represents a subset of composer.json
-type ComposerJSON struct {
-	Require map[string]string `json:"require"`
+// Commands is a list of shell commands that may also be written as a single string
+type Commands []string
+
+func (c *Commands) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*c = Commands{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+// IsEmpty reports whether nothing was detected or configured
+func (c *Config) IsEmpty() bool {
+	return c.PHP == "" && c.Public == "" && c.Node == "" && c.Driver == "" &&
+		len(c.Aliases) == 0 && len(c.Plugins) == 0 && len(c.Env) == 0 &&
+		c.Nginx == "" && c.ClientMaxBodySize == "" && c.Index == "" && len(c.Processes) == 0 &&
+		c.Proxy == "" && !c.Wildcard && c.FPM == nil
 }
 
 // Detect scans a directory for configuration files
@@ -37,28 +72,43 @@ func Detect(path string) (*Config, error) {
 		}
 	}
 
-	// 2. Check composer.json for PHP version if not already set
-	if config.PHP == "" {
-		composerPath := filepath.Join(path, "composer.json")
-		if _, err := os.Stat(composerPath); err == nil {
-			if phpVer, err := extractPHPVersion(composerPath); err == nil && phpVer != "" {
-				config.PHP = phpVer
-			}
+	if config.Proxy != "" {
+		proxy, err := ParseProxy(config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy in .sld.yaml: %w", err)
+		}
+		config.Proxy = proxy
+	}
+	if config.FPM != nil {
+		if err := config.FPM.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid fpm in .sld.yaml: %w", err)
 		}
 	}
 
-	// 3. Check .nvmrc for Node version if not already set
-	if config.Node == "" {
-		nvmrcPath := filepath.Join(path, ".nvmrc")
-		if _, err := os.Stat(nvmrcPath); err == nil {
-			data, err := os.ReadFile(nvmrcPath)
-			if err == nil {
-				config.Node = strings.TrimSpace(string(data))
-			}
+	// 2. Runtime versions (.sld.yaml, .tool-versions, composer.json, .nvmrc, ...)
+	php := DetectPHP(path)
+	config.PHP, config.PHPSource = php.Constraint, php.Source
+	node := DetectNode(path)
+	config.Node, config.NodeSource = node.Constraint, node.Source
+
+	// 3. Detect the framework driver, which knows its web root
+	if config.Driver == "" {
+		if d := drivers.Detect(path); d != nil {
+			config.Driver = d.Name()
+		}
+	}
+	if d, ok := drivers.Get(config.Driver); ok && config.Public == "" {
+		config.Public = d.WebRoot(path)
+	}
+
+	// 4. Worker processes from a Procfile when .sld.yaml declares none
+	if len(config.Processes) == 0 {
+		if procs, err := ReadProcfile(path); err == nil {
+			config.Processes = procs
 		}
 	}
 
-	// 4. Auto-detect "public" directory (common in Laravel/Symfony/Modern Apps)
+	// 5. Auto-detect "public" directory (common in Laravel/Symfony/Modern Apps)
 	if config.Public == "" {
 		publicPath := filepath.Join(path, "public")
 		if info, err := os.Stat(publicPath); err == nil && info.IsDir() {
@@ -69,23 +119,12 @@ func Detect(path string) (*Config, error) {
 	return config, nil
 }
 
-// extractPHPVersion parses composer.json to find the required PHP version constraint
-func extractPHPVersion(path string) (string, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
-	}
-
-	var composer ComposerJSON
-	if err := json.Unmarshal(data, &composer); err != nil {
-		return "", err
-	}
-
-	// Extract version from "php": "^8.1"
-	constraint, ok := composer.Require["php"]
-	if !ok {
-		return "", nil
-	}
+// PHPRequirement returns the detected PHP constraint and its source
+func (c *Config) PHPRequirement() Requirement {
+	return Requirement{Runtime: RuntimePHP, Constraint: c.PHP, Source: c.PHPSource}
+}
 
-	return constraint, nil
+// NodeRequirement returns the detected Node version and its source
+func (c *Config) NodeRequirement() Requirement {
+	return Requirement{Runtime: RuntimeNode, Constraint: c.Node, Source: c.NodeSource}
 }
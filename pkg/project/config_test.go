@@ -0,0 +1,85 @@
+package project
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/fpm"
+)
+
+func TestDetectReadsSLDYaml(t *testing.T) {
+	dir := writeConfig(t, `public: web
+driver: spa
+aliases: [api.shop, admin.shop]
+wildcard: true
+plugins: [redis, mailpit]
+env:
+  APP_ENV: local
+client_max_body_size: 100M
+index: app.php
+nginx: |
+  location /storage { expires 7d; }
+proxy: "3000"
+fpm:
+  pm: ondemand
+  max_children: 4
+  php_admin_value:
+    memory_limit: 512M
+`)
+
+	conf, err := Detect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{
+		Public:            "web",
+		Driver:            "spa",
+		Aliases:           []string{"api.shop", "admin.shop"},
+		Wildcard:          true,
+		Plugins:           []string{"redis", "mailpit"},
+		Env:               map[string]string{"APP_ENV": "local"},
+		ClientMaxBodySize: "100M",
+		Index:             "app.php",
+		Nginx:             "location /storage { expires 7d; }\n",
+		Proxy:             "http://127.0.0.1:3000", // Normalized by ParseProxy
+		FPM: &fpm.Settings{
+			PM:          "ondemand",
+			MaxChildren: 4,
+			AdminValues: map[string]string{"memory_limit": "512M"},
+		},
+	}
+	if !reflect.DeepEqual(conf, want) {
+		t.Errorf("Detect =\n%+v\nwant\n%+v", conf, want)
+	}
+	if conf.IsEmpty() {
+		t.Error("IsEmpty reported a configured project as empty")
+	}
+}
+
+func TestDetectRejectsInvalidSLDYaml(t *testing.T) {
+	tests := []struct {
+		config string
+		err    string
+	}{
+		{"aliases: [unclosed\n", "failed to parse .sld.yaml"},
+		{"wildcard: sometimes\n", "failed to parse .sld.yaml"},
+		{"proxy: ftp://localhost:21\n", "invalid proxy"},
+		{"fpm:\n  pm: forever\n", "invalid fpm"},
+	}
+	for _, tt := range tests {
+		if _, err := Detect(writeConfig(t, tt.config)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: err = %v, want %q", tt.config, err, tt.err)
+		}
+	}
+}
+
+func TestDetectEmptyProject(t *testing.T) {
+	conf, err := Detect(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !conf.IsEmpty() {
+		t.Errorf("expected an empty config, got %+v", conf)
+	}
+}
@@ -0,0 +1,44 @@
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// procfileLine matches "name: command" as used by Heroku and foreman
+var procfileLine = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.+)$`)
+
+// ReadProcfile loads the Procfile in a project directory
+func ReadProcfile(dir string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "Procfile"))
+	if err != nil {
+		return nil, err
+	}
+	return ParseProcfile(data)
+}
+
+// ParseProcfile parses Procfile entries. Blank lines and # comments are skipped.
+func ParseProcfile(data []byte) (map[string]string, error) {
+	procs := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := procfileLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("Procfile line %d: expected \"name: command\"", n)
+		}
+		if _, dup := procs[m[1]]; dup {
+			return nil, fmt.Errorf("Procfile line %d: duplicate process %q", n, m[1])
+		}
+		procs[m[1]] = strings.TrimSpace(m[2])
+	}
+	return procs, scanner.Err()
+}
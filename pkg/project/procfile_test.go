@@ -0,0 +1,42 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProcfile(t *testing.T) {
+	procs, err := ParseProcfile([]byte("# workers\nqueue: php artisan queue:work --tries=3\n\nvite:npm run dev\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"queue": "php artisan queue:work --tries=3", "vite": "npm run dev"}
+	if !reflect.DeepEqual(procs, want) {
+		t.Errorf("got %v, want %v", procs, want)
+	}
+
+	if _, err := ParseProcfile([]byte("queue: a\nqueue: b\n")); err == nil {
+		t.Error("expected an error for a duplicate process")
+	}
+	if _, err := ParseProcfile([]byte("just a command\n")); err == nil {
+		t.Error("expected an error for a line without a name")
+	}
+}
+
+func TestDetectPrefersSLDYamlProcesses(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Procfile", "web: php -S localhost:8000\n")
+	conf, err := Detect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Processes["web"] != "php -S localhost:8000" {
+		t.Errorf("Procfile not picked up: %v", conf.Processes)
+	}
+
+	writeFile(t, dir, ".sld.yaml", "processes:\n  queue: php artisan queue:work\n")
+	conf, _ = Detect(dir)
+	if len(conf.Processes) != 1 || conf.Processes["queue"] == "" {
+		t.Errorf(".sld.yaml processes should replace the Procfile: %v", conf.Processes)
+	}
+}
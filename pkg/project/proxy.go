@@ -0,0 +1,43 @@
+package project
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ParseProxy normalizes a proxy upstream. A bare port ("3000") or host:port
+// ("127.0.0.1:3000") is taken as plain HTTP.
+func ParseProxy(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("proxy target is empty")
+	}
+	if port, err := strconv.Atoi(raw); err == nil {
+		if port <= 0 || port > 65535 {
+			return "", fmt.Errorf("invalid port %d", port)
+		}
+		return fmt.Sprintf("http://127.0.0.1:%d", port), nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid proxy target: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("proxy target must be http or https, got %s", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("proxy target %q has no host", raw)
+	}
+	if p := u.Port(); p != "" {
+		if port, err := strconv.Atoi(p); err != nil || port <= 0 || port > 65535 {
+			return "", fmt.Errorf("invalid port %s", p)
+		}
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
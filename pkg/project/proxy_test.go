@@ -0,0 +1,24 @@
+package project
+
+import "testing"
+
+func TestParseProxy(t *testing.T) {
+	valid := map[string]string{
+		"3000":                        "http://127.0.0.1:3000",
+		"127.0.0.1:5173":              "http://127.0.0.1:5173",
+		"http://localhost:8080/":      "http://localhost:8080",
+		"https://127.0.0.1:8443/base": "https://127.0.0.1:8443/base",
+	}
+	for raw, want := range valid {
+		got, err := ParseProxy(raw)
+		if err != nil || got != want {
+			t.Errorf("ParseProxy(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+
+	for _, raw := range []string{"", "70000", "ftp://host:21", "http://:3000", "http://host:99999"} {
+		if got, err := ParseProxy(raw); err == nil {
+			t.Errorf("ParseProxy(%q) = %q, expected an error", raw, got)
+		}
+	}
+}
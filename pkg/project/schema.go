@@ -0,0 +1,185 @@
+package project
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is the JSON Schema for .sld.yaml, for editors and CI linters
+//
+//go:embed sld.schema.json
+var Schema []byte
+
+// schemaNode is the subset of JSON Schema the validator understands
+type schemaNode struct {
+	Description          string                 `json:"description"`
+	Type                 schemaTypes            `json:"type"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	PropertyNames        *schemaNode            `json:"propertyNames"`
+	Items                *schemaNode            `json:"items"`
+	Enum                 []string               `json:"enum"`
+	Pattern              string                 `json:"pattern"`
+}
+
+// schemaTypes accepts both "type": "string" and "type": ["string", "number"]
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+var rootSchema = mustLoadSchema()
+
+func mustLoadSchema() *schemaNode {
+	var s schemaNode
+	if err := json.Unmarshal(Schema, &s); err != nil {
+		panic(fmt.Sprintf("invalid embedded .sld.yaml schema: %v", err))
+	}
+	return &s
+}
+
+// checkSchema walks a YAML node against the schema, reporting positions.
+// Unknown keys are warnings so older sld versions can read newer files.
+func checkSchema(node *yaml.Node, schema *schemaNode, field string, report func(Issue)) {
+	at := func(n *yaml.Node, severity, format string, args ...interface{}) {
+		report(Issue{Severity: severity, Line: n.Line, Column: n.Column, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(schema.Type) > 0 && !typeMatches(node, schema.Type) {
+		at(node, SeverityError, "expected %s, got %s", strings.Join(schema.Type, " or "), describeNode(node))
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		extra, allowExtra := additional(schema)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := joinField(field, key.Value)
+
+			if schema.PropertyNames != nil && schema.PropertyNames.Pattern != "" {
+				if !regexp.MustCompile(schema.PropertyNames.Pattern).MatchString(key.Value) {
+					report(Issue{Severity: SeverityError, Line: key.Line, Column: key.Column, Field: child,
+						Message: fmt.Sprintf("invalid key %q", key.Value)})
+				}
+			}
+
+			if prop, ok := schema.Properties[key.Value]; ok {
+				checkSchema(value, prop, child, report)
+			} else if extra != nil {
+				checkSchema(value, extra, child, report)
+			} else if !allowExtra {
+				report(Issue{Severity: SeverityWarning, Line: key.Line, Column: key.Column, Field: child,
+					Message: fmt.Sprintf("unknown key %q will be ignored", key.Value)})
+			}
+		}
+	case yaml.SequenceNode:
+		if schema.Items != nil {
+			for i, item := range node.Content {
+				checkSchema(item, schema.Items, fmt.Sprintf("%s[%d]", field, i), report)
+			}
+		}
+	case yaml.ScalarNode:
+		if len(schema.Enum) > 0 && !contains(schema.Enum, node.Value) {
+			at(node, SeverityError, "%q is not one of: %s", node.Value, strings.Join(schema.Enum, ", "))
+		}
+		if schema.Pattern != "" && !regexp.MustCompile(schema.Pattern).MatchString(node.Value) {
+			at(node, SeverityError, "%q does not match %s", node.Value, schema.Pattern)
+		}
+	}
+}
+
+// additional returns the schema for keys not listed in properties, and
+// whether such keys are allowed at all
+func additional(schema *schemaNode) (*schemaNode, bool) {
+	raw := strings.TrimSpace(string(schema.AdditionalProperties))
+	switch raw {
+	case "", "true":
+		return nil, schema.Properties == nil || raw == "true"
+	case "false":
+		return nil, false
+	}
+	var s schemaNode
+	if err := json.Unmarshal(schema.AdditionalProperties, &s); err != nil {
+		return nil, true
+	}
+	return &s, true
+}
+
+func typeMatches(node *yaml.Node, types []string) bool {
+	for _, t := range types {
+		switch t {
+		case "object":
+			if node.Kind == yaml.MappingNode {
+				return true
+			}
+		case "array":
+			if node.Kind == yaml.SequenceNode {
+				return true
+			}
+		case "string":
+			if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+				return true
+			}
+		case "number":
+			if node.Kind == yaml.ScalarNode && (node.Tag == "!!int" || node.Tag == "!!float") {
+				return true
+			}
+		case "boolean":
+			if node.Kind == yaml.ScalarNode && node.Tag == "!!bool" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	switch node.Tag {
+	case "!!int", "!!float":
+		return "a number"
+	case "!!bool":
+		return "a boolean"
+	case "!!null":
+		return "nothing"
+	}
+	return "a string"
+}
+
+func joinField(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
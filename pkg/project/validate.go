@@ -0,0 +1,182 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/fpm"
+	"gopkg.in/yaml.v3"
+)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is one validation finding, positioned in the file
+type Issue struct {
+	Severity string `json:"severity"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Severity, i.Message)
+}
+
+// ValidationResult lists everything found in one .sld.yaml
+type ValidationResult struct {
+	File   string  `json:"file"`
+	Valid  bool    `json:"valid"` // No errors (warnings are allowed)
+	Issues []Issue `json:"issues"`
+}
+
+// ValidateOptions supplies what is installed on this machine.
+// A nil list skips that check, e.g. in CI where sld isn't installed.
+type ValidateOptions struct {
+	PHPVersions  []string // Installed PHP versions (major.minor)
+	NodeVersions []string // Installed Node.js versions
+	Plugins      []string // Known plugin IDs
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+):?`)
+
+// Validate checks the .sld.yaml of a project directory (or the file itself)
+func Validate(path string, opts ValidateOptions) (*ValidationResult, error) {
+	file := path
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		file = filepath.Join(path, ".sld.yaml")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	res := &ValidationResult{File: file, Issues: []Issue{}}
+	report := func(i Issue) { res.Issues = append(res.Issues, i) }
+
+	// 1. Syntax
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		line := 0
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+		msg := yamlErrorLine.ReplaceAllString(strings.TrimPrefix(err.Error(), "yaml: "), "")
+		report(Issue{Severity: SeverityError, Line: line, Column: 1, Message: strings.TrimSpace(msg)})
+		return res.finish(), nil
+	}
+	if len(doc.Content) == 0 {
+		return res.finish(), nil // An empty file is fine
+	}
+	root := doc.Content[0]
+
+	// 2. Shape
+	checkSchema(root, rootSchema, "", report)
+	if root.Kind != yaml.MappingNode {
+		return res.finish(), nil
+	}
+
+	// 3. Meaning
+	dir := filepath.Dir(file)
+	if n := valueNode(root, "php"); n != nil && n.Kind == yaml.ScalarNode {
+		checkRuntime(n, Requirement{Runtime: RuntimePHP, Constraint: n.Value}, opts.PHPVersions, report)
+	}
+	if n := valueNode(root, "node"); n != nil && n.Kind == yaml.ScalarNode {
+		checkRuntime(n, Requirement{Runtime: RuntimeNode, Constraint: n.Value}, opts.NodeVersions, report)
+	}
+
+	webRoot := dir
+	if n := valueNode(root, "public"); n != nil && n.Kind == yaml.ScalarNode {
+		webRoot = filepath.Join(dir, n.Value)
+		if info, err := os.Stat(webRoot); err != nil || !info.IsDir() {
+			report(Issue{Severity: SeverityError, Line: n.Line, Column: n.Column, Field: "public",
+				Message: fmt.Sprintf("directory %s does not exist", n.Value)})
+		}
+	}
+
+	if n := valueNode(root, "index"); n != nil && n.Kind == yaml.ScalarNode {
+		if _, err := os.Stat(filepath.Join(webRoot, n.Value)); err != nil {
+			report(Issue{Severity: SeverityWarning, Line: n.Line, Column: n.Column, Field: "index",
+				Message: fmt.Sprintf("%s not found in the web root", n.Value)})
+		}
+	}
+
+	if n := valueNode(root, "proxy"); n != nil && n.Kind == yaml.ScalarNode {
+		if _, err := ParseProxy(n.Value); err != nil {
+			report(Issue{Severity: SeverityError, Line: n.Line, Column: n.Column, Field: "proxy", Message: err.Error()})
+		}
+	}
+
+	if n := valueNode(root, "fpm"); n != nil && n.Kind == yaml.MappingNode {
+		var settings fpm.Settings
+		if err := n.Decode(&settings); err == nil {
+			if err := settings.Validate(); err != nil {
+				report(Issue{Severity: SeverityError, Line: n.Line, Column: n.Column, Field: "fpm", Message: err.Error()})
+			}
+		}
+	}
+
+	if n := valueNode(root, "plugins"); n != nil && n.Kind == yaml.SequenceNode && opts.Plugins != nil {
+		for i, item := range n.Content {
+			if !contains(opts.Plugins, item.Value) {
+				report(Issue{Severity: SeverityError, Line: item.Line, Column: item.Column, Field: fmt.Sprintf("plugins[%d]", i),
+					Message: fmt.Sprintf("unknown plugin %q (available: %s)", item.Value, strings.Join(opts.Plugins, ", "))})
+			}
+		}
+	}
+
+	return res.finish(), nil
+}
+
+// checkRuntime parses the version and matches it against installed versions
+func checkRuntime(n *yaml.Node, req Requirement, installed []string, report func(Issue)) {
+	if req.IsAlias() {
+		return
+	}
+	matches, err := req.Match(installed)
+	if err != nil {
+		report(Issue{Severity: SeverityError, Line: n.Line, Column: n.Column, Field: req.Runtime,
+			Message: fmt.Sprintf("invalid %s version %q: %v", req.DisplayName(), n.Value, err)})
+		return
+	}
+	if installed == nil || len(matches) > 0 {
+		return
+	}
+	report(Issue{Severity: SeverityWarning, Line: n.Line, Column: n.Column, Field: req.Runtime,
+		Message: fmt.Sprintf("no installed %s satisfies %q (installed: %s); sld will try to install it", req.DisplayName(), n.Value, strings.Join(installed, ", "))})
+}
+
+// valueNode returns the value of a top-level key
+func valueNode(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func (r *ValidationResult) finish() *ValidationResult {
+	sort.SliceStable(r.Issues, func(i, j int) bool {
+		if r.Issues[i].Line != r.Issues[j].Line {
+			return r.Issues[i].Line < r.Issues[j].Line
+		}
+		return r.Issues[i].Column < r.Issues[j].Column
+	})
+	r.Valid = true
+	for _, i := range r.Issues {
+		if i.Severity == SeverityError {
+			r.Valid = false
+		}
+	}
+	return r
+}
@@ -0,0 +1,153 @@
+package project
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/drivers"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, dir, ".sld.yaml", content)
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateReportsPositions(t *testing.T) {
+	dir := writeConfig(t, `php: "^8.1"
+public: web
+aliases: api.shop
+colour: blue
+client_max_body_size: lots
+`)
+
+	res, err := Validate(dir, ValidateOptions{PHPVersions: []string{"7.4"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid {
+		t.Fatal("expected the file to be invalid")
+	}
+
+	want := []string{
+		"1:6: warning: no installed PHP",
+		"2:9: error: directory web does not exist",
+		"3:10: error: expected array",
+		"4:1: warning: unknown key \"colour\"",
+		"5:23: error: \"lots\" does not match",
+	}
+	if len(res.Issues) != len(want) {
+		t.Fatalf("expected %d issues, got %v", len(want), res.Issues)
+	}
+	for i, prefix := range want {
+		if got := res.Issues[i].String(); !strings.HasPrefix(got, prefix) {
+			t.Errorf("issue %d = %q, want prefix %q", i, got, prefix)
+		}
+	}
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	dir := writeConfig(t, "php: 8.2\npublic: [web\n")
+	res, err := Validate(dir, ValidateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid || len(res.Issues) != 1 || res.Issues[0].Line == 0 {
+		t.Errorf("expected one positioned syntax error, got %+v", res.Issues)
+	}
+}
+
+func TestValidateAcceptsFullConfig(t *testing.T) {
+	dir := writeConfig(t, `php: 8.2
+public: public
+driver: laravel
+aliases: [api.shop]
+wildcard: true
+plugins: [redis]
+env:
+  APP_DEBUG: true
+client_max_body_size: 100M
+nginx: |
+  location /storage { expires 7d; }
+processes:
+  queue: php artisan queue:work
+fpm:
+  pm: dynamic
+  max_children: 10
+  slowlog_timeout: 2s
+  php_admin_value:
+    memory_limit: 512M
+hooks:
+  post-link:
+    - composer install
+    - php artisan key:generate
+  pre-unlink: php artisan db:wipe
+`)
+	os.Mkdir(filepath.Join(dir, "public"), 0755)
+
+	res, err := Validate(dir, ValidateOptions{PHPVersions: []string{"8.3", "8.2"}, Plugins: []string{"redis"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || len(res.Issues) != 0 {
+		t.Errorf("expected no issues, got %v", res.Issues)
+	}
+}
+
+func TestValidateFPM(t *testing.T) {
+	dir := writeConfig(t, "fpm:\n  pm: dynamic\n  max_children: 2\n  max_spare_servers: 4\n")
+	res, err := Validate(dir, ValidateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid || len(res.Issues) != 1 || res.Issues[0].Field != "fpm" || res.Issues[0].Line != 2 {
+		t.Errorf("expected a positioned fpm error, got %v", res.Issues)
+	}
+	if _, err := Detect(dir); err == nil {
+		t.Error("Detect accepted invalid fpm settings")
+	}
+}
+
+func TestHooksAcceptStringOrList(t *testing.T) {
+	dir := writeConfig(t, "hooks:\n  post-link: [composer install, npm ci]\n  pre-unlink: php artisan db:wipe\n  post-deploy: echo\n")
+
+	conf, err := Detect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Hooks["post-link"]) != 2 || conf.Hooks["pre-unlink"][0] != "php artisan db:wipe" {
+		t.Errorf("hooks not parsed: %v", conf.Hooks)
+	}
+
+	res, _ := Validate(dir, ValidateOptions{})
+	if res.Valid || len(res.Issues) != 1 || res.Issues[0].Field != "hooks.post-deploy" {
+		t.Errorf("expected an error for the unknown hook event, got %v", res.Issues)
+	}
+}
+
+func TestSchemaListsAllDrivers(t *testing.T) {
+	var schema struct {
+		Properties struct {
+			Driver struct {
+				Enum []string `json:"enum"`
+			} `json:"driver"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(schema.Properties.Driver.Enum, ","), strings.Join(drivers.Names(), ","); got != want {
+		t.Errorf("schema driver enum %s does not match registered drivers %s", got, want)
+	}
+}
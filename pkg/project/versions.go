@@ -0,0 +1,204 @@
+package project
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// Runtimes a project can pin
+const (
+	RuntimePHP  = "php"
+	RuntimeNode = "node"
+)
+
+// Requirement is a runtime version a project asks for and where it was found
+type Requirement struct {
+	Runtime    string `json:"runtime"`
+	Constraint string `json:"constraint"`       // e.g. "^8.1", "20", "lts/iron"
+	Source     string `json:"source,omitempty"` // e.g. ".nvmrc", "package.json (engines)"
+}
+
+// versionSource reads one file; an empty result means "not set here"
+type versionSource struct {
+	name string
+	read func(dir string) string
+}
+
+// phpSources and nodeSources are checked in order, the first hit wins.
+// Explicit pins come before ranges.
+var phpSources = []versionSource{
+	{".sld.yaml", func(dir string) string { return sldYAMLKey(dir, "php") }},
+	{".tool-versions", func(dir string) string { return toolVersion(dir, "php") }},
+	{"composer.json", func(dir string) string {
+		var c struct {
+			Require map[string]string `json:"require"`
+		}
+		readJSON(filepath.Join(dir, "composer.json"), &c)
+		return c.Require["php"]
+	}},
+}
+
+var nodeSources = []versionSource{
+	{".sld.yaml", func(dir string) string { return sldYAMLKey(dir, "node") }},
+	{".nvmrc", func(dir string) string { return firstLine(filepath.Join(dir, ".nvmrc")) }},
+	{".node-version", func(dir string) string { return firstLine(filepath.Join(dir, ".node-version")) }},
+	{".tool-versions", func(dir string) string {
+		if v := toolVersion(dir, "nodejs"); v != "" {
+			return v
+		}
+		return toolVersion(dir, "node")
+	}},
+	{"package.json (volta)", func(dir string) string { return packageJSON(dir).Volta.Node }},
+	{"package.json (engines)", func(dir string) string { return packageJSON(dir).Engines.Node }},
+}
+
+// DetectPHP finds the PHP version a project asks for
+func DetectPHP(dir string) Requirement {
+	return detect(dir, RuntimePHP, phpSources)
+}
+
+// DetectNode finds the Node.js version a project asks for
+func DetectNode(dir string) Requirement {
+	return detect(dir, RuntimeNode, nodeSources)
+}
+
+func detect(dir, runtime string, sources []versionSource) Requirement {
+	for _, src := range sources {
+		if v := strings.TrimSpace(src.read(dir)); v != "" {
+			return Requirement{Runtime: runtime, Constraint: v, Source: src.name}
+		}
+	}
+	return Requirement{Runtime: runtime}
+}
+
+// DisplayName is the runtime's name for messages
+func (r Requirement) DisplayName() string {
+	if r.Runtime == RuntimeNode {
+		return "Node.js"
+	}
+	return "PHP"
+}
+
+// IsAlias reports whether a Node constraint names a release line
+// (lts/iron, node, latest) that only the version manager can resolve
+func (r Requirement) IsAlias() bool {
+	if r.Runtime != RuntimeNode {
+		return false
+	}
+	c := strings.ToLower(r.Constraint)
+	return strings.HasPrefix(c, "lts") || c == "node" || c == "latest" || c == "stable" || c == "system"
+}
+
+// Parse reads the constraint in the runtime's own dialect
+func (r Requirement) Parse() (*semver.Constraint, error) {
+	if r.Runtime == RuntimeNode {
+		return semver.ParseNPMConstraint(r.Constraint)
+	}
+	return semver.ParseConstraint(r.Constraint)
+}
+
+// Match returns the installed versions that satisfy the requirement, newest first.
+// PHP is installed per minor line (8.2), Node per exact release (20.11.1).
+func (r Requirement) Match(installed []string) ([]string, error) {
+	c, err := r.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, raw := range installed {
+		v, err := semver.ParseVersion(raw)
+		if err != nil {
+			continue
+		}
+		ok := c.Check(v)
+		if r.Runtime == RuntimePHP {
+			ok = c.AllowsMinor(v)
+		}
+		if ok {
+			matches = append(matches, raw)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return semver.MustParseVersion(matches[i]).Compare(semver.MustParseVersion(matches[j])) > 0
+	})
+	return matches, nil
+}
+
+func sldYAMLKey(dir, key string) string {
+	data, err := os.ReadFile(filepath.Join(dir, ".sld.yaml"))
+	if err != nil {
+		return ""
+	}
+	// Nodes keep the raw text, so an unquoted php: 8.10 isn't read as 8.1
+	var doc map[string]yaml.Node
+	if yaml.Unmarshal(data, &doc) != nil {
+		return ""
+	}
+	if n, ok := doc[key]; ok && n.Kind == yaml.ScalarNode && n.Tag != "!!null" {
+		return n.Value
+	}
+	return ""
+}
+
+// toolVersion reads an asdf / mise .tool-versions entry ("nodejs 20.11.1")
+func toolVersion(dir, tool string) string {
+	f, err := os.Open(filepath.Join(dir, ".tool-versions"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == tool {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+func firstLine(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+type packageManifest struct {
+	Engines struct {
+		Node string `json:"node"`
+	} `json:"engines"`
+	Volta struct {
+		Node string `json:"node"`
+	} `json:"volta"`
+}
+
+func packageJSON(dir string) packageManifest {
+	var pkg packageManifest
+	readJSON(filepath.Join(dir, "package.json"), &pkg)
+	return pkg
+}
+
+func readJSON(path string, v interface{}) {
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, v)
+	}
+}
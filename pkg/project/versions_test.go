@@ -0,0 +1,80 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectNodeSources(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  Requirement
+	}{
+		{"nvmrc", map[string]string{".nvmrc": "v20\n", "package.json": `{"engines":{"node":">=18"}}`},
+			Requirement{RuntimeNode, "v20", ".nvmrc"}},
+		{"node-version", map[string]string{".node-version": "18.19.0"},
+			Requirement{RuntimeNode, "18.19.0", ".node-version"}},
+		{"tool-versions", map[string]string{".tool-versions": "# pinned\nphp 8.2.10\nnodejs 20.11.1\n"},
+			Requirement{RuntimeNode, "20.11.1", ".tool-versions"}},
+		{"volta before engines", map[string]string{"package.json": `{"engines":{"node":">=18"},"volta":{"node":"20.10.0"}}`},
+			Requirement{RuntimeNode, "20.10.0", "package.json (volta)"}},
+		{"engines", map[string]string{"package.json": `{"engines":{"node":"^18 || ^20"}}`},
+			Requirement{RuntimeNode, "^18 || ^20", "package.json (engines)"}},
+		{"sld.yaml wins", map[string]string{".sld.yaml": "node: 22\n", ".nvmrc": "20"},
+			Requirement{RuntimeNode, "22", ".sld.yaml"}},
+		{"none", map[string]string{"README.md": ""},
+			Requirement{Runtime: RuntimeNode}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, dir, name, content)
+			}
+			if got := DetectNode(dir); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectPHPSources(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "composer.json", `{"require":{"php":"^8.1"}}`)
+	if got := DetectPHP(dir); got.Constraint != "^8.1" || got.Source != "composer.json" {
+		t.Errorf("composer.json not read: %+v", got)
+	}
+
+	writeFile(t, dir, ".tool-versions", "php 8.2.10\n")
+	if got := DetectPHP(dir); got.Constraint != "8.2.10" || got.Source != ".tool-versions" {
+		t.Errorf(".tool-versions should win over composer.json: %+v", got)
+	}
+
+	writeFile(t, dir, ".sld.yaml", "php: 8.10\n")
+	if got := DetectPHP(dir); got.Constraint != "8.10" || got.Source != ".sld.yaml" {
+		t.Errorf(".sld.yaml should win and keep the raw text: %+v", got)
+	}
+}
+
+func TestRequirementMatch(t *testing.T) {
+	node := Requirement{Runtime: RuntimeNode, Constraint: "18"}
+	got, err := node.Match([]string{"16.20.2", "18.17.0", "18.19.0", "20.11.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"18.19.0", "18.17.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("node matches = %v, want %v", got, want)
+	}
+
+	php := Requirement{Runtime: RuntimePHP, Constraint: ">=8.0 <8.3"}
+	got, _ = php.Match([]string{"7.4", "8.3", "8.1", "8.2"})
+	if want := []string{"8.2", "8.1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("php matches = %v, want %v", got, want)
+	}
+
+	if !(Requirement{Runtime: RuntimeNode, Constraint: "lts/iron"}).IsAlias() {
+		t.Error("lts/iron should be an alias")
+	}
+}
@@ -0,0 +1,332 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bound is one end of an interval; a nil version means unbounded
+type bound struct {
+	v         *Version
+	inclusive bool
+}
+
+// interval is the set of versions between lo and hi, less the versions
+// excluded with != in the same AND group
+type interval struct {
+	lo, hi   bound
+	excluded []Version
+}
+
+var anyVersion = interval{}
+
+// Constraint is a parsed Composer constraint: a union of AND groups,
+// each reduced to a single interval
+type Constraint struct {
+	raw       string
+	intervals []interval
+}
+
+// dialect selects between the Composer and npm readings of the same syntax
+type dialect int
+
+const (
+	composer dialect = iota
+	npm
+)
+
+// ParseConstraint understands the Composer syntax: "||" / "|" for OR,
+// spaces or commas for AND, hyphen ranges, ^, ~, wildcards, comparison
+// operators and @stability flags (which are ignored).
+func ParseConstraint(s string) (*Constraint, error) {
+	return parse(s, composer)
+}
+
+// ParseNPMConstraint reads npm / nvm ranges, which differ from Composer in
+// two places: a partial version is a wildcard ("18" is 18.x) and "~1.2"
+// only allows patch releases.
+func ParseNPMConstraint(s string) (*Constraint, error) {
+	return parse(s, npm)
+}
+
+func parse(s string, d dialect) (*Constraint, error) {
+	c := &Constraint{raw: s}
+	if strings.TrimSpace(s) == "" {
+		return nil, fmt.Errorf("empty constraint")
+	}
+
+	for _, group := range splitOr(s) {
+		iv, err := parseAndGroup(group, d)
+		if err != nil {
+			return nil, err
+		}
+		if !iv.empty() {
+			c.intervals = append(c.intervals, iv)
+		}
+	}
+	return c, nil
+}
+
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Check reports whether v satisfies the constraint
+func (c *Constraint) Check(v Version) bool {
+	for _, iv := range c.intervals {
+		if iv.contains(v) && !iv.excludes(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsMinor reports whether some release of the major.minor line
+// satisfies the constraint. Installed runtimes are usually only known by
+// their minor version (php8.2-fpm), so "^8.2.5" accepts "8.2".
+func (c *Constraint) AllowsMinor(v Version) bool {
+	line := interval{
+		lo: bound{v: &Version{v.Major, v.Minor, 0}, inclusive: true},
+		hi: bound{v: &Version{v.Major, v.Minor + 1, 0}},
+	}
+	for _, iv := range c.intervals {
+		if !iv.intersect(line).empty() {
+			return true
+		}
+	}
+	return false
+}
+
+func splitOr(s string) []string {
+	s = strings.ReplaceAll(s, "||", "|")
+	return strings.Split(s, "|")
+}
+
+// parseAndGroup intersects all comparators of one OR branch
+func parseAndGroup(group string, d dialect) (interval, error) {
+	group = strings.TrimSpace(group)
+	if group == "" {
+		return interval{}, fmt.Errorf("empty constraint in OR group")
+	}
+
+	// Hyphen range: "1.0 - 2.0"
+	if lo, hi, ok := strings.Cut(group, " - "); ok {
+		return hyphenRange(lo, hi)
+	}
+
+	fields := strings.FieldsFunc(group, func(r rune) bool { return r == ' ' || r == ',' })
+
+	// Glue operators separated from their version (">= 8.0")
+	var terms []string
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if strings.Trim(f, "<>=!^~") == "" && i+1 < len(fields) {
+			f += fields[i+1]
+			i++
+		}
+		terms = append(terms, f)
+	}
+
+	result := anyVersion
+	var excluded []Version
+	for _, term := range terms {
+		iv, ex, err := parseTerm(term, d)
+		if err != nil {
+			return interval{}, err
+		}
+		if ex != nil {
+			excluded = append(excluded, *ex)
+			continue
+		}
+		result = result.intersect(iv)
+	}
+	// != only applies to exact checks, so it is kept aside from the bounds
+	result.excluded = excluded
+	return result, nil
+}
+
+// parseTerm turns a single comparator into an interval (or an exclusion for !=)
+func parseTerm(term string, d dialect) (interval, *Version, error) {
+	// Stability flags only affect which releases Composer considers
+	if i := strings.Index(term, "@"); i >= 0 {
+		term = term[:i]
+		if term == "" {
+			return anyVersion, nil, nil
+		}
+	}
+	if strings.HasPrefix(term, "dev-") {
+		return interval{}, nil, fmt.Errorf("branch constraint %q has no version", term)
+	}
+	if term == "*" {
+		return anyVersion, nil, nil
+	}
+
+	for _, op := range []string{">=", "<=", "!=", "<>", "==", ">", "<", "=", "^", "~"} {
+		if !strings.HasPrefix(term, op) {
+			continue
+		}
+		v, n, err := parseParts(term[len(op):])
+		if err != nil {
+			return interval{}, nil, err
+		}
+		if n == 0 {
+			return interval{}, nil, fmt.Errorf("missing version in %q", term)
+		}
+
+		switch op {
+		case ">=":
+			return interval{lo: bound{v: &v, inclusive: true}}, nil, nil
+		case ">":
+			return interval{lo: bound{v: &v}}, nil, nil
+		case "<=":
+			return interval{hi: bound{v: &v, inclusive: true}}, nil, nil
+		case "<":
+			return interval{hi: bound{v: &v}}, nil, nil
+		case "!=", "<>":
+			return interval{}, &v, nil
+		case "^":
+			return caretRange(v, n), nil, nil
+		case "~":
+			return tildeRange(v, n, d), nil, nil
+		default: // =, ==
+			return exactOrWildcard(term[len(op):], v, n, d), nil, nil
+		}
+	}
+
+	v, n, err := parseParts(term)
+	if err != nil {
+		return interval{}, nil, err
+	}
+	return exactOrWildcard(term, v, n, d), nil, nil
+}
+
+// caretRange allows changes that don't modify the left-most non-zero part
+func caretRange(v Version, parts int) interval {
+	switch {
+	case v.Major > 0 || parts == 1:
+		return between(v, Version{v.Major + 1, 0, 0})
+	case v.Minor > 0 || parts == 2:
+		return between(v, Version{0, v.Minor + 1, 0})
+	}
+	return between(v, Version{0, 0, v.Patch + 1})
+}
+
+// tildeRange allows the last given part to increase: ~8.1 is >=8.1 <9.0,
+// ~8.1.2 is >=8.1.2 <8.2.0. npm keeps ~8.1 within 8.1.x.
+func tildeRange(v Version, parts int, d dialect) interval {
+	if parts >= 3 || (parts == 2 && d == npm) {
+		return between(v, Version{v.Major, v.Minor + 1, 0})
+	}
+	return between(v, Version{v.Major + 1, 0, 0})
+}
+
+// exactOrWildcard handles "8.1.2", "8.1.*" and "8.*". A bare version with
+// missing parts ("8.1") is an exact match on 8.1.0 in Composer and 8.1.x in npm.
+func exactOrWildcard(raw string, v Version, parts int, d dialect) interval {
+	wildcard := strings.ContainsAny(raw, "*xX") || d == npm
+	if !wildcard || parts == 3 {
+		return interval{lo: bound{v: &v, inclusive: true}, hi: bound{v: &v, inclusive: true}}
+	}
+	switch parts {
+	case 0:
+		return anyVersion
+	case 1:
+		return between(v, Version{v.Major + 1, 0, 0})
+	default:
+		return between(v, Version{v.Major, v.Minor + 1, 0})
+	}
+}
+
+// hyphenRange is inclusive; a partial upper bound includes that whole line
+func hyphenRange(loRaw, hiRaw string) (interval, error) {
+	lo, n, err := parseParts(loRaw)
+	if err != nil || n == 0 {
+		return interval{}, fmt.Errorf("invalid range start %q", loRaw)
+	}
+	hi, n, err := parseParts(hiRaw)
+	if err != nil || n == 0 {
+		return interval{}, fmt.Errorf("invalid range end %q", hiRaw)
+	}
+
+	switch n {
+	case 1:
+		return between(lo, Version{hi.Major + 1, 0, 0}), nil
+	case 2:
+		return between(lo, Version{hi.Major, hi.Minor + 1, 0}), nil
+	}
+	return interval{lo: bound{v: &lo, inclusive: true}, hi: bound{v: &hi, inclusive: true}}, nil
+}
+
+// between is [lo, hi)
+func between(lo, hi Version) interval {
+	return interval{lo: bound{v: &lo, inclusive: true}, hi: bound{v: &hi}}
+}
+
+func (iv interval) contains(v Version) bool {
+	if iv.lo.v != nil {
+		c := v.Compare(*iv.lo.v)
+		if c < 0 || (c == 0 && !iv.lo.inclusive) {
+			return false
+		}
+	}
+	if iv.hi.v != nil {
+		c := v.Compare(*iv.hi.v)
+		if c > 0 || (c == 0 && !iv.hi.inclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+func (iv interval) excludes(v Version) bool {
+	for _, x := range iv.excluded {
+		if v.Compare(x) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (iv interval) intersect(o interval) interval {
+	return interval{lo: tighterLower(iv.lo, o.lo), hi: tighterUpper(iv.hi, o.hi)}
+}
+
+func (iv interval) empty() bool {
+	if iv.lo.v == nil || iv.hi.v == nil {
+		return false
+	}
+	c := iv.lo.v.Compare(*iv.hi.v)
+	return c > 0 || (c == 0 && !(iv.lo.inclusive && iv.hi.inclusive))
+}
+
+func tighterLower(a, b bound) bound {
+	if a.v == nil {
+		return b
+	}
+	if b.v == nil {
+		return a
+	}
+	switch c := a.v.Compare(*b.v); {
+	case c > 0:
+		return a
+	case c < 0:
+		return b
+	}
+	return bound{v: a.v, inclusive: a.inclusive && b.inclusive}
+}
+
+func tighterUpper(a, b bound) bound {
+	if a.v == nil {
+		return b
+	}
+	if b.v == nil {
+		return a
+	}
+	switch c := a.v.Compare(*b.v); {
+	case c < 0:
+		return a
+	case c > 0:
+		return b
+	}
+	return bound{v: a.v, inclusive: a.inclusive && b.inclusive}
+}
@@ -0,0 +1,124 @@
+package semver
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"^8.1", "8.1.0", true},
+		{"^8.1", "8.4.2", true},
+		{"^8.1", "9.0.0", false},
+		{"^8.1", "8.0.9", false},
+		{"^8.2.5", "8.2.4", false},
+		{"^8.2.5", "8.2.5", true},
+		{"^0.3", "0.3.9", true},
+		{"^0.3", "0.4.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"~8.1", "8.9.0", true},
+		{"~8.1", "9.0.0", false},
+		{"~8.1.2", "8.1.9", true},
+		{"~8.1.2", "8.2.0", false},
+		{"8.1.*", "8.1.30", true},
+		{"8.1.*", "8.2.0", false},
+		{"8.*", "8.4.0", true},
+		{"*", "5.6.0", true},
+		{">=8.0 <8.3", "8.2.9", true},
+		{">=8.0 <8.3", "8.3.0", false},
+		{">=8.0, <8.3", "7.4.0", false},
+		{">= 8.0", "8.0.0", true},
+		{"^7.4|^8.0", "7.4.33", true},
+		{"^7.4 || ^8.0", "8.3.0", true},
+		{"^7.4|^8.0", "7.3.0", false},
+		{"8.0 - 8.2", "8.2.14", true},
+		{"8.0 - 8.2", "8.3.0", false},
+		{"8.0.0 - 8.2.0", "8.2.1", false},
+		{">=8.1@dev", "8.1.0", true},
+		{"^8.1@stable", "8.2.0", true},
+		{">=8.0 !=8.1.0", "8.1.0", false},
+		{"^8.0 != 8.1.0 || 8.1.0", "8.1.0", true},
+		{"^8.0 != 8.1.0 || 8.2.0", "8.1.0", false},
+		{"v8.1.2", "8.1.2", true},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("%q: %v", tt.constraint, err)
+			continue
+		}
+		if got := c.Check(MustParseVersion(tt.version)); got != tt.want {
+			t.Errorf("%q.Check(%s) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestAllowsMinor(t *testing.T) {
+	tests := []struct {
+		constraint string
+		minor      string
+		want       bool
+	}{
+		{"^8.2.5", "8.2", true},
+		{"^8.2.5", "8.1", false},
+		{">=8.0 <8.3", "8.3", false},
+		{">=8.0 <8.3", "8.2", true},
+		{"~8.1", "8.3", true},
+		{"8.1", "8.1", true},
+		{"8.1.*", "8.1", true},
+		{"^7.4|^8.0", "8.4", true},
+		{"^7.4|^8.0", "7.3", false},
+		{">8.1", "8.1", true}, // 8.1.1 satisfies it
+		{"<8.1", "8.1", false},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("%q: %v", tt.constraint, err)
+			continue
+		}
+		if got := c.AllowsMinor(MustParseVersion(tt.minor)); got != tt.want {
+			t.Errorf("%q.AllowsMinor(%s) = %v, want %v", tt.constraint, tt.minor, got, tt.want)
+		}
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, s := range []string{"", "^", "dev-main", "abc", "^8.1 ||"} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestNPMDialect(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"18", "18.19.0", true},
+		{"18", "19.0.0", false},
+		{"v20.11", "20.11.1", true},
+		{"~18.17", "18.19.0", false},
+		{"~18.17", "18.17.5", true},
+		{">=18.0.0 <21", "20.11.1", true},
+		{"^18 || ^20", "20.0.0", true},
+		{"18.x", "18.2.0", true},
+		{"20.11.1", "20.11.2", false},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseNPMConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("%q: %v", tt.constraint, err)
+			continue
+		}
+		if got := c.Check(MustParseVersion(tt.version)); got != tt.want {
+			t.Errorf("%q.Check(%s) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Package semver parses Composer version constraints ("^7.4|^8.0",
+// ">=8.0 <8.3", "~8.1", "8.1.*") and matches them against versions.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a release number. Parts beyond patch and pre-release
+// suffixes are ignored, which is enough for PHP and Node runtimes.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion reads "8", "8.1", "v8.1.2" or "8.1.2-RC1"
+func ParseVersion(s string) (Version, error) {
+	v, parts, err := parseParts(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if parts == 0 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	return v, nil
+}
+
+// MustParseVersion is ParseVersion for known-good literals
+func MustParseVersion(s string) Version {
+	v, err := ParseVersion(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Compare returns -1, 0 or 1
+func (v Version) Compare(o Version) int {
+	for _, d := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if d[0] < d[1] {
+			return -1
+		}
+		if d[0] > d[1] {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// parseParts reads up to three numeric parts and reports how many were given.
+// A "*" or "x" part stops parsing and counts as missing.
+func parseParts(s string) (Version, int, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i] // Pre-release / build suffix
+	}
+
+	var nums [3]int
+	n := 0
+	for _, p := range strings.Split(s, ".") {
+		if p == "*" || p == "x" || p == "X" {
+			break
+		}
+		num, err := strconv.Atoi(p)
+		if err != nil || num < 0 {
+			return Version{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+		if n < 3 {
+			nums[n] = num
+		}
+		n++
+	}
+	if n > 3 {
+		n = 3
+	}
+	return Version{nums[0], nums[1], nums[2]}, n, nil
+}
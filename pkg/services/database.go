@@ -22,11 +22,11 @@ type DatabaseService struct {
 }
 
 // NewDatabaseService creates a new database service
-func NewDatabaseService() *DatabaseService {
+func NewDatabaseService(snapDir string) *DatabaseService {
 	// Default to MySQL for now
 	return &DatabaseService{
 		driver:  NewMySQLDriver(),
-		SnapDir: "/var/lib/sld/snapshots",
+		SnapDir: snapDir,
 	}
 }
 
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
+	"strconv"
 	"syscall"
 )
 
@@ -39,3 +41,43 @@ func getPathOwner(path string) (int, int, error) {
 		path = parent
 	}
 }
+
+// PathOwner returns the name of the user owning path, or "" when that is
+// root or unknown
+func PathOwner(path string) string {
+	uid, _, err := getPathOwner(path)
+	if err != nil || uid == 0 {
+		return ""
+	}
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+// shellCommand runs a command line through the POSIX shell
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("/bin/sh", "-c", command)
+}
+
+// setProcessGroup puts the command in its own process group so that
+// everything it spawns can be signalled together
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// signalProcessGroup sends SIGTERM (or SIGKILL when force is set) to the group
+func signalProcessGroup(cmd *exec.Cmd, force bool) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+	return syscall.Kill(-cmd.Process.Pid, sig)
+}
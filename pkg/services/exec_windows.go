@@ -17,3 +17,24 @@ func prepareCommand(cmd *exec.Cmd, uid, gid int, env []string) {
 func getPathOwner(path string) (int, int, error) {
 	return 0, 0, nil
 }
+
+// PathOwner is unknown on Windows
+func PathOwner(path string) string {
+	return ""
+}
+
+// shellCommand runs a command line through cmd.exe
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("cmd", "/C", command)
+}
+
+// setProcessGroup is a no-op on Windows
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup kills the process; Windows has no graceful equivalent
+func signalProcessGroup(cmd *exec.Cmd, force bool) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
@@ -37,6 +37,7 @@ type HealerService struct {
 	activeIssues map[string]HealerIssue
 	mu           sync.RWMutex
 	lastAnalyses map[string]time.Time // Debounce mechanism
+	fixes        map[string]func(arg string) error
 }
 
 func NewHealerService(bus *events.Bus) *HealerService {
@@ -44,9 +45,18 @@ func NewHealerService(bus *events.Bus) *HealerService {
 		Bus:          bus,
 		activeIssues: make(map[string]HealerIssue),
 		lastAnalyses: make(map[string]time.Time),
+		fixes:        make(map[string]func(arg string) error),
 	}
 }
 
+// RegisterFix lets other packages fix their own issues. An issue with
+// FixAction "name:arg" is resolved by calling fn(arg).
+func (h *HealerService) RegisterFix(name string, fn func(arg string) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fixes[name] = fn
+}
+
 // Start listens to log entries
 func (h *HealerService) Start() {
 	h.Bus.Subscribe(events.LogEntry, h.handleLogEntry)
@@ -82,7 +92,7 @@ func (h *HealerService) analyze(entry LogEntryData) {
 			port = "3306"
 		}
 
-		h.reportIssue(HealerIssue{
+		h.ReportIssue(HealerIssue{
 			ID:          fmt.Sprintf("port-conflict-%s", port),
 			Title:       fmt.Sprintf("Port %s is Blocked", port),
 			Description: fmt.Sprintf("Another service is using port %s, preventing start.", port),
@@ -99,14 +109,14 @@ func (h *HealerService) analyze(entry LogEntryData) {
 		// Extract function name to guess extension
 		// Example: "Call to undefined function imagettftext()" -> gd
 		if strings.Contains(msg, "imagettftext") || strings.Contains(msg, "imagecreate") {
-			h.reportIssue(HealerIssue{
+			h.ReportIssue(HealerIssue{
 				ID:          "missing-ext-gd",
 				Title:       "Missing PHP Extension: GD",
 				Description: "Your code requires the GD image library.",
 				Severity:    SeverityWarning,
 				Source:      entry.Source,
-				FixAction:   "install_ext_gd",
-				CanAutoFix:  true, // If we have sudo
+				FixAction:   "install_php_ext:gd", // Registered by the daemon, for the default PHP version
+				CanAutoFix:  true,
 			})
 		}
 		// Add more common ones (curl, mbstring, etc)
@@ -117,7 +127,7 @@ func (h *HealerService) analyze(entry LogEntryData) {
 	if strings.Contains(msg, "permission denied") || strings.Contains(msg, "access denied") {
 		// Try to extract path
 		// Simplistic extraction logic
-		h.reportIssue(HealerIssue{
+		h.ReportIssue(HealerIssue{
 			ID:          fmt.Sprintf("perm-error-%d", time.Now().Unix()),
 			Title:       "Permission Denied",
 			Description: "The application cannot write to a file or directory.",
@@ -130,7 +140,9 @@ func (h *HealerService) analyze(entry LogEntryData) {
 	}
 }
 
-func (h *HealerService) reportIssue(issue HealerIssue) {
+// ReportIssue records an issue and announces it. The same ID is reported
+// at most once a minute.
+func (h *HealerService) ReportIssue(issue HealerIssue) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -153,6 +165,22 @@ func (h *HealerService) reportIssue(issue HealerIssue) {
 	})
 }
 
+// ClearIssue drops an issue that went away on its own
+func (h *HealerService) ClearIssue(issueID string) {
+	h.mu.Lock()
+	_, ok := h.activeIssues[issueID]
+	delete(h.activeIssues, issueID)
+	delete(h.lastAnalyses, issueID)
+	h.mu.Unlock()
+
+	if ok {
+		h.Bus.Publish(events.Event{
+			Type:    events.HealerIssueResolved,
+			Payload: issueID,
+		})
+	}
+}
+
 // GetActiveIssues returns all unsolved issues
 func (h *HealerService) GetActiveIssues() []HealerIssue {
 	h.mu.RLock()
@@ -181,13 +209,18 @@ func (h *HealerService) ResolveIssue(issueID string) error {
 	case strings.HasPrefix(issue.FixAction, "kill_port_"):
 		port := strings.TrimPrefix(issue.FixAction, "kill_port_")
 		err = h.killProcessOnPort(port)
-	case issue.FixAction == "install_ext_gd":
-		err = h.installPackage("php-gd")
 	case issue.FixAction == "fix_permissions_generic":
 		// No-op or guide user
 		return fmt.Errorf("automatic permission fix not yet implemented for safety")
 	default:
-		return fmt.Errorf("unknown fix action: %s", issue.FixAction)
+		name, arg, _ := strings.Cut(issue.FixAction, ":")
+		h.mu.RLock()
+		fix, ok := h.fixes[name]
+		h.mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("unknown fix action: %s", issue.FixAction)
+		}
+		err = fix(arg)
 	}
 
 	if err != nil {
@@ -213,10 +246,3 @@ func (h *HealerService) killProcessOnPort(port string) error {
 	// Might need sudo if we are not root (daemon usually is root or has caps)
 	return cmd.Run()
 }
-
-func (h *HealerService) installPackage(pkg string) error {
-	// Assumes apt-get for now (User's OS is Linux)
-	// DEBIAN_FRONTEND=noninteractive
-	cmd := exec.Command("apt-get", "install", "-y", pkg)
-	return cmd.Run()
-}
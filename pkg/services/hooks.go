@@ -0,0 +1,243 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+)
+
+// HookEvent names a point in a project's lifecycle
+type HookEvent string
+
+const (
+	HookPostCreate HookEvent = "post-create" // After the dashboard created a project
+	HookPostLink   HookEvent = "post-link"   // After `sld link`, and for each new site `sld park` picks up
+	HookPreUnlink  HookEvent = "pre-unlink"  // Before `sld unlink` removes the site
+)
+
+// HookEvents lists every supported event
+var HookEvents = []HookEvent{HookPostCreate, HookPostLink, HookPreUnlink}
+
+// HookContext describes the site a hook runs for. It is exposed to hooks
+// as SLD_HOOK, SLD_SITE, SLD_DOMAIN and SLD_PATH.
+type HookContext struct {
+	Site   string
+	Domain string
+	Path   string
+}
+
+// HookRun is the outcome of one hook command
+type HookRun struct {
+	Event    HookEvent `json:"event"`
+	Site     string    `json:"site"`
+	Source   string    `json:"source"` // ".sld.yaml" or the global hook file
+	Command  string    `json:"command"`
+	ExitCode int       `json:"exit_code"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// HookOutput is published for every line a hook prints
+type HookOutput struct {
+	Event   HookEvent `json:"event"`
+	Site    string    `json:"site"`
+	Command string    `json:"command"`
+	Line    string    `json:"line"`
+	IsError bool      `json:"is_error"`
+}
+
+// FailedHooks returns the runs that did not succeed
+func FailedHooks(runs []HookRun) []HookRun {
+	var failed []HookRun
+	for _, r := range runs {
+		if r.Error != "" {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// HookRunner runs global hooks from Dir and per-project hooks from .sld.yaml,
+// streaming their output through the event bus
+type HookRunner struct {
+	events  *events.Bus
+	Dir     string        // Global hooks: <Dir>/<event> and <Dir>/<event>.d/*
+	Timeout time.Duration // Per command
+}
+
+// NewHookRunner creates a runner for the global hooks in dir
+func NewHookRunner(eventBus *events.Bus, dir string) *HookRunner {
+	return &HookRunner{
+		events:  eventBus,
+		Dir:     dir,
+		Timeout: 10 * time.Minute,
+	}
+}
+
+// Run executes the global hooks for event and then the project's commands,
+// in the project directory. It stops at the first failure.
+func (h *HookRunner) Run(event HookEvent, ctx HookContext, commands []string, env map[string]string) []HookRun {
+	type hook struct {
+		source, command string
+		build           func() *exec.Cmd
+	}
+	var hooks []hook
+	for _, file := range h.globalHooks(event) {
+		hooks = append(hooks, hook{file, file, func() *exec.Cmd { return exec.Command(file) }})
+	}
+	for _, c := range commands {
+		if strings.TrimSpace(c) != "" {
+			hooks = append(hooks, hook{".sld.yaml", c, func() *exec.Cmd { return shellCommand(c) }})
+		}
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	uid, gid := 0, 0
+	if os.Geteuid() == 0 {
+		uid, gid, _ = getPathOwner(ctx.Path)
+	}
+	hookEnv := processEnv(uid, env)
+	hookEnv = append(hookEnv,
+		"SLD_HOOK="+string(event),
+		"SLD_SITE="+ctx.Site,
+		"SLD_DOMAIN="+ctx.Domain,
+		"SLD_PATH="+ctx.Path,
+	)
+
+	var runs []HookRun
+	for _, hk := range hooks {
+		run := HookRun{Event: event, Site: ctx.Site, Source: hk.source, Command: hk.command}
+		if err := h.exec(event, ctx, hk.command, hk.build(), uid, gid, hookEnv); err != nil {
+			run.ExitCode = -1
+			if code, ok := exitCode(err); ok {
+				run.ExitCode = code
+			}
+			run.Error = err.Error()
+		}
+		runs = append(runs, run)
+		h.events.Publish(events.Event{Type: events.HookFinished, Payload: run})
+		if run.Error != "" {
+			break
+		}
+	}
+	return runs
+}
+
+// exec runs one hook command, publishing each line of output
+func (h *HookRunner) exec(event HookEvent, hc HookContext, command string, cmd *exec.Cmd, uid, gid int, env []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	defer cancel()
+
+	cmd.Dir = hc.Path
+	prepareCommand(cmd, uid, gid, env)
+	cmd.Env = env
+	setProcessGroup(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	stream := func(r io.Reader, isError bool) {
+		defer wg.Done()
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			h.events.Publish(events.Event{
+				Type: events.HookOutput,
+				Payload: HookOutput{
+					Event:   event,
+					Site:    hc.Site,
+					Command: command,
+					Line:    scanner.Text(),
+					IsError: isError,
+				},
+			})
+		}
+	}
+	wg.Add(2)
+	go stream(stdout, false)
+	go stream(stderr, true)
+
+	// Watch the deadline ourselves: the pipes stay open until the process group dies
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			signalProcessGroup(cmd, true)
+		case <-done:
+		}
+	}()
+	wg.Wait()
+	close(done)
+
+	err = cmd.Wait()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", h.Timeout)
+	}
+	return err
+}
+
+// globalHooks lists the executable hook files for event, <event> first
+// and then <event>.d/* in name order
+func (h *HookRunner) globalHooks(event HookEvent) []string {
+	if h.Dir == "" {
+		return nil
+	}
+
+	var files []string
+	single := filepath.Join(h.Dir, string(event))
+	if isExecutable(single) {
+		files = append(files, single)
+	}
+
+	entries, _ := os.ReadDir(single + ".d")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(single+".d", name)
+		if !strings.HasPrefix(name, ".") && isExecutable(path) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// isExecutable reports whether path is a runnable file (any file on Windows)
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
+
+// exitCode extracts the exit status from an *exec.ExitError
+func exitCode(err error) (int, bool) {
+	if e, ok := err.(interface{ ExitCode() int }); ok {
+		return e.ExitCode(), true
+	}
+	return 0, false
+}
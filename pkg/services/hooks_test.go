@@ -0,0 +1,79 @@
+//go:build !windows
+
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+)
+
+func TestHookRunnerOrderOutputAndFailure(t *testing.T) {
+	hooksDir := t.TempDir()
+	project := t.TempDir()
+
+	os.WriteFile(filepath.Join(hooksDir, "post-link"), []byte("#!/bin/sh\necho global $SLD_SITE $SLD_DOMAIN\n"), 0755)
+	os.Mkdir(filepath.Join(hooksDir, "post-link.d"), 0755)
+	os.WriteFile(filepath.Join(hooksDir, "post-link.d", "10-db"), []byte("#!/bin/sh\necho db for $SLD_HOOK\n"), 0755)
+	os.WriteFile(filepath.Join(hooksDir, "post-link.d", "README"), []byte("not executable"), 0644)
+
+	bus := events.NewBus()
+	var mu sync.Mutex
+	var lines []string
+	bus.Subscribe(events.HookOutput, func(e events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, e.Payload.(HookOutput).Line)
+	})
+
+	runner := NewHookRunner(bus, hooksDir)
+	ctx := HookContext{Site: "shop", Domain: "shop.test", Path: project}
+	runs := runner.Run(HookPostLink, ctx, []string{"echo $APP_ENV in $(basename $PWD)", "exit 4", "echo never"}, map[string]string{"APP_ENV": "local"})
+
+	if len(runs) != 4 {
+		t.Fatalf("expected the run to stop at the failing hook, got %+v", runs)
+	}
+	if runs[0].Source != filepath.Join(hooksDir, "post-link") || runs[2].Source != ".sld.yaml" {
+		t.Errorf("global hooks should run first: %+v", runs)
+	}
+	failed := FailedHooks(runs)
+	if len(failed) != 1 || failed[0].Command != "exit 4" || failed[0].ExitCode != 4 {
+		t.Errorf("unexpected failures: %+v", failed)
+	}
+
+	want := []string{"global shop shop.test", "db for post-link", "local in " + filepath.Base(project)}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lines) != len(want) {
+		t.Fatalf("got output %q, want %q", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestHookRunnerTimeout(t *testing.T) {
+	runner := NewHookRunner(events.NewBus(), "")
+	runner.Timeout = 100 * time.Millisecond
+
+	start := time.Now()
+	runs := runner.Run(HookPreUnlink, HookContext{Site: "slow", Path: t.TempDir()}, []string{"sleep 30"}, nil)
+	if len(runs) != 1 || runs[0].Error == "" {
+		t.Fatalf("expected a timeout failure, got %+v", runs)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("hook was not killed at the timeout")
+	}
+}
+
+func TestHookRunnerNoHooks(t *testing.T) {
+	if runs := NewHookRunner(events.NewBus(), t.TempDir()).Run(HookPostCreate, HookContext{Path: t.TempDir()}, nil, nil); runs != nil {
+		t.Errorf("expected no runs, got %+v", runs)
+	}
+}
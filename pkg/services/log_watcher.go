@@ -34,6 +34,8 @@ const (
 	LogSourceNginxAccess LogSource = "nginx-access"
 	LogSourcePHPFPM      LogSource = "php-fpm"
 	LogSourceLaravel     LogSource = "laravel"
+	LogSourceProcess     LogSource = "process" // Prefix for supervised workers, e.g. "process:blog:queue"
+	LogSourceCerts       LogSource = "certs"   // SLD's own certificate checks rather than a log
 )
 
 // LogEntryData represents a single log line with metadata
@@ -207,6 +209,29 @@ func (w *LogWatcher) processLogs(source LogSource, t *tail.Tail) {
 	}
 }
 
+// Emit broadcasts a line that did not come from a tailed file, such as
+// the output of a supervised process
+func (w *LogWatcher) Emit(source LogSource, level LogLevel, line string) LogEntryData {
+	w.mu.Lock()
+	w.counter++
+	id := fmt.Sprintf("%s-%d-%d", source, time.Now().UnixNano(), w.counter)
+	w.mu.Unlock()
+
+	entry := LogEntryData{
+		ID:        id,
+		Source:    source,
+		Level:     level,
+		Message:   w.parseMessage(source, line),
+		Timestamp: time.Now().Format(time.RFC3339),
+		Raw:       line,
+	}
+	w.Bus.Publish(events.Event{
+		Type:    events.LogEntry,
+		Payload: entry,
+	})
+	return entry
+}
+
 // parseLogLevel extracts log level from a log line
 func (w *LogWatcher) parseLogLevel(source LogSource, line string) LogLevel {
 	lowerLine := strings.ToLower(line)
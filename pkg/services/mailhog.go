@@ -168,6 +168,44 @@ func (p *MailHogPlugin) UIPort() int {
 	return 8025
 }
 
+// mailhogSMTPAddr is where MailHog accepts mail
+const mailhogSMTPAddr = "127.0.0.1:1025"
+
+// PHPExtensions is empty: mail() only needs sendmail_path
+func (p *MailHogPlugin) PHPExtensions() []string {
+	return nil
+}
+
+// PHPConfig points mail() at MailHog's bundled sendmail, so mail sent
+// from any site is captured
+func (p *MailHogPlugin) PHPConfig() (map[string]string, error) {
+	bin := p.binary()
+	if bin == "" {
+		return nil, fmt.Errorf("mailhog is not installed")
+	}
+	return map[string]string{
+		"sendmail_path": fmt.Sprintf("%s sendmail --smtp-addr=%s", bin, mailhogSMTPAddr),
+	}, nil
+}
+
+// binary is the absolute path of the MailHog executable, empty when it
+// isn't installed
+func (p *MailHogPlugin) binary() string {
+	for _, name := range []string{"MailHog", "mailhog"} {
+		if path, err := exec.LookPath(name); err == nil {
+			if abs, err := filepath.Abs(path); err == nil {
+				return abs
+			}
+			return path
+		}
+	}
+	binPath := filepath.Join(p.dataDir, "mailhog")
+	if _, err := os.Stat(binPath); err == nil {
+		return binPath
+	}
+	return ""
+}
+
 // Health checks if MailHog is responding
 func (p *MailHogPlugin) Health() (bool, string) {
 	if p.Status() != plugins.StatusRunning {
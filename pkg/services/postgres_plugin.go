@@ -119,3 +119,12 @@ func (p *PostgresPlugin) Health() (bool, string) {
 	}
 	return false, "Stopped"
 }
+
+// PHPExtensions enables the PostgreSQL drivers for PDO and pg_* functions
+func (p *PostgresPlugin) PHPExtensions() []string {
+	return []string{"pdo_pgsql", "pgsql"}
+}
+
+func (p *PostgresPlugin) PHPConfig() (map[string]string, error) {
+	return nil, nil
+}
@@ -1,7 +1,6 @@
 package services
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -917,30 +916,3 @@ func (pm *ProjectManager) DiscardGhost(path string, dbName string, dbService int
 
 	return nil
 }
-
-// PackageJSON represents package.json structure for engine parsing
-type PackageJSON struct {
-	Engines struct {
-		Node string `json:"node"`
-	} `json:"engines"`
-}
-
-// ScanNodeRequirement reads package.json to find node version requirement
-func (pm *ProjectManager) ScanNodeRequirement(projectPath string) (string, error) {
-	pkgPath := filepath.Join(projectPath, "package.json")
-	if _, err := os.Stat(pkgPath); os.IsNotExist(err) {
-		return "", nil // No package.json, no requirement
-	}
-
-	data, err := os.ReadFile(pkgPath)
-	if err != nil {
-		return "", err
-	}
-
-	var pkg PackageJSON
-	if err := json.Unmarshal(data, &pkg); err != nil {
-		return "", nil // Ignore invalid json
-	}
-
-	return pkg.Engines.Node, nil
-}
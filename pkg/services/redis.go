@@ -159,6 +159,15 @@ func (p *RedisPlugin) Health() (bool, string) {
 	return false, "Redis PING failed"
 }
 
+// PHPExtensions enables phpredis so sites can reach the server
+func (p *RedisPlugin) PHPExtensions() []string {
+	return []string{"redis"}
+}
+
+func (p *RedisPlugin) PHPConfig() (map[string]string, error) {
+	return nil, nil
+}
+
 // Logs returns the last N lines of Redis logs
 func (p *RedisPlugin) Logs(lines int) ([]string, error) {
 	logPath := filepath.Join(p.dataDir, "redis.log")
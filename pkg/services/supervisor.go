@@ -0,0 +1,429 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ProcessState is the lifecycle state of a supervised process
+type ProcessState string
+
+const (
+	ProcessRunning ProcessState = "running"
+	ProcessBackoff ProcessState = "backoff" // Exited, waiting to be restarted
+	ProcessStopped ProcessState = "stopped"
+)
+
+// ProcessSpec is one named command from .sld.yaml or a Procfile
+type ProcessSpec struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+// ProcessStatus reports a supervised process
+type ProcessStatus struct {
+	Name        string       `json:"name"`
+	Command     string       `json:"command"`
+	State       ProcessState `json:"state"`
+	PID         int          `json:"pid,omitempty"`
+	Restarts    int          `json:"restarts"`
+	StartedAt   time.Time    `json:"started_at,omitzero"`
+	LastExit    string       `json:"last_exit,omitempty"`   // e.g. "exit status 1"
+	NextRestart time.Time    `json:"next_restart,omitzero"` // Set while in backoff
+}
+
+// maxProcessLogLines is how much output each site keeps for `sld proc logs`
+const maxProcessLogLines = 1000
+
+// Supervisor keeps per-site worker processes (queue workers, vite, ...)
+// running, restarting them with exponential backoff when they exit
+type Supervisor struct {
+	logs *LogWatcher
+
+	MinBackoff  time.Duration // First restart delay
+	MaxBackoff  time.Duration // Restart delay cap
+	StableAfter time.Duration // Uptime after which the backoff resets
+	StopTimeout time.Duration // Grace period between SIGTERM and SIGKILL
+
+	mu    sync.Mutex
+	sites map[string]*siteProcesses
+}
+
+type siteProcesses struct {
+	ctl sync.Mutex // Serializes Start and Stop
+
+	mu    sync.Mutex
+	procs map[string]*process
+	lines []LogEntryData // Ring of recent output across the site's processes
+}
+
+type process struct {
+	site string
+	spec ProcessSpec
+	dir  string
+	env  []string
+	uid  int
+	gid  int
+
+	mu     sync.Mutex
+	status ProcessStatus
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewSupervisor creates a supervisor that publishes output through logs
+func NewSupervisor(logs *LogWatcher) *Supervisor {
+	return &Supervisor{
+		logs:        logs,
+		MinBackoff:  time.Second,
+		MaxBackoff:  time.Minute,
+		StableAfter: 30 * time.Second,
+		StopTimeout: 10 * time.Second,
+		sites:       make(map[string]*siteProcesses),
+	}
+}
+
+// Start brings the site's processes in line with specs: missing or changed
+// ones are (re)started and ones no longer declared are stopped.
+// Processes run in dir as the directory's owner, with env added to their environment.
+func (s *Supervisor) Start(site, dir string, specs []ProcessSpec, env map[string]string) error {
+	if len(specs) == 0 {
+		return fmt.Errorf("%s declares no processes (add processes: to .sld.yaml or a Procfile)", site)
+	}
+
+	uid, gid := 0, 0
+	if os.Geteuid() == 0 {
+		uid, gid, _ = getPathOwner(dir)
+	}
+	baseEnv := processEnv(uid, env)
+
+	s.mu.Lock()
+	group, ok := s.sites[site]
+	if !ok {
+		group = &siteProcesses{procs: make(map[string]*process)}
+		s.sites[site] = group
+	}
+	s.mu.Unlock()
+
+	group.ctl.Lock()
+	defer group.ctl.Unlock()
+	current := group.snapshot()
+
+	wanted := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		wanted[spec.Name] = true
+		if p, ok := current[spec.Name]; ok {
+			if p.running() && p.spec == spec && p.dir == dir {
+				continue
+			}
+			p.halt()
+		}
+
+		p := &process{
+			site: site,
+			spec: spec,
+			dir:  dir,
+			env:  baseEnv,
+			uid:  uid,
+			gid:  gid,
+			stop: make(chan struct{}),
+			done: make(chan struct{}),
+		}
+		p.status = ProcessStatus{Name: spec.Name, Command: spec.Command, State: ProcessBackoff}
+		group.mu.Lock()
+		group.procs[spec.Name] = p
+		group.mu.Unlock()
+		go s.run(group, p)
+	}
+
+	for name, p := range current {
+		if !wanted[name] {
+			p.halt()
+			group.mu.Lock()
+			delete(group.procs, name)
+			group.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// Stop terminates every process of a site. Status and logs are kept.
+func (s *Supervisor) Stop(site string) error {
+	group := s.group(site)
+	if group == nil {
+		return fmt.Errorf("no processes running for %s", site)
+	}
+
+	group.ctl.Lock()
+	defer group.ctl.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range group.snapshot() {
+		wg.Add(1)
+		go func(p *process) {
+			defer wg.Done()
+			p.halt()
+		}(p)
+	}
+	wg.Wait()
+	return nil
+}
+
+// Remove stops a site's processes and forgets them
+func (s *Supervisor) Remove(site string) {
+	if s.group(site) == nil {
+		return
+	}
+	s.Stop(site)
+
+	s.mu.Lock()
+	delete(s.sites, site)
+	s.mu.Unlock()
+}
+
+// StopAll stops every supervised process, used on daemon shutdown
+func (s *Supervisor) StopAll() {
+	for _, site := range s.Sites() {
+		s.Stop(site)
+	}
+}
+
+// Sites lists the sites with supervised processes
+func (s *Supervisor) Sites() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sites := make([]string, 0, len(s.sites))
+	for site := range s.sites {
+		sites = append(sites, site)
+	}
+	sort.Strings(sites)
+	return sites
+}
+
+// Status returns the site's processes sorted by name (nil when none were started)
+func (s *Supervisor) Status(site string) []ProcessStatus {
+	group := s.group(site)
+	if group == nil {
+		return nil
+	}
+
+	procs := group.snapshot()
+	statuses := make([]ProcessStatus, 0, len(procs))
+	for _, p := range procs {
+		p.mu.Lock()
+		statuses = append(statuses, p.status)
+		p.mu.Unlock()
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Logs returns up to n of the most recent output lines of a site, oldest first
+func (s *Supervisor) Logs(site string, n int) []LogEntryData {
+	group := s.group(site)
+	if group == nil {
+		return []LogEntryData{}
+	}
+
+	group.mu.Lock()
+	defer group.mu.Unlock()
+	lines := group.lines
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return append([]LogEntryData{}, lines...)
+}
+
+// snapshot copies the process map so it can be walked without holding mu
+func (g *siteProcesses) snapshot() map[string]*process {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	procs := make(map[string]*process, len(g.procs))
+	for name, p := range g.procs {
+		procs[name] = p
+	}
+	return procs
+}
+
+func (s *Supervisor) group(site string) *siteProcesses {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sites[site]
+}
+
+// run starts the process and keeps restarting it until it is halted
+func (s *Supervisor) run(group *siteProcesses, p *process) {
+	defer close(p.done)
+	backoff := s.MinBackoff
+
+	for {
+		cmd := shellCommand(p.spec.Command)
+		cmd.Dir = p.dir
+		prepareCommand(cmd, p.uid, p.gid, p.env)
+		cmd.Env = p.env
+		setProcessGroup(cmd)
+		cmd.Stdout = &lineWriter{emit: func(line string) { s.emit(group, p, LogLevelInfo, line) }}
+		cmd.Stderr = &lineWriter{emit: func(line string) { s.emit(group, p, LogLevelWarning, line) }}
+		cmd.WaitDelay = time.Second // Don't hang on grandchildren holding the pipes
+
+		started := time.Now()
+		exited := make(chan error, 1)
+		if err := cmd.Start(); err != nil {
+			exited <- err
+		} else {
+			p.update(func(st *ProcessStatus) {
+				st.State = ProcessRunning
+				st.PID = cmd.Process.Pid
+				st.StartedAt = started
+				st.NextRestart = time.Time{}
+			})
+			s.emit(group, p, LogLevelInfo, fmt.Sprintf("started (pid %d)", cmd.Process.Pid))
+			go func() { exited <- cmd.Wait() }()
+		}
+
+		select {
+		case <-p.stop:
+			signalProcessGroup(cmd, false)
+			select {
+			case <-exited:
+			case <-time.After(s.StopTimeout):
+				signalProcessGroup(cmd, true)
+				<-exited
+			}
+			p.update(func(st *ProcessStatus) {
+				st.State = ProcessStopped
+				st.PID = 0
+			})
+			s.emit(group, p, LogLevelInfo, "stopped")
+			return
+
+		case err := <-exited:
+			if time.Since(started) >= s.StableAfter {
+				backoff = s.MinBackoff
+			}
+			reason := "exit status 0"
+			if err != nil {
+				reason = err.Error()
+			}
+			p.update(func(st *ProcessStatus) {
+				st.State = ProcessBackoff
+				st.PID = 0
+				st.LastExit = reason
+				st.Restarts++
+				st.NextRestart = time.Now().Add(backoff)
+			})
+			s.emit(group, p, LogLevelError, fmt.Sprintf("exited (%s), restarting in %s", reason, backoff))
+		}
+
+		select {
+		case <-p.stop:
+			p.update(func(st *ProcessStatus) {
+				st.State = ProcessStopped
+				st.NextRestart = time.Time{}
+			})
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > s.MaxBackoff {
+			backoff = s.MaxBackoff
+		}
+	}
+}
+
+// emit records a line in the site's ring and broadcasts it as a log entry
+func (s *Supervisor) emit(group *siteProcesses, p *process, level LogLevel, line string) {
+	source := LogSource(fmt.Sprintf("%s:%s:%s", LogSourceProcess, p.site, p.spec.Name))
+	entry := s.logs.Emit(source, level, line)
+
+	group.mu.Lock()
+	group.lines = append(group.lines, entry)
+	if len(group.lines) > maxProcessLogLines {
+		group.lines = group.lines[len(group.lines)-maxProcessLogLines:]
+	}
+	group.mu.Unlock()
+}
+
+func (p *process) update(fn func(st *ProcessStatus)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fn(&p.status)
+}
+
+func (p *process) running() bool {
+	select {
+	case <-p.done:
+		return false
+	case <-p.stop:
+		return false
+	default:
+		return true
+	}
+}
+
+// halt stops the run loop and waits for the process to exit
+func (p *process) halt() {
+	p.mu.Lock()
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	p.mu.Unlock()
+	<-p.done
+}
+
+// processEnv builds the environment for a worker running as uid
+func processEnv(uid int, extra map[string]string) []string {
+	env := os.Environ()
+	if uid != 0 {
+		if u, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+			path := "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+			path += ":" + filepath.Join(u.HomeDir, ".local/bin")
+			path += ":" + filepath.Join(u.HomeDir, ".composer/vendor/bin")
+			env = []string{
+				"HOME=" + u.HomeDir,
+				"USER=" + u.Username,
+				"LOGNAME=" + u.Username,
+				"PATH=" + path,
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+extra[k])
+	}
+	return env
+}
+
+// lineWriter splits a process's output stream into lines
+type lineWriter struct {
+	emit func(line string)
+	buf  []byte
+}
+
+func (w *lineWriter) Write(data []byte) (int, error) {
+	w.buf = append(w.buf, data...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if line := string(bytes.TrimRight(w.buf[:i], "\r")); line != "" {
+			w.emit(line)
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(data), nil
+}
@@ -0,0 +1,109 @@
+//go:build !windows
+
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
+)
+
+func newTestSupervisor() *Supervisor {
+	s := NewSupervisor(NewLogWatcher(events.NewBus(), nil))
+	s.MinBackoff = 10 * time.Millisecond
+	s.MaxBackoff = 40 * time.Millisecond
+	s.StopTimeout = time.Second
+	return s
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSupervisorRestartsCrashedProcess(t *testing.T) {
+	s := newTestSupervisor()
+	specs := []ProcessSpec{{Name: "worker", Command: "echo working; echo oops >&2; exit 3"}}
+	if err := s.Start("blog", t.TempDir(), specs, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Remove("blog")
+
+	waitFor(t, "restarts", func() bool {
+		st := s.Status("blog")
+		return len(st) == 1 && st[0].Restarts >= 3
+	})
+	if got := s.Status("blog")[0].LastExit; got != "exit status 3" {
+		t.Errorf("unexpected last exit %q", got)
+	}
+
+	var stdout, stderr bool
+	for _, line := range s.Logs("blog", 0) {
+		if line.Source != "process:blog:worker" {
+			t.Fatalf("unexpected source %q", line.Source)
+		}
+		stdout = stdout || (line.Raw == "working" && line.Level == LogLevelInfo)
+		stderr = stderr || (line.Raw == "oops" && line.Level == LogLevelWarning)
+	}
+	if !stdout || !stderr {
+		t.Errorf("output not captured: %+v", s.Logs("blog", 0))
+	}
+}
+
+func TestSupervisorStopAndReconcile(t *testing.T) {
+	s := newTestSupervisor()
+	dir := t.TempDir()
+	specs := []ProcessSpec{
+		{Name: "queue", Command: "sleep 30"},
+		{Name: "vite", Command: "sleep 30"},
+	}
+	if err := s.Start("shop", dir, specs, map[string]string{"APP_ENV": "local"}); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Remove("shop")
+
+	running := func() bool {
+		for _, st := range s.Status("shop") {
+			if st.State != ProcessRunning {
+				return false
+			}
+		}
+		return true
+	}
+	waitFor(t, "processes to start", running)
+	queuePID := s.Status("shop")[0].PID
+
+	// Dropping vite stops it and leaves the unchanged queue worker alone
+	if err := s.Start("shop", dir, specs[:1], nil); err != nil {
+		t.Fatal(err)
+	}
+	st := s.Status("shop")
+	if len(st) != 1 || st[0].Name != "queue" || st[0].PID != queuePID {
+		t.Fatalf("reconcile restarted or kept the wrong processes: %+v", st)
+	}
+
+	if err := s.Stop("shop"); err != nil {
+		t.Fatal(err)
+	}
+	if st := s.Status("shop"); st[0].State != ProcessStopped || st[0].PID != 0 {
+		t.Errorf("expected stopped, got %+v", st[0])
+	}
+	if logs := s.Logs("shop", 1); len(logs) != 1 || !strings.Contains(logs[0].Raw, "stopped") {
+		t.Errorf("stop not logged: %+v", logs)
+	}
+}
+
+func TestSupervisorRequiresProcesses(t *testing.T) {
+	if err := newTestSupervisor().Start("empty", t.TempDir(), nil, nil); err == nil {
+		t.Error("expected an error when no processes are declared")
+	}
+}
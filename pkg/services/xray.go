@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/hpcloud/tail"
 	"github.com/supreme-majesty/supreme-local-dev/pkg/events"
@@ -30,9 +31,9 @@ type XRayService struct {
 	Tail    *tail.Tail
 }
 
-func NewXRayService(bus *events.Bus) *XRayService {
+func NewXRayService(bus *events.Bus, logPath string) *XRayService {
 	return &XRayService{
-		LogPath: "/var/log/nginx/sld-xray.log",
+		LogPath: logPath,
 		Bus:     bus,
 	}
 }
@@ -40,9 +41,10 @@ func NewXRayService(bus *events.Bus) *XRayService {
 func (x *XRayService) Start() error {
 	// Ensure log file exists to prevent tail error
 	if _, err := os.Stat(x.LogPath); os.IsNotExist(err) {
-		os.WriteFile(x.LogPath, []byte(""), 0666)
+		// Nginx's master process opens logs as root, so no world-writable mode is needed
+		os.MkdirAll(filepath.Dir(x.LogPath), 0755)
+		os.WriteFile(x.LogPath, []byte(""), 0644)
 	}
-	os.Chmod(x.LogPath, 0666) // Always ensure it's writable by Nginx
 
 	t, err := tail.TailFile(x.LogPath, tail.Config{
 		Follow: true,
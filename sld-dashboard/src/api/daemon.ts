@@ -1,6 +1,7 @@
 /**
  * SLD Daemon API Client
- * Communicates with the Go daemon running on port 2025
+ * Communicates with the Go daemon on its configured API port (default 2025,
+ * see `api_port` in /etc/sld/config.yaml)
  */
 
 const API_BASE = "/api";
@@ -15,12 +16,43 @@ export interface SLDState {
   php_version: string;
   secure: boolean;
   port: string;
+  active_workspace: string;
+}
+
+export interface Workspace {
+  name: string;
+  active: boolean;
+  paths: number;
+  links: number;
+}
+
+export interface DaemonConfig {
+  config: {
+    home: string;
+    api_port: number;
+    bind_address: string;
+    https_port: number;
+    log_dir: string;
+    projects_dir: string;
+  };
+  api_url: string;
 }
 
 export interface ActionResponse {
   success: boolean;
   message?: string;
   error?: string;
+  hooks?: HookRun[];
+  hook_errors?: string[]; // Link/park/unlink succeeded but these hooks failed
+}
+
+export interface HookRun {
+  event: "post-create" | "post-link" | "pre-unlink";
+  site: string;
+  source: string;
+  command: string;
+  exit_code: number;
+  error?: string;
 }
 
 export interface ServiceStatus {
@@ -35,10 +67,23 @@ export interface Project {
   domain: string;
   phpVersion?: string;
   secure: boolean;
-  type: "parked" | "linked";
+  type: "parked" | "linked" | "proxy";
+  proxy?: string; // Upstream URL for reverse-proxied sites
   creating?: boolean; // true if project is still being created in background
   tags?: string[];
   category?: string;
+  processes?: ProcessStatus[];
+}
+
+export interface ProcessStatus {
+  name: string;
+  command: string;
+  state: "running" | "backoff" | "stopped";
+  pid?: number;
+  restarts: number;
+  started_at?: string;
+  last_exit?: string;
+  next_restart?: string;
 }
 
 export interface HealthCheck {
@@ -60,12 +105,72 @@ export interface HealerIssue {
   can_auto_fix: boolean;
 }
 
+// Outcome of the last nginx config apply
+export interface NginxConfigError {
+  message: string;
+  file?: string;
+  line?: number;
+  text?: string;
+  source?: "sld" | "plugin" | "site";
+  name?: string;
+  output: string;
+}
+
+export interface NginxStatus {
+  path: string;
+  backup: string;
+  applied_at?: string;
+  error?: NginxConfigError;
+}
+
+export interface DNSStatus {
+  enabled: boolean;
+  addr?: string;
+  tld: string;
+  upstreams: string[];
+  listening: boolean; // The server answers for the TLD
+  resolving: boolean; // The system resolver routes the TLD to it
+  error?: string;
+}
+
+export interface CertInfo {
+  kind: "ca" | "shared" | "site";
+  domain?: string;
+  path: string;
+  in_use: boolean; // Served by nginx; the CA always is
+  subject?: string;
+  issuer?: string;
+  sans: string[];
+  not_before?: string;
+  not_after?: string;
+  days_left: number;
+  status: "ok" | "expiring" | "expired" | "mismatch" | "foreign" | "invalid";
+  missing?: string[]; // Served names the certificate doesn't cover
+  error?: string;
+}
+
+export interface PHPExtension {
+  name: string;
+  loaded: boolean;
+  builtin: boolean; // Compiled in, can't be disabled
+  shared: boolean; // Library installed in extension_dir
+  ini_file?: string;
+  package?: string; // e.g. php8.2-redis, or pecl/redis on macOS
+}
+
+export interface PHPIni {
+  version: string;
+  values: Record<string, string>; // Effective settings as PHP-FPM sees them
+  overrides: Record<string, string>; // Set with `sld php ini set`
+}
+
 export interface Plugin {
   id: string;
   name: string;
   description: string;
   version: string;
   installed: boolean;
+  ui_port?: number; // Web UI on localhost, when the plugin has one
   status: "running" | "stopped" | "installing" | "not_installed";
 }
 
@@ -336,6 +441,59 @@ class DaemonApi {
     return this.request<SLDState>("/state");
   }
 
+  // Daemon configuration (ports, bind address, paths)
+  async getConfig(): Promise<DaemonConfig> {
+    return this.request<DaemonConfig>("/config");
+  }
+
+  // Workspaces
+  async getWorkspaces(): Promise<Workspace[]> {
+    return this.request<Workspace[]>("/workspaces");
+  }
+
+  async createWorkspace(name: string, copy = false): Promise<ActionResponse> {
+    return this.request<ActionResponse>("/workspaces", {
+      method: "POST",
+      body: JSON.stringify({ name, copy }),
+    });
+  }
+
+  async useWorkspace(name: string): Promise<ActionResponse> {
+    return this.request<ActionResponse>("/workspaces/use", {
+      method: "POST",
+      body: JSON.stringify({ name }),
+    });
+  }
+
+  // Nginx config status
+  async getNginxStatus(): Promise<NginxStatus> {
+    return this.request<NginxStatus>("/nginx");
+  }
+
+  // Built-in DNS server
+  async getDNSStatus(): Promise<DNSStatus> {
+    return this.request<DNSStatus>("/dns");
+  }
+
+  async getCerts(): Promise<CertInfo[]> {
+    return this.request<CertInfo[]>("/certs");
+  }
+
+  // Process supervisor
+  async getProcesses(site: string): Promise<ProcessStatus[]> {
+    return this.request<ProcessStatus[]>(`/proc?site=${encodeURIComponent(site)}`);
+  }
+
+  async controlProcesses(
+    site: string,
+    action: "start" | "stop" | "restart"
+  ): Promise<ProcessStatus[]> {
+    return this.request<ProcessStatus[]>(`/proc/${action}`, {
+      method: "POST",
+      body: JSON.stringify({ site }),
+    });
+  }
+
   // Project Management
   async park(path: string): Promise<ActionResponse> {
     return this.request<ActionResponse>("/park", {
@@ -365,6 +523,20 @@ class DaemonApi {
     });
   }
 
+  async proxy(name: string, url: string, secure = false): Promise<ActionResponse> {
+    return this.request<ActionResponse>("/proxy", {
+      method: "POST",
+      body: JSON.stringify({ name, url, secure }),
+    });
+  }
+
+  async unproxy(name: string): Promise<void> {
+    return this.request("/unproxy", {
+      method: "POST",
+      body: JSON.stringify({ name }),
+    });
+  }
+
   async ignore(path: string): Promise<boolean> {
     const res = await this.request<ActionResponse>("/ignore", {
       method: "POST",
@@ -414,10 +586,42 @@ class DaemonApi {
     return this.request<string[]>("/php/versions");
   }
 
-  // SSL/HTTPS
-  async secure(): Promise<ActionResponse> {
+  async getPHPExtensions(version: string): Promise<PHPExtension[]> {
+    return this.request<PHPExtension[]>(`/php/${encodeURIComponent(version)}/extensions`);
+  }
+
+  // Installs the extension's package first when it isn't there
+  async setPHPExtension(version: string, name: string, enabled: boolean): Promise<ActionResponse> {
+    return this.request<ActionResponse>(`/php/${encodeURIComponent(version)}/extensions`, {
+      method: "POST",
+      body: JSON.stringify({ name, enabled }),
+    });
+  }
+
+  async getPHPIni(version: string): Promise<PHPIni> {
+    return this.request<PHPIni>(`/php/${encodeURIComponent(version)}/ini`);
+  }
+
+  // Without a value the override is dropped
+  async setPHPIni(version: string, name: string, value?: string): Promise<ActionResponse> {
+    return this.request<ActionResponse>(`/php/${encodeURIComponent(version)}/ini`, {
+      method: "POST",
+      body: JSON.stringify(value === undefined ? { name, unset: true } : { name, value }),
+    });
+  }
+
+  // SSL/HTTPS: all sites, or one site with its own certificate
+  async secure(site?: string): Promise<ActionResponse> {
     return this.request<ActionResponse>("/secure", {
       method: "POST",
+      body: site ? JSON.stringify({ site }) : undefined,
+    });
+  }
+
+  async unsecure(site?: string): Promise<ActionResponse> {
+    return this.request<ActionResponse>("/unsecure", {
+      method: "POST",
+      body: site ? JSON.stringify({ site }) : undefined,
     });
   }
 
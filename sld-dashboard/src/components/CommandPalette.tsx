@@ -16,6 +16,7 @@ import {
   Terminal,
 } from "lucide-react";
 import { cn } from "@/lib/utils";
+import { useSiteUrl } from "@/hooks/use-daemon";
 
 interface CommandItem {
   id: string;
@@ -30,6 +31,7 @@ interface CommandItem {
 interface Site {
   name: string;
   domain: string;
+  secure: boolean;
 }
 
 export function CommandPalette() {
@@ -41,6 +43,7 @@ export function CommandPalette() {
   const inputRef = useRef<HTMLInputElement>(null);
   const listRef = useRef<HTMLDivElement>(null);
   const navigate = useNavigate();
+  const siteUrl = useSiteUrl();
 
   // Fetch sites for dynamic commands
   useEffect(() => {
@@ -198,7 +201,7 @@ export function CommandPalette() {
       icon: ExternalLink,
       category: "sites" as const,
       action: () => {
-        window.open(`http://${site.domain}`, "_blank");
+        window.open(siteUrl(site), "_blank");
       },
       keywords: ["browser", "visit"],
     },
@@ -3,6 +3,7 @@ import { useEffect, useRef } from "react";
 import {
   api,
   type SLDState,
+  type DaemonConfig,
   type Project,
   type ServiceStatus,
   type Plugin,
@@ -12,11 +13,13 @@ import {
   type Tunnel,
 } from "@/api/daemon";
 import { useAppStore } from "@/stores/useAppStore";
+import { getSiteUrl } from "@/lib/utils";
 import { useToast } from "@/hooks/useToast";
 
 // Keys
 export const queryKeys = {
   state: ["state"],
+  config: ["config"],
   sites: ["sites"],
   services: ["services"],
   health: ["health"],
@@ -31,6 +34,27 @@ export function useSldState() {
   });
 }
 
+export function useDaemonConfig() {
+  return useQuery<DaemonConfig>({
+    queryKey: queryKeys.config,
+    queryFn: () => api.getConfig(),
+    staleTime: Infinity, // Only changes when the daemon restarts
+  });
+}
+
+// Returns a function building site URLs with the ports nginx listens on
+export function useSiteUrl() {
+  const { data: state } = useSldState();
+  const { data: config } = useDaemonConfig();
+  return (site: { domain: string; secure?: boolean }) =>
+    getSiteUrl(
+      site.domain,
+      !!site.secure,
+      state?.port,
+      config?.config.https_port
+    );
+}
+
 export function useSites() {
   return useQuery<Project[]>({
     queryKey: queryKeys.sites,
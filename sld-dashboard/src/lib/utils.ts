@@ -58,6 +58,21 @@ export function getProjectUrl(
   return `${protocol}://${name}.${tld}`;
 }
 
+/**
+ * Build the URL nginx serves a site at, with the port when it isn't the
+ * default one for the scheme
+ */
+export function getSiteUrl(
+  domain: string,
+  secure: boolean,
+  httpPort: string = "80",
+  httpsPort: number = 443
+): string {
+  const port = secure ? String(httpsPort) : httpPort;
+  const suffix = port === (secure ? "443" : "80") ? "" : `:${port}`;
+  return `${secure ? "https" : "http"}://${domain}${suffix}`;
+}
+
 /**
  * Format a date string
  */
@@ -1991,8 +1991,7 @@ $mysqli->close();
                       onClick={() =>
                         window.open(
                           `${
-                            import.meta.env.VITE_API_URL ||
-                            "http://localhost:2025"
+                            import.meta.env.VITE_API_URL || ""
                           }/api/db/snapshots/download?id=${snap.filename}`,
                           "_blank",
                         )
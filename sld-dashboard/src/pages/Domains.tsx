@@ -15,13 +15,19 @@ import {
   CardContent,
 } from "@/components/ui/Card";
 import { Button } from "@/components/ui/Button";
-import { useSites, useSldState, useSecureMutation } from "@/hooks/use-daemon";
+import {
+  useSites,
+  useSldState,
+  useSecureMutation,
+  useSiteUrl,
+} from "@/hooks/use-daemon";
 import { cn } from "@/lib/utils";
 
 export default function Domains() {
   const { data: projects = [] } = useSites();
   const { data: state } = useSldState();
   const secureMutation = useSecureMutation();
+  const siteUrl = useSiteUrl();
 
   const enableSecure = () => {
     secureMutation.mutate();
@@ -38,8 +44,7 @@ export default function Domains() {
   }, [projects]);
 
   const handleOpenDomain = (domain: string, secure: boolean) => {
-    const protocol = secure ? "https" : "http";
-    window.open(`${protocol}://${domain}`, "_blank");
+    window.open(siteUrl({ domain, secure }), "_blank");
   };
 
   return (
@@ -102,7 +107,7 @@ export default function Domains() {
                       HTTPS is enabled globally
                     </p>
                     <p className="text-sm text-[var(--muted-foreground)]">
-                      All .{state.tld} domains are secured with mkcert
+                      All .{state.tld} domains are secured with SLD CA
                       certificates
                     </p>
                   </div>
@@ -125,7 +125,7 @@ function PluginCard({ plugin }: { plugin: Plugin }) {
             </button>
           )}
 
-          {plugin.id === "mailhog" && isRunning && (
+          {plugin.ui_port && isRunning && (
             <button
               className={cn(
                 "flex items-center gap-1.5 px-3 py-1.5 rounded-lg text-sm",
@@ -133,7 +133,7 @@ function PluginCard({ plugin }: { plugin: Plugin }) {
                 "hover:bg-[var(--primary)]/20",
                 "transition-colors duration-200"
               )}
-              onClick={() => window.open("http://localhost:8025", "_blank")}
+              onClick={() => window.open(`http://localhost:${plugin.ui_port}`, "_blank")}
             >
               <ExternalLink size={14} />
               Open UI
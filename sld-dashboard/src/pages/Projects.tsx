@@ -22,6 +22,7 @@ import {
   useShareStatus,
   useShareStartMutation,
   useShareStopMutation,
+  useSiteUrl,
 } from "@/hooks/use-daemon";
 import { Modal } from "@/components/ui/Modal";
 import { CreateProjectModal } from "@/components/CreateProjectModal";
@@ -33,6 +34,7 @@ import { Code, Settings2, Terminal } from "lucide-react";
 export default function Projects() {
   const { data: projects = [], isLoading } = useSites();
   const { data: editors = [] } = useEditors();
+  const siteUrl = useSiteUrl();
   const ignoreMutation = useIgnoreMutation();
   const unlinkMutation = useUnlinkMutation();
   const openEditorMutation = useOpenInEditorMutation();
@@ -224,7 +226,7 @@ export default function Projects() {
 
               <div className="flex flex-col gap-2">
                 <a
-                  href={`http://${project.domain}`}
+                  href={siteUrl(project)}
                   target="_blank"
                   rel="noopener noreferrer"
                   className="flex items-center justify-center gap-2 w-full py-2.5 bg-[var(--primary)]/10 text-[var(--primary)] hover:bg-[var(--primary)] hover:text-white rounded-lg font-medium transition-all group-hover:shadow-md"
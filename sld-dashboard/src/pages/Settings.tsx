@@ -17,6 +17,7 @@ import { Button } from "@/components/ui/Button";
 import { useAppStore } from "@/stores/useAppStore";
 import {
   useSldState,
+  useDaemonConfig,
   useSwitchPHPMutation,
   usePHPVersions,
 } from "@/hooks/use-daemon";
@@ -110,6 +111,7 @@ function Select({
 export default function Settings() {
   const { theme, toggleTheme } = useAppStore();
   const { data: state, isLoading: isStateLoading } = useSldState();
+  const { data: daemonConfig } = useDaemonConfig();
   const switchPHPMutation = useSwitchPHPMutation();
   const { data: phpVersions, isLoading: isVersionsLoading } = usePHPVersions();
   const { toast } = useToast();
@@ -230,6 +232,38 @@ export default function Settings() {
             readOnly
           />
         </SettingsRow>
+        <SettingsRow
+          label="HTTPS Port"
+          description="Port for secured sites (https_port in config.yaml)"
+        >
+          <input
+            type="text"
+            value={daemonConfig?.config.https_port ?? 443}
+            className={cn(
+              "w-20 px-3 py-2 rounded-lg text-center",
+              "bg-[var(--input)] border border-[var(--border)]",
+              "text-[var(--foreground)] font-mono",
+              "focus:outline-none focus:border-[var(--ring)]"
+            )}
+            readOnly
+          />
+        </SettingsRow>
+        <SettingsRow
+          label="Daemon API"
+          description="Address the dashboard and CLI reach the daemon on (api_port, bind_address)"
+        >
+          <span className="font-mono text-sm text-[var(--foreground)]">
+            {daemonConfig?.api_url || "…"}
+          </span>
+        </SettingsRow>
+        <SettingsRow
+          label="Projects Directory"
+          description="Where new projects are created (projects_dir)"
+        >
+          <span className="font-mono text-sm text-[var(--foreground)]">
+            {daemonConfig?.config.projects_dir || "…"}
+          </span>
+        </SettingsRow>
         <SettingsRow
           label="TLD Domain"
           description="Top-level domain for local sites"
@@ -11,6 +11,7 @@ import {
   Wifi,
 } from "lucide-react";
 import { cn } from "@/lib/utils";
+import { useSiteUrl } from "@/hooks/use-daemon";
 
 interface Tunnel {
   site: string;
@@ -22,6 +23,7 @@ interface Site {
   name: string;
   domain: string;
   path: string;
+  secure: boolean;
   type: "parked" | "linked";
 }
 
@@ -32,6 +34,7 @@ export default function Share() {
   const [starting, setStarting] = useState<string | null>(null);
   const [stopping, setStopping] = useState<string | null>(null);
   const [copied, setCopied] = useState<string | null>(null);
+  const siteUrl = useSiteUrl();
 
   const fetchData = async () => {
     try {
@@ -209,9 +212,14 @@ export default function Share() {
                           </span>
                         )}
                       </div>
-                      <p className="text-sm text-[var(--muted-foreground)]">
-                        {site.domain}
-                      </p>
+                      <a
+                        href={siteUrl(site)}
+                        target="_blank"
+                        rel="noopener noreferrer"
+                        className="text-sm text-[var(--muted-foreground)] hover:text-[var(--foreground)]"
+                      >
+                        {siteUrl(site)}
+                      </a>
                     </div>
                   </div>
 
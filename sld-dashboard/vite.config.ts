@@ -20,7 +20,8 @@ export default defineConfig({
     port: 5173,
     proxy: {
       "/api": {
-        target: "http://localhost:2025",
+        // Match api_port/bind_address from the daemon config when it differs
+        target: process.env.SLD_API_URL || "http://localhost:2025",
         changeOrigin: true,
       },
     },